@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	stderr "errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/config"
+	"github.com/skrpld/NearBeee/internal/core/database/postgres"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/internal/core/repository"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/hash"
+)
+
+const (
+	benchEmail = "geobench@nearbeee.invalid"
+
+	legacyQuery = `SELECT post_id FROM posts
+         WHERE calculate_distance($1, $2, latitude, longitude) <= $3
+         ORDER BY calculate_distance($1, $2, latitude, longitude)
+         LIMIT $4`
+
+	seedQuery = `INSERT INTO posts (user_id, title, content, idempotency_key, latitude, longitude)
+			SELECT $1, 'geobench', 'geobench', 'geobench-' || uuid_generate_v4(),
+				CASE WHEN random() < 0.5 THEN c.lat + (random() - 0.5) ELSE random() * 130 - 60 END,
+				CASE WHEN random() < 0.5 THEN c.lon + (random() - 0.5) ELSE random() * 360 - 180 END
+			FROM generate_series(1, $2) AS s(i)
+			JOIN (VALUES (0, 55.75, 37.62), (1, 40.71, -74.01), (2, 35.68, 139.69), (3, 51.51, -0.13), (4, -33.87, 151.21))
+				AS c(n, lat, lon) ON c.n = s.i % 5`
+)
+
+var cities = [][2]float64{{55.75, 37.62}, {40.71, -74.01}, {35.68, 139.69}, {51.51, -0.13}, {-33.87, 151.21}}
+
+type timings []time.Duration
+
+func (t timings) String() string {
+	if len(t) == 0 {
+		return "n/a"
+	}
+
+	sorted := slices.Clone(t)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	return fmt.Sprintf("mean %v, p50 %v, p95 %v", total/time.Duration(len(sorted)),
+		sorted[len(sorted)/2], sorted[len(sorted)*95/100])
+}
+
+func main() {
+	posts := flag.Int("posts", 1_000_000, "number of posts to seed")
+	queries := flag.Int("queries", 100, "number of proximity queries per strategy")
+	radius := flag.Float64("radius", 5, "search radius in km")
+	count := flag.Int64("count", 50, "posts returned per query")
+	keep := flag.Bool("keep", false, "keep the seeded posts after the run")
+	flag.Parse()
+
+	if err := config.InitConfig(); err != nil {
+		log.Fatal(err)
+	}
+	cfg := config.GetConfig()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	postgresDB, err := postgres.NewPostgresDB(cfg.PostgresConfig, ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer postgresDB.Close()
+
+	repo := repository.NewPostgresRepository(postgresDB)
+
+	user, err := benchUser(repo)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !*keep {
+		defer func() {
+			if err := repo.DeleteUser(user.UserId); err != nil {
+				log.Printf("cleanup: %v", err)
+			}
+		}()
+	}
+
+	if err = seed(postgresDB, user.UserId, *posts); err != nil {
+		log.Fatal(err)
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+
+	var legacy, indexed timings
+	var setMismatches, orderMismatches int
+
+	for range *queries {
+		city := cities[rnd.Intn(len(cities))]
+		latitude := city[0] + rnd.Float64() - 0.5
+		longitude := city[1] + rnd.Float64() - 0.5
+
+		start := time.Now()
+		expected, err := legacyPostIds(postgresDB, latitude, longitude, *radius, *count)
+		if err != nil {
+			log.Fatal(err)
+		}
+		legacy = append(legacy, time.Since(start))
+
+		start = time.Now()
+		result, _, err := repo.GetPostsByLocation(latitude, longitude, *radius, uuid.Nil, nil, *count)
+		if err != nil {
+			log.Fatal(err)
+		}
+		indexed = append(indexed, time.Since(start))
+
+		actual := make([]uuid.UUID, 0, len(result))
+		for _, post := range result {
+			actual = append(actual, post.PostId)
+		}
+
+		if !slices.Equal(expected, actual) {
+			orderMismatches++
+			if !sameSet(expected, actual) {
+				setMismatches++
+			}
+		}
+	}
+
+	fmt.Printf("posts: %d seeded, radius %.1f km, limit %d, %d queries\n", *posts, *radius, *count, *queries)
+	fmt.Printf("haversine scan: %s\n", legacy)
+	fmt.Printf("spatial index:  %s\n", indexed)
+	fmt.Printf("result mismatches: %d (order only: %d)\n", setMismatches, orderMismatches-setMismatches)
+}
+
+func benchUser(repo *repository.PostgresRepository) (*entities.User, error) {
+	user, err := repo.GetUserByEmail(benchEmail)
+	if err == nil {
+		return user, nil
+	}
+	if !stderr.Is(err, errors.ErrInvalidEmail) {
+		return nil, err
+	}
+
+	passwordHash, err := hash.HashString(uuid.NewString())
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.CreateUser(benchEmail, passwordHash)
+}
+
+func seed(db *postgres.PostgresDB, userId uuid.UUID, target int) error {
+	var existing int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM posts WHERE user_id = $1`, userId).Scan(&existing); err != nil {
+		return err
+	}
+
+	if missing := target - existing; missing > 0 {
+		log.Printf("seeding %d posts", missing)
+
+		start := time.Now()
+		if _, err := db.Exec(seedQuery, userId, missing); err != nil {
+			return err
+		}
+		log.Printf("seeded in %v", time.Since(start))
+	}
+
+	_, err := db.Exec(`ANALYZE posts`)
+	return err
+}
+
+func legacyPostIds(db *postgres.PostgresDB, latitude, longitude, radius float64, count int64) ([]uuid.UUID, error) {
+	rows, err := db.Query(legacyQuery, latitude, longitude, radius, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	postIds := make([]uuid.UUID, 0, count)
+	for rows.Next() {
+		var postId uuid.UUID
+		if err = rows.Scan(&postId); err != nil {
+			return nil, err
+		}
+		postIds = append(postIds, postId)
+	}
+
+	return postIds, rows.Err()
+}
+
+func sameSet(a, b []uuid.UUID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(a))
+	for _, id := range a {
+		seen[id] = struct{}{}
+	}
+	for _, id := range b {
+		if _, ok := seen[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -11,7 +11,11 @@ import (
 	"github.com/skrpld/NearBeee/internal/core/database/postgres"
 	"github.com/skrpld/NearBeee/internal/core/logger"
 	"github.com/skrpld/NearBeee/internal/core/repository"
+	"github.com/skrpld/NearBeee/internal/core/service"
 	"github.com/skrpld/NearBeee/internal/transport/rest/servers"
+	"github.com/skrpld/NearBeee/pkg/utils/geocoder"
+	"github.com/skrpld/NearBeee/pkg/utils/mail"
+	"github.com/skrpld/NearBeee/pkg/utils/storage"
 
 	"github.com/skrpld/NearBeee/internal/config"
 )
@@ -19,7 +23,6 @@ import (
 // TODO:
 //  redis
 //  kafka?
-//  проверка широты\долготы
 
 func main() {
 	ctx := context.Background()
@@ -51,15 +54,55 @@ func main() {
 		return
 	}
 
+	mailer, err := mail.NewMailer(cfg.MailConfig)
+	if err != nil {
+		zapLogger.Error("mail.NewMailer", logger.Error(err))
+		return
+	}
+
+	validator, err := mail.NewValidatorFromConfig(cfg.ValidationConfig)
+	if err != nil {
+		zapLogger.Error("mail.NewValidatorFromConfig", logger.Error(err))
+		return
+	}
+
+	mediaStorage, err := storage.NewStorage(cfg.StorageConfig)
+	if err != nil {
+		zapLogger.Error("storage.NewStorage", logger.Error(err))
+		return
+	}
+
+	places, err := geocoder.NewGeocoder(cfg.GeocoderConfig)
+	if err != nil {
+		zapLogger.Error("geocoder.NewGeocoder", logger.Error(err))
+		return
+	}
+
 	postgresRepo := repository.NewPostgresRepository(postgresDB)
 	mongodbRepo := repository.NewMongodbRepository(mongoDB)
 
-	server, err := servers.NewHttpServer(cfg.HttpServerConfig, postgresRepo, mongodbRepo, zapLogger)
+	if err = service.NormalizeLegacyEmails(postgresRepo, zapLogger); err != nil {
+		zapLogger.Error("service.NormalizeLegacyEmails", logger.Error(err))
+	}
+
+	if err = service.BackfillMessageCounts(dbCtx, postgresRepo, mongodbRepo, zapLogger); err != nil {
+		zapLogger.Error("service.BackfillMessageCounts", logger.Error(err))
+	}
+
+	clusters := service.NewClusterCache(cfg.PostsConfig)
+
+	server, err := servers.NewHttpServer(cfg.HttpServerConfig, cfg.AuthConfig, cfg.OIDCConfig, cfg.PostsConfig, cfg.PaginationConfig, cfg.ProfileConfig, cfg.AccountConfig, mailer, validator, mediaStorage, places, clusters, postgresRepo, mongodbRepo, zapLogger)
 	if err != nil {
 		zapLogger.Error("servers.NewNearBeeeServer", logger.Error(err))
 		return
 	}
 
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+
+	deletionWorker := service.NewAccountDeletionWorker(postgresRepo, mongodbRepo, mediaStorage, clusters, cfg.AccountConfig, zapLogger)
+	go deletionWorker.Run(workerCtx)
+
 	graceChan := make(chan os.Signal, 1)
 	signal.Notify(graceChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -70,6 +113,8 @@ func main() {
 	}()
 	<-graceChan
 
+	stopWorkers()
+
 	if err = server.Stop(); err != nil {
 		zapLogger.Error("server.Stop", logger.Error(err))
 	}
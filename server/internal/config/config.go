@@ -10,8 +10,14 @@ import (
 	"github.com/skrpld/NearBeee/internal/core/database/mongodb"
 	"github.com/skrpld/NearBeee/internal/core/database/postgres"
 	"github.com/skrpld/NearBeee/internal/core/logger"
+	"github.com/skrpld/NearBeee/internal/core/service"
 	"github.com/skrpld/NearBeee/internal/transport/rest/servers"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/utils/geocoder"
 	"github.com/skrpld/NearBeee/pkg/utils/jwt"
+	"github.com/skrpld/NearBeee/pkg/utils/mail"
+	"github.com/skrpld/NearBeee/pkg/utils/oidc"
+	"github.com/skrpld/NearBeee/pkg/utils/storage"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
@@ -23,6 +29,18 @@ type Config struct {
 	postgres.PostgresConfig  `mapstructure:",squash"`
 	logger.LoggerConfig      `mapstructure:",squash"`
 	jwt.JWTConfig            `mapstructure:",squash"`
+	mail.MailConfig          `mapstructure:",squash"`
+	mail.ValidationConfig    `mapstructure:",squash"`
+	service.AuthConfig       `mapstructure:",squash"`
+	service.PostsConfig      `mapstructure:",squash"`
+	service.PaginationConfig `mapstructure:",squash"`
+	service.ProfileConfig    `mapstructure:",squash"`
+	service.AccountConfig    `mapstructure:",squash"`
+	storage.StorageConfig    `mapstructure:",squash"`
+	geocoder.GeocoderConfig  `mapstructure:",squash"`
+	oidc.OIDCConfig          `mapstructure:",squash"`
+	web.CookieConfig         `mapstructure:",squash"`
+	web.ProxyConfig          `mapstructure:",squash"`
 }
 
 var (
@@ -76,9 +94,25 @@ func reloadConfig() error {
 		return err
 	}
 
-	currentConfig.Store(&newCfg)
+	if err := jwt.UpdateJWTConfig(&newCfg.JWTConfig, newCfg.Secret); err != nil {
+		return err
+	}
 
-	jwt.UpdateJWTConfig(&newCfg.JWTConfig)
+	if err := web.UpdateCookieConfig(&newCfg.CookieConfig); err != nil {
+		return err
+	}
+
+	if err := web.UpdateProxyConfig(&newCfg.ProxyConfig); err != nil {
+		return err
+	}
+
+	providerConfigs, err := oidc.LoadProviderConfigs(newCfg.Providers, v.GetString)
+	if err != nil {
+		return err
+	}
+	newCfg.ProviderConfigs = providerConfigs
+
+	currentConfig.Store(&newCfg)
 
 	return nil
 }
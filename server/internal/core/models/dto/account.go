@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DeleteAccountRequest struct {
+	UserId   uuid.UUID `json:"-"`
+	Password string    `json:"password"`
+	Token    string    `json:"token"`
+}
+type DeleteAccountResponse struct {
+	ScheduledFor     time.Time `json:"scheduled_for,omitzero"`
+	ConfirmationSent bool      `json:"confirmation_sent,omitempty"`
+}
+
+type ExportAccountRequest struct {
+	UserId uuid.UUID `json:"-"`
+}
+type ExportAccountResponse struct {
+	FileName string
+	Data     []byte
+}
+
+func (r *ExportAccountResponse) WriteResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", r.FileName))
+
+	_, err := w.Write(r.Data)
+	return err
+}
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+)
+
+type AdminUser struct {
+	UserId    uuid.UUID     `json:"user_id"`
+	Email     string        `json:"email"`
+	Verified  bool          `json:"verified"`
+	Role      entities.Role `json:"role"`
+	BannedAt  *time.Time    `json:"banned_at,omitempty"`
+	BanReason *string       `json:"ban_reason,omitempty"`
+}
+
+type SearchUsersRequest struct {
+	Query  string        `json:"-"`
+	Role   entities.Role `json:"-"`
+	Count  int64         `json:"-"`
+	Offset int64         `json:"-"`
+}
+type SearchUsersResponse struct {
+	Users []*AdminUser `json:"users"`
+}
+
+type BanUserRequest struct {
+	ActorId   uuid.UUID     `json:"-"`
+	ActorRole entities.Role `json:"-"`
+	UserId    string        `json:"-"`
+	Reason    string        `json:"reason"`
+}
+type BanUserResponse struct {
+	User *AdminUser `json:"user"`
+}
+
+type UnbanUserRequest struct {
+	ActorId   uuid.UUID     `json:"-"`
+	ActorRole entities.Role `json:"-"`
+	UserId    string        `json:"-"`
+}
+type UnbanUserResponse struct {
+	User *AdminUser `json:"user"`
+}
+
+type SetUserRoleRequest struct {
+	ActorId   uuid.UUID     `json:"-"`
+	ActorRole entities.Role `json:"-"`
+	UserId    string        `json:"-"`
+	Role      entities.Role `json:"role"`
+}
+type SetUserRoleResponse struct {
+	User *AdminUser `json:"user"`
+}
+
+type ForceDeletePostRequest struct {
+	PostId string `json:"-"`
+}
+type ForceDeletePostResponse struct {
+	PostId string `json:"post_id"`
+}
+
+type ForceDeleteMessageRequest struct {
+	MessageId string `json:"-"`
+}
+type ForceDeleteMessageResponse struct {
+	Success bool `json:"success"`
+}
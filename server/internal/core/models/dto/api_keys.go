@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+)
+
+type CreateApiKeyRequest struct {
+	UserId    uuid.UUID        `json:"-"`
+	Name      string           `json:"name"`
+	Scopes    []entities.Scope `json:"scopes"`
+	ExpiresAt *time.Time       `json:"expires_at"`
+}
+type CreateApiKeyResponse struct {
+	ApiKey *entities.ApiKey `json:"api_key"`
+	Key    string           `json:"key"`
+}
+
+type GetApiKeysRequest struct {
+	UserId uuid.UUID
+}
+type GetApiKeysResponse struct {
+	ApiKeys []*entities.ApiKey `json:"api_keys"`
+}
+
+type RevokeApiKeyRequest struct {
+	UserId uuid.UUID
+	KeyId  string
+}
+type RevokeApiKeyResponse struct {
+	Success bool `json:"success"`
+}
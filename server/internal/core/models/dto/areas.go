@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+)
+
+type CreateAreaRequest struct {
+	Slug     string          `json:"slug"`
+	Name     string          `json:"name"`
+	Geometry json.RawMessage `json:"geometry"`
+}
+
+type CreateAreaResponse struct {
+	Area     *entities.Area  `json:"area"`
+	Geometry json.RawMessage `json:"geometry"`
+}
+
+type GetAreaRequest struct {
+	Slug string `json:"-"`
+}
+
+type GetAreaResponse struct {
+	Area     *entities.Area  `json:"area"`
+	Geometry json.RawMessage `json:"geometry"`
+}
+
+type DeleteAreaRequest struct {
+	Slug string `json:"-"`
+}
+
+type DeleteAreaResponse struct {
+	Slug string `json:"slug"`
+}
+
+type GetAreaPostsRequest struct {
+	Slug         string    `json:"-"`
+	ViewerId     uuid.UUID `json:"-"`
+	Count        int64     `json:"count"`
+	Cursor       string    `json:"cursor"`
+	ExpandAuthor bool      `json:"-"`
+}
+
+type GetAreaPostsResponse struct {
+	Posts      []*entities.Post `json:"posts"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	HasMore    bool             `json:"has_more"`
+}
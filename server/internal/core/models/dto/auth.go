@@ -1,27 +1,74 @@
 package dto
 
 import (
+	"github.com/google/uuid"
 	"github.com/skrpld/NearBeee/internal/core/models/entities"
 )
 
 type RegistrateUserRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name"`
+	UserAgent  string `json:"-"`
+	IpAddress  string `json:"-"`
 }
 type RegistrateUserResponse struct {
-	UserId       string `json:"user_id"`
-	RefreshToken string `json:"-"`
-	AccessToken  string `json:"-"`
+	UserId           string `json:"user_id"`
+	SessionId        string `json:"session_id"`
+	VerificationSent bool   `json:"verification_sent"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	AccessToken      string `json:"-"`
 }
 
 type LoginUserRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name"`
+	UserAgent  string `json:"-"`
+	IpAddress  string `json:"-"`
 }
 type LoginUserResponse struct {
-	UserId       string `json:"user_id"`
-	RefreshToken string `json:"refresh_token"`
-	AccessToken  string `json:"-"`
+	UserId            string `json:"user_id"`
+	SessionId         string `json:"session_id,omitempty"`
+	Verified          bool   `json:"verified"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+	RefreshToken      string `json:"refresh_token,omitempty"`
+	AccessToken       string `json:"-"`
+}
+
+type VerifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+	DeviceName     string `json:"device_name"`
+	UserAgent      string `json:"-"`
+	IpAddress      string `json:"-"`
+}
+
+type SetupTwoFactorRequest struct {
+	UserId uuid.UUID
+}
+type SetupTwoFactorResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthUri string `json:"otpauth_uri"`
+}
+
+type ConfirmTwoFactorRequest struct {
+	UserId uuid.UUID `json:"-"`
+	Code   string    `json:"code"`
+}
+type ConfirmTwoFactorResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type DisableTwoFactorRequest struct {
+	UserId       uuid.UUID `json:"-"`
+	Code         string    `json:"code"`
+	RecoveryCode string    `json:"recovery_code"`
+}
+type DisableTwoFactorResponse struct {
+	Success bool `json:"success"`
 }
 
 type RefreshUserTokenRequest struct {
@@ -29,7 +76,48 @@ type RefreshUserTokenRequest struct {
 }
 type RefreshUserTokenResponse struct {
 	UserId       string `json:"user_id"`
-	RefreshToken string `json:"-"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	AccessToken  string `json:"-"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+type VerifyEmailResponse struct {
+	Success bool `json:"success"`
+}
+
+type ResendVerificationRequest struct {
+	UserId uuid.UUID
+}
+type ResendVerificationResponse struct {
+	Success bool `json:"success"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+type ForgotPasswordResponse struct {
+	Success bool `json:"success"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+type ResetPasswordResponse struct {
+	Success bool `json:"success"`
+}
+
+type ChangePasswordRequest struct {
+	UserId          uuid.UUID         `json:"-"`
+	Session         *entities.Session `json:"-"`
+	CurrentPassword string            `json:"current_password"`
+	NewPassword     string            `json:"new_password"`
+}
+type ChangePasswordResponse struct {
+	SessionId    string `json:"session_id"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 	AccessToken  string `json:"-"`
 }
 
@@ -38,5 +126,43 @@ type AuthorizeUserRequest struct {
 }
 
 type AuthorizeUserResponse struct {
-	User *entities.User
+	User    *entities.User
+	Session *entities.Session
+	ApiKey  *entities.ApiKey
+}
+
+type AuthorizeApiKeyRequest struct {
+	Key string
+}
+
+type GetSessionsRequest struct {
+	UserId    uuid.UUID
+	SessionId uuid.UUID
+}
+type GetSessionsResponse struct {
+	CurrentSessionId string              `json:"current_session_id"`
+	Sessions         []*entities.Session `json:"sessions"`
+}
+
+type RevokeSessionRequest struct {
+	UserId    uuid.UUID
+	SessionId string
+}
+type RevokeSessionResponse struct {
+	Success bool `json:"success"`
+}
+
+type LogoutUserRequest struct {
+	UserId    uuid.UUID
+	SessionId uuid.UUID
+}
+type LogoutUserResponse struct {
+	Success bool `json:"success"`
+}
+
+type LogoutAllRequest struct {
+	UserId uuid.UUID
+}
+type LogoutAllResponse struct {
+	Success bool `json:"success"`
 }
@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"github.com/skrpld/NearBeee/pkg/geo"
+	"github.com/skrpld/NearBeee/pkg/utils/geocoder"
+)
+
+type ReverseGeocodeRequest struct {
+	geo.Coordinate
+}
+
+type ReverseGeocodeResponse struct {
+	Place    geocoder.Place `json:"place"`
+	Distance float64        `json:"distance"`
+}
@@ -17,7 +17,8 @@ type CreateMessageResponse struct {
 }
 
 type GetMessageByMessageIdRequest struct {
-	MessageId string `json:"-"`
+	MessageId    string `json:"-"`
+	ExpandAuthor bool   `json:"-"`
 }
 
 type GetMessageByMessageIdResponse struct {
@@ -25,19 +26,27 @@ type GetMessageByMessageIdResponse struct {
 }
 
 type GetMessageByUserIdRequest struct {
-	UserId uuid.UUID `json:"-"`
-	Count  int64     `json:"count"`
+	UserId       uuid.UUID `json:"-"`
+	Count        int64     `json:"count"`
+	Cursor       string    `json:"cursor"`
+	ExpandAuthor bool      `json:"-"`
 }
 type GetMessageByUserIdResponse struct {
-	Messages []*entities.Message `json:"messages"`
+	Messages   []*entities.Message `json:"messages"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	HasMore    bool                `json:"has_more"`
 }
 
 type GetMessagesByPostIdRequest struct {
-	PostId string `json:"post_id"`
-	Count  int64  `json:"count"`
+	PostId       string `json:"post_id"`
+	Count        int64  `json:"count"`
+	Cursor       string `json:"cursor"`
+	ExpandAuthor bool   `json:"-"`
 }
 type GetMessagesByPostIdResponse struct {
-	Messages []*entities.Message `json:"messages"`
+	Messages   []*entities.Message `json:"messages"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	HasMore    bool                `json:"has_more"`
 }
 
 type UpdateMessageByIdRequest struct {
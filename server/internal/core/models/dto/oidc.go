@@ -0,0 +1,24 @@
+package dto
+
+import "time"
+
+type StartOIDCRequest struct {
+	Provider   string
+	DeviceName string
+}
+type StartOIDCResponse struct {
+	AuthUrl   string
+	State     string
+	ExpiresAt time.Time
+	Secure    bool
+}
+
+type OIDCCallbackRequest struct {
+	Provider  string
+	State     string
+	Code      string
+	Error     string
+	Cookie    string
+	UserAgent string
+	IpAddress string
+}
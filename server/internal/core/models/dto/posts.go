@@ -1,17 +1,21 @@
 package dto
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/geo"
+	"github.com/skrpld/NearBeee/pkg/mvt"
 )
 
 type CreatePostRequest struct {
-	UserId         uuid.UUID `json:"-"`
-	Title          string    `json:"title"`
-	Content        string    `json:"content"`
-	IdempotencyKey string    `json:"idempotency_key"`
-	Latitude       float64   `json:"latitude"`
-	Longitude      float64   `json:"longitude"`
+	UserId         uuid.UUID                  `json:"-"`
+	Title          string                     `json:"title"`
+	Content        string                     `json:"content"`
+	IdempotencyKey string                     `json:"idempotency_key"`
+	Precision      entities.LocationPrecision `json:"precision"`
+	geo.Coordinate
 }
 
 type CreatePostResponse struct {
@@ -19,27 +23,90 @@ type CreatePostResponse struct {
 }
 
 type GetPostsByUserIdRequest struct {
-	UserId uuid.UUID `json:"-"`
-	Count  int64     `json:"count"`
+	UserId       uuid.UUID `json:"-"`
+	Count        int64     `json:"count"`
+	Cursor       string    `json:"cursor"`
+	ExpandAuthor bool      `json:"-"`
 }
 
 type GetPostsByUserIdResponse struct {
-	Posts []*entities.Post `json:"posts"`
+	Posts      []*entities.Post `json:"posts"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	HasMore    bool             `json:"has_more"`
 }
 
 type GetPostsByLocationRequest struct {
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	Count     int64   `json:"count"`
-	Radius    float64 `json:"radius"`
+	geo.Coordinate
+	ViewerId     uuid.UUID `json:"-"`
+	Count        int64     `json:"count"`
+	Cursor       string    `json:"cursor"`
+	Radius       float64   `json:"radius"`
+	ExpandAuthor bool      `json:"-"`
 }
 
 type GetPostsByLocationResponse struct {
-	Posts []*entities.Post `json:"posts"`
+	Posts      []*entities.Post `json:"posts"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	HasMore    bool             `json:"has_more"`
+}
+
+type GetPostsFeedRequest struct {
+	geo.Coordinate
+	ViewerId     uuid.UUID `json:"-"`
+	Count        int64     `json:"count"`
+	Cursor       string    `json:"cursor"`
+	Radius       float64   `json:"radius"`
+	DebugScore   bool      `json:"-"`
+	ExpandAuthor bool      `json:"-"`
+}
+
+type GetPostsFeedResponse struct {
+	Posts      []*entities.Post `json:"posts"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	HasMore    bool             `json:"has_more"`
+}
+
+type GetPostsByBBoxRequest struct {
+	geo.BBox
+	ViewerId     uuid.UUID          `json:"-"`
+	Order        entities.PostOrder `json:"order"`
+	Count        int64              `json:"count"`
+	Cursor       string             `json:"cursor"`
+	ExpandAuthor bool               `json:"-"`
+}
+
+type GetPostsByBBoxResponse struct {
+	Posts      []*entities.Post `json:"posts"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	HasMore    bool             `json:"has_more"`
+}
+
+type GetPostClustersRequest struct {
+	BBox         geo.BBox
+	Zoom         int
+	ViewerId     uuid.UUID
+	ExpandAuthor bool
+}
+
+type GetPostClustersResponse struct {
+	Clusters []*entities.PostCluster `json:"clusters"`
+	Posts    []*entities.Post        `json:"posts,omitempty"`
+}
+
+type GetPostsTileRequest struct {
+	Tile mvt.Tile
+}
+
+type GetPostsTileResponse struct {
+	Body   []byte
+	ETag   string
+	MaxAge time.Duration
 }
 
 type GetPostByPostIdRequest struct {
-	PostId string `json:"-"`
+	PostId       string    `json:"-"`
+	ViewerId     uuid.UUID `json:"-"`
+	ExpandAuthor bool      `json:"-"`
 }
 
 type GetPostByPostIdResponse struct {
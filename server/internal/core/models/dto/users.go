@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+)
+
+type GetMyProfileRequest struct {
+	UserId uuid.UUID `json:"-"`
+}
+type GetMyProfileResponse struct {
+	Profile *entities.Profile `json:"profile"`
+}
+
+type UpdateMyProfileRequest struct {
+	UserId      uuid.UUID `json:"-"`
+	Handle      *string   `json:"handle"`
+	DisplayName *string   `json:"display_name"`
+	Bio         *string   `json:"bio"`
+}
+type UpdateMyProfileResponse struct {
+	Profile *entities.Profile `json:"profile"`
+}
+
+type UpdateAvatarRequest struct {
+	UserId uuid.UUID `json:"-"`
+	Body   io.Reader `json:"-"`
+}
+type UpdateAvatarResponse struct {
+	Profile *entities.Profile `json:"profile"`
+}
+
+type GetProfileByHandleRequest struct {
+	Handle string `json:"-"`
+}
+type GetProfileByHandleResponse struct {
+	Profile *entities.Profile `json:"profile"`
+}
@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DeletionStepMessages  = "messages"
+	DeletionStepAnonymize = "anonymize"
+	DeletionStepAvatar    = "avatar"
+	DeletionStepUser      = "user"
+)
+
+var DeletionSteps = []string{
+	DeletionStepMessages,
+	DeletionStepAnonymize,
+	DeletionStepAvatar,
+	DeletionStepUser,
+}
+
+type AccountDeletion struct {
+	UserId       uuid.UUID
+	Step         string
+	RequestedAt  time.Time
+	ScheduledFor time.Time
+	StartedAt    *time.Time
+	Attempts     int
+	LastError    *string
+}
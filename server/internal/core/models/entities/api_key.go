@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Scope string
+
+const (
+	PostsReadScope     Scope = "posts:read"
+	PostsWriteScope    Scope = "posts:write"
+	MessagesReadScope  Scope = "messages:read"
+	MessagesWriteScope Scope = "messages:write"
+)
+
+var scopes = map[Scope]struct{}{
+	PostsReadScope:     {},
+	PostsWriteScope:    {},
+	MessagesReadScope:  {},
+	MessagesWriteScope: {},
+}
+
+func (s Scope) IsValid() bool {
+	_, ok := scopes[s]
+	return ok
+}
+
+type ApiKey struct {
+	KeyId      uuid.UUID  `json:"key_id"`
+	UserId     uuid.UUID  `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	SecretHash string     `json:"-"`
+	Scopes     []Scope    `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k *ApiKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/pkg/geo"
+)
+
+type Area struct {
+	AreaId    uuid.UUID        `json:"area_id"`
+	Slug      string           `json:"slug"`
+	Name      string           `json:"name"`
+	Geometry  geo.MultiPolygon `json:"-"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type PostArea struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
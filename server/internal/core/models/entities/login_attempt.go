@@ -0,0 +1,10 @@
+package entities
+
+import "time"
+
+type LoginAttempt struct {
+	Key          string
+	Failures     int
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+}
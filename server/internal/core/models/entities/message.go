@@ -13,4 +13,5 @@ type Message struct {
 	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Author    *Author   `json:"author,omitempty"`
 }
@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+type PageCursor struct {
+	CreatedAt time.Time `json:"t"`
+	Distance  float64   `json:"d,omitempty"`
+	Score     float64   `json:"s,omitempty"`
+	AsOf      time.Time `json:"a,omitzero"`
+	Id        string    `json:"id"`
+}
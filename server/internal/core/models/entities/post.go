@@ -7,13 +7,97 @@ import (
 )
 
 type Post struct {
-	PostId         uuid.UUID `json:"post_id"`
-	UserId         uuid.UUID `json:"user_id"`
-	Title          string    `json:"title"`
-	Content        string    `json:"content"`
-	IdempotencyKey string    `json:"idempotency_key"`
-	Latitude       float64   `json:"latitude"`
-	Longitude      float64   `json:"longitude"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	PostId         uuid.UUID         `json:"post_id"`
+	UserId         uuid.UUID         `json:"user_id"`
+	Title          string            `json:"title"`
+	Content        string            `json:"content"`
+	IdempotencyKey string            `json:"idempotency_key"`
+	Latitude       float64           `json:"latitude"`
+	Longitude      float64           `json:"longitude"`
+	Precision      LocationPrecision `json:"precision"`
+	Country        string            `json:"country,omitempty"`
+	Region         string            `json:"region,omitempty"`
+	Locality       string            `json:"locality,omitempty"`
+	MessageCount   int64             `json:"message_count"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	Areas          []PostArea        `json:"areas"`
+	Score          *PostScore        `json:"score,omitempty"`
+	Author         *Author           `json:"author,omitempty"`
+}
+
+// PostScore breaks a feed ranking down into its decays before weighting.
+type PostScore struct {
+	Total      float64 `json:"total"`
+	DistanceKm float64 `json:"distance_km"`
+	Distance   float64 `json:"distance"`
+	Recency    float64 `json:"recency"`
+	Engagement float64 `json:"engagement"`
+}
+
+// FeedScoring configures the feed ranking, a weighted sum of a distance decay,
+// a recency decay, both halving every half-life, and the log of a post's
+// engagement. Engagement is the message count alone: posts have no reactions
+// yet, and once they do their pre-aggregated count joins it under the same
+// weight rather than needing a weight of its own.
+type FeedScoring struct {
+	DistanceWeight   float64
+	RecencyWeight    float64
+	EngagementWeight float64
+	DistanceHalfLife float64
+	RecencyHalfLife  time.Duration
+}
+
+type LocationPrecision string
+
+const (
+	PrecisionExact         LocationPrecision = "exact"
+	PrecisionStreet        LocationPrecision = "street"
+	PrecisionNeighbourhood LocationPrecision = "neighbourhood"
+	PrecisionCity          LocationPrecision = "city"
+)
+
+func (p LocationPrecision) IsValid() bool {
+	return p == PrecisionExact || p.GeohashPrecision() > 0
+}
+
+// GeohashPrecision is the geohash length of the cell a post's public location
+// is confined to: about 150 m for street, 1.2 km for neighbourhood and 5 km for
+// city. Exact locations are not confined and report 0.
+func (p LocationPrecision) GeohashPrecision() int {
+	switch p {
+	case PrecisionStreet:
+		return 7
+	case PrecisionNeighbourhood:
+		return 6
+	case PrecisionCity:
+		return 5
+	}
+	return 0
+}
+
+type PostOrder string
+
+const (
+	// PostOrderRecent lists the newest posts first.
+	PostOrderRecent PostOrder = "recent"
+	// PostOrderScore lists the posts with the most messages first, newest first
+	// among equal counts. It is not the time-decayed score of the feed.
+	PostOrderScore PostOrder = "score"
+)
+
+func (o PostOrder) IsValid() bool {
+	switch o {
+	case PostOrderRecent, PostOrderScore:
+		return true
+	}
+	return false
+}
+
+type PostCluster struct {
+	Geohash   string      `json:"geohash"`
+	Latitude  float64     `json:"latitude"`
+	Longitude float64     `json:"longitude"`
+	Count     int64       `json:"count"`
+	PostIds   []uuid.UUID `json:"post_ids"`
 }
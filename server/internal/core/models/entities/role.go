@@ -0,0 +1,62 @@
+package entities
+
+type Role string
+
+const (
+	UserRole      Role = "user"
+	ModeratorRole Role = "moderator"
+	AdminRole     Role = "admin"
+)
+
+type Permission string
+
+const (
+	ViewUsersPermission        Permission = "users:read"
+	BanUsersPermission         Permission = "users:ban"
+	ManageRolesPermission      Permission = "users:role"
+	DeleteAnyPostPermission    Permission = "posts:delete_any"
+	DeleteAnyMessagePermission Permission = "messages:delete_any"
+	ManageAreasPermission      Permission = "areas:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	UserRole: {},
+	ModeratorRole: {
+		ViewUsersPermission,
+		BanUsersPermission,
+		DeleteAnyPostPermission,
+		DeleteAnyMessagePermission,
+	},
+	AdminRole: {
+		ViewUsersPermission,
+		BanUsersPermission,
+		ManageRolesPermission,
+		DeleteAnyPostPermission,
+		DeleteAnyMessagePermission,
+		ManageAreasPermission,
+	},
+}
+
+var roleRanks = map[Role]int{
+	UserRole:      0,
+	ModeratorRole: 1,
+	AdminRole:     2,
+}
+
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r Role) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Role) Outranks(other Role) bool {
+	return roleRanks[r] > roleRanks[other]
+}
@@ -7,9 +7,17 @@ import (
 )
 
 type User struct {
-	UserId                 uuid.UUID
-	Email                  string
-	PasswordHash           string
-	RefreshToken           string
-	RefreshTokenExpiryTime time.Time
+	UserId       uuid.UUID
+	Email        string
+	PasswordHash string
+	Verified     bool
+	Role         Role
+	BannedAt     *time.Time
+	BanReason    *string
+}
+
+// HasPassword reports whether the user can sign in with a password. Accounts
+// created through an identity provider have none until a password reset.
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != ""
 }
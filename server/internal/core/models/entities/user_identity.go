@@ -0,0 +1,15 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserIdentity struct {
+	Provider  string
+	Subject   string
+	UserId    uuid.UUID
+	Email     string
+	CreatedAt time.Time
+}
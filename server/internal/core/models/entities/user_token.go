@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EmailVerificationPurpose  = "email_verification"
+	PasswordResetPurpose      = "password_reset"
+	TwoFactorChallengePurpose = "two_factor_challenge"
+	AccountDeletionPurpose    = "account_deletion"
+)
+
+type UserToken struct {
+	TokenId   uuid.UUID
+	UserId    uuid.UUID
+	Purpose   string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,14 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserTOTP struct {
+	UserId          uuid.UUID
+	SecretEncrypted string
+	ConfirmedAt     *time.Time
+	LastUsedStep    int64
+}
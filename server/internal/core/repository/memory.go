@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/utils/cache"
+)
+
+type LoginAttemptsMemoryRepository struct {
+	mu       sync.Mutex
+	attempts *cache.Cache[string, entities.LoginAttempt]
+}
+
+func NewLoginAttemptsMemoryRepository(ttl time.Duration, maxEntries int) *LoginAttemptsMemoryRepository {
+	return &LoginAttemptsMemoryRepository{
+		attempts: cache.New[string, entities.LoginAttempt](ttl, maxEntries),
+	}
+}
+
+func (r *LoginAttemptsMemoryRepository) GetLoginAttempt(key string) (*entities.LoginAttempt, error) {
+	attempt, ok := r.attempts.Get(key)
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (r *LoginAttemptsMemoryRepository) RecordLoginFailure(key string, resetAfter time.Duration) (*entities.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	attempt, ok := r.attempts.Get(key)
+	if !ok || now.Sub(attempt.LastFailedAt) > resetAfter {
+		attempt = entities.LoginAttempt{Key: key}
+	}
+
+	attempt.Failures++
+	attempt.LastFailedAt = now
+	r.attempts.Set(key, attempt)
+
+	return &attempt, nil
+}
+
+func (r *LoginAttemptsMemoryRepository) LockLogin(key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts.Get(key)
+	if !ok {
+		attempt = entities.LoginAttempt{Key: key, LastFailedAt: time.Now()}
+	}
+
+	attempt.LockedUntil = &until
+	r.attempts.Set(key, attempt)
+
+	return nil
+}
+
+func (r *LoginAttemptsMemoryRepository) ResetLoginAttempts(key string) error {
+	r.attempts.Delete(key)
+	return nil
+}
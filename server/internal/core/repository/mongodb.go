@@ -25,7 +25,10 @@ func NewMongodbRepository(mongoDB *mongodb.MongoDB) *MongodbRepository {
 	}
 }
 
-const msgCollectionName = "messages"
+const (
+	msgCollectionName = "messages"
+	anonymizedContent = "[deleted]"
+)
 
 func (r *MongodbRepository) CreateMessage(ctx context.Context, postId, userId uuid.UUID, content string) (*entities.Message, error) {
 	newMsg := &dao.Message{
@@ -62,42 +65,34 @@ func (r *MongodbRepository) GetMessageByMessageId(ctx context.Context, msgId bso
 	return msg.ToEntity(), nil
 }
 
-func (r *MongodbRepository) GetMessageByUserId(ctx context.Context, userId uuid.UUID, count int64) ([]*entities.Message, error) {
-	opts := options.Find().
-		SetLimit(parseMongoLimit(count)).
-		SetSort(bson.M{"created_at": -1})
-
-	result, err := r.mongoDB.Collection(msgCollectionName).
-		Find(ctx, bson.M{"user_id": userId}, opts)
-	if err != nil {
-		return nil, err
-	}
-
-	defer result.Close(ctx)
+func (r *MongodbRepository) GetMessageByUserId(ctx context.Context, userId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Message, *entities.PageCursor, error) {
+	return r.findMessagesPage(ctx, bson.M{"user_id": userId}, after, limit)
+}
 
-	var msgs []dao.Message
+func (r *MongodbRepository) GetMessagesByPostId(ctx context.Context, postId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Message, *entities.PageCursor, error) {
+	return r.findMessagesPage(ctx, bson.M{"post_id": postId}, after, limit)
+}
 
-	if err = result.All(ctx, &msgs); err != nil {
-		return nil, err
-	}
+func (r *MongodbRepository) findMessagesPage(ctx context.Context, filter bson.M, after *entities.PageCursor, limit int64) ([]*entities.Message, *entities.PageCursor, error) {
+	if after != nil {
+		afterId, err := bson.ObjectIDFromHex(after.Id)
+		if err != nil {
+			return nil, nil, errors.ErrInvalidCursor
+		}
 
-	response := make([]*entities.Message, 0, len(msgs))
-	for _, msg := range msgs {
-		response = append(response, msg.ToEntity())
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": after.CreatedAt}},
+			bson.M{"created_at": after.CreatedAt, "_id": bson.M{"$lt": afterId}},
+		}
 	}
 
-	return response, nil
-}
-
-func (r *MongodbRepository) GetMessagesByPostId(ctx context.Context, postId uuid.UUID, count int64) ([]*entities.Message, error) {
 	opts := options.Find().
-		SetLimit(parseMongoLimit(count)).
-		SetSort(bson.M{"created_at": -1})
+		SetLimit(limit + 1).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
 
-	result, err := r.mongoDB.Collection(msgCollectionName).
-		Find(ctx, bson.M{"post_id": postId}, opts)
+	result, err := r.mongoDB.Collection(msgCollectionName).Find(ctx, filter, opts)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	defer result.Close(ctx)
@@ -105,7 +100,14 @@ func (r *MongodbRepository) GetMessagesByPostId(ctx context.Context, postId uuid
 	var msgs []dao.Message
 
 	if err = result.All(ctx, &msgs); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	var next *entities.PageCursor
+	if int64(len(msgs)) > limit {
+		msgs = msgs[:limit]
+		last := msgs[limit-1]
+		next = &entities.PageCursor{CreatedAt: last.CreatedAt, Id: last.MessageId.Hex()}
 	}
 
 	response := make([]*entities.Message, 0, len(msgs))
@@ -113,7 +115,7 @@ func (r *MongodbRepository) GetMessagesByPostId(ctx context.Context, postId uuid
 		response = append(response, msg.ToEntity())
 	}
 
-	return response, nil
+	return response, next, nil
 }
 
 func (r *MongodbRepository) UpdateMessageById(ctx context.Context, messageId bson.ObjectID, userId uuid.UUID, content string) (*entities.Message, error) {
@@ -142,24 +144,83 @@ func (r *MongodbRepository) UpdateMessageById(ctx context.Context, messageId bso
 	return msg.ToEntity(), nil
 }
 
-func (r *MongodbRepository) DeleteMessageById(ctx context.Context, messageId bson.ObjectID, userId uuid.UUID) error {
-	result, err := r.mongoDB.Collection(msgCollectionName).DeleteOne(ctx, bson.M{"_id": messageId, "user_id": userId})
+func (r *MongodbRepository) DeleteMessageById(ctx context.Context, messageId bson.ObjectID, userId uuid.UUID) (*entities.Message, error) {
+	return r.deleteMessage(ctx, bson.M{"_id": messageId, "user_id": userId})
+}
+
+func (r *MongodbRepository) ForceDeleteMessageById(ctx context.Context, messageId bson.ObjectID) (*entities.Message, error) {
+	return r.deleteMessage(ctx, bson.M{"_id": messageId})
+}
+
+func (r *MongodbRepository) deleteMessage(ctx context.Context, filter bson.M) (*entities.Message, error) {
+	var msg dao.Message
+
+	err := r.mongoDB.Collection(msgCollectionName).
+		FindOneAndDelete(ctx, filter).
+		Decode(&msg)
 	if err != nil {
-		return err
+		if stderr.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.ErrMsgNotFound
+		}
+		return nil, err
 	}
 
-	if result.DeletedCount == 0 {
-		return errors.ErrMsgNotFound
+	return msg.ToEntity(), nil
+}
+
+func (r *MongodbRepository) DeleteMessagesByPostId(ctx context.Context, postId uuid.UUID) error {
+	_, err := r.mongoDB.Collection(msgCollectionName).DeleteMany(ctx, bson.M{"post_id": postId})
+	return err
+}
+
+func (r *MongodbRepository) DeleteMessagesByPostIds(ctx context.Context, postIds []uuid.UUID) error {
+	if len(postIds) == 0 {
+		return nil
 	}
 
-	return nil
+	_, err := r.mongoDB.Collection(msgCollectionName).DeleteMany(ctx, bson.M{"post_id": bson.M{"$in": postIds}})
+	return err
 }
 
-func parseMongoLimit(limit int64) int64 {
-	if limit < 1 {
-		return 0
+func (r *MongodbRepository) AnonymizeMessagesByUserId(ctx context.Context, userId uuid.UUID) error {
+	update := bson.M{
+		"$set": bson.M{
+			"user_id":    uuid.Nil,
+			"content":    anonymizedContent,
+			"updated_at": currentTimeUTC(),
+		},
+	}
+
+	_, err := r.mongoDB.Collection(msgCollectionName).UpdateMany(ctx, bson.M{"user_id": userId}, update)
+	return err
+}
+
+// CountMessagesByPost returns the number of messages on every post that has
+// any.
+func (r *MongodbRepository) CountMessagesByPost(ctx context.Context) (map[uuid.UUID]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$post_id", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := r.mongoDB.Collection(msgCollectionName).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
 	}
-	return limit
+	defer cursor.Close(ctx)
+
+	counts := make(map[uuid.UUID]int64)
+	for cursor.Next(ctx) {
+		var row struct {
+			PostId uuid.UUID `bson:"_id"`
+			Count  int64     `bson:"count"`
+		}
+		if err = cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.PostId] = row.Count
+	}
+
+	return counts, cursor.Err()
 }
 
 func currentTimeUTC() time.Time {
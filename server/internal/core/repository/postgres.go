@@ -3,13 +3,19 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	stderr "errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/skrpld/NearBeee/internal/core/database/postgres"
 	"github.com/skrpld/NearBeee/internal/core/models/entities"
 	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/geo"
+	"github.com/skrpld/NearBeee/pkg/geo/geojson"
+	"github.com/skrpld/NearBeee/pkg/utils/geohash"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -19,6 +25,9 @@ import (
 type PostgresRepository struct {
 	ctx        context.Context
 	postgresDB *postgres.PostgresDB
+
+	spatialOnce sync.Once
+	postGIS     bool
 }
 
 func NewPostgresRepository(postgresDB *postgres.PostgresDB) *PostgresRepository {
@@ -28,18 +37,40 @@ func NewPostgresRepository(postgresDB *postgres.PostgresDB) *PostgresRepository
 }
 
 const (
-	usersTableName = "users"
-	postsTableName = "posts"
+	usersTableName         = "users"
+	postsTableName         = "posts"
+	refreshTokensTableName = "refresh_tokens"
+	sessionsTableName      = "sessions"
+	userTokensTableName    = "user_tokens"
+	userTOTPTableName      = "user_totp"
+	recoveryCodesTableName = "user_recovery_codes"
+	loginAttemptsTableName = "login_attempts"
+	profilesTableName      = "profiles"
+	accountDeletionsTable  = "account_deletions"
+	apiKeysTableName       = "api_keys"
+	userIdentitiesTable    = "user_identities"
+	areasTableName         = "areas"
+	postAreasTableName     = "post_areas"
+	backfillsTableName     = "backfills"
 )
 
-func (r *PostgresRepository) CreateUser(email, passwordHash, refreshToken string, refreshTokenExpiryTime time.Time) (*entities.User, error) {
+const userColumns = `user_id, email, COALESCE(password_hash, '') AS password_hash, verified, role, banned_at, ban_reason`
+
+const profileColumns = `user_id, handle, display_name, bio, avatar_key, created_at, updated_at`
+
+func (r *PostgresRepository) CreateUser(email, passwordHash string) (*entities.User, error) {
 	var user entities.User
 
-	query := fmt.Sprintf(`INSERT INTO %s (email, password_hash, refresh_token, refresh_token_expiry_time) 
-			VALUES ($1, $2, $3, $4) RETURNING *`, usersTableName)
+	query := fmt.Sprintf(`WITH new_user AS (
+				INSERT INTO %s (email, password_hash) VALUES ($1, NULLIF($2, '')) RETURNING %s
+			), new_profile AS (
+				INSERT INTO %s (user_id, handle)
+				SELECT user_id, 'user_' || SUBSTRING(REPLACE(user_id::TEXT, '-', '') FOR 12) FROM new_user
+			)
+			SELECT %[2]s FROM new_user`, usersTableName, userColumns, profilesTableName)
 
-	err := r.postgresDB.QueryRow(query, email, passwordHash, refreshToken, refreshTokenExpiryTime).
-		Scan(&user.UserId, &user.Email, &user.PasswordHash, &user.RefreshToken, &user.RefreshTokenExpiryTime)
+	err := r.postgresDB.QueryRow(query, email, passwordHash).
+		Scan(&user.UserId, &user.Email, &user.PasswordHash, &user.Verified, &user.Role, &user.BannedAt, &user.BanReason)
 	if err != nil {
 		pgErr, ok := err.(*pq.Error)
 		if ok && pgErr.Code == "23505" { // 23505 - unique_violation
@@ -51,13 +82,52 @@ func (r *PostgresRepository) CreateUser(email, passwordHash, refreshToken string
 	return &user, nil
 }
 
+// GetUsersWithNonASCIIEmail lists the accounts whose address still has to be
+// normalized in Go, as the migrations cannot punycode domains.
+func (r *PostgresRepository) GetUsersWithNonASCIIEmail() ([]*entities.User, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE octet_length(email) <> char_length(email)`, userColumns, usersTableName)
+
+	rows, err := r.postgresDB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	users := make([]*entities.User, 0)
+	for rows.Next() {
+		var user entities.User
+		err = rows.Scan(&user.UserId, &user.Email, &user.PasswordHash, &user.Verified, &user.Role, &user.BannedAt, &user.BanReason)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *PostgresRepository) UpdateUserEmail(userId uuid.UUID, email string) error {
+	query := fmt.Sprintf(`UPDATE %s SET email = $2 WHERE user_id = $1`, usersTableName)
+
+	_, err := r.postgresDB.Exec(query, userId, email)
+	if err != nil {
+		pgErr, ok := err.(*pq.Error)
+		if ok && pgErr.Code == "23505" { // 23505 - unique_violation
+			return errors.ErrUserAlreadyExists
+		}
+		return err
+	}
+
+	return nil
+}
+
 func (r *PostgresRepository) GetUserByEmail(email string) (*entities.User, error) {
 	var user entities.User
 
-	query := fmt.Sprintf(`SELECT * FROM %s WHERE email = $1`, usersTableName)
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE email = $1`, userColumns, usersTableName)
 
 	err := r.postgresDB.QueryRow(query, email).
-		Scan(&user.UserId, &user.Email, &user.PasswordHash, &user.RefreshToken, &user.RefreshTokenExpiryTime)
+		Scan(&user.UserId, &user.Email, &user.PasswordHash, &user.Verified, &user.Role, &user.BannedAt, &user.BanReason)
 
 	if err != nil {
 		if stderr.Is(err, sql.ErrNoRows) {
@@ -72,14 +142,14 @@ func (r *PostgresRepository) GetUserByEmail(email string) (*entities.User, error
 func (r *PostgresRepository) GetUserById(userId uuid.UUID) (*entities.User, error) {
 	var user entities.User
 
-	query := fmt.Sprintf(`SELECT * FROM %s WHERE user_id = $1`, usersTableName)
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE user_id = $1`, userColumns, usersTableName)
 
 	err := r.postgresDB.QueryRow(query, userId).
-		Scan(&user.UserId, &user.Email, &user.PasswordHash, &user.RefreshToken, &user.RefreshTokenExpiryTime)
+		Scan(&user.UserId, &user.Email, &user.PasswordHash, &user.Verified, &user.Role, &user.BannedAt, &user.BanReason)
 
 	if err != nil {
 		if stderr.Is(err, sql.ErrNoRows) {
-			return nil, errors.ErrInvalidEmail
+			return nil, errors.ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -87,153 +157,497 @@ func (r *PostgresRepository) GetUserById(userId uuid.UUID) (*entities.User, erro
 	return &user, nil
 }
 
-func (r *PostgresRepository) UpdateRefreshTokenByUserId(userId uuid.UUID, refreshToken string, refreshTokenExpiryTime time.Time) error {
-	query := fmt.Sprintf(`UPDATE %s SET refresh_token = $1, refresh_token_expiry_time = $2
-          WHERE user_id = $3`, usersTableName)
+func (r *PostgresRepository) SetUserVerified(userId uuid.UUID) error {
+	query := fmt.Sprintf(`UPDATE %s SET verified = TRUE, verified_at = NOW()
+			WHERE user_id = $1 AND verified = FALSE`, usersTableName)
+
+	_, err := r.postgresDB.Exec(query, userId)
+	return err
+}
+
+func (r *PostgresRepository) UpdateUserPassword(userId uuid.UUID, passwordHash string) error {
+	query := fmt.Sprintf(`UPDATE %s SET password_hash = $1 WHERE user_id = $2`, usersTableName)
+
+	result, err := r.postgresDB.Exec(query, passwordHash, userId)
+	if err != nil {
+		return err
+	}
+
+	countRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if countRows != 1 {
+		return errors.ErrInvalidToken
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) GetUserByIdentity(provider, subject string) (*entities.User, error) {
+	var user entities.User
+
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE user_id = (
+				SELECT user_id FROM %s WHERE provider = $1 AND subject = $2
+			)`, userColumns, usersTableName, userIdentitiesTable)
+
+	err := r.postgresDB.QueryRow(query, provider, subject).
+		Scan(&user.UserId, &user.Email, &user.PasswordHash, &user.Verified, &user.Role, &user.BannedAt, &user.BanReason)
 
-	_, err := r.postgresDB.Exec(query, refreshToken, refreshTokenExpiryTime, userId)
 	if err != nil {
 		if stderr.Is(err, sql.ErrNoRows) {
-			return errors.ErrInvalidToken
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *PostgresRepository) CreateUserIdentity(identity *entities.UserIdentity) error {
+	query := fmt.Sprintf(`INSERT INTO %s (provider, subject, user_id, email)
+			VALUES ($1, $2, $3, $4) RETURNING created_at`, userIdentitiesTable)
+
+	err := r.postgresDB.QueryRow(query, identity.Provider, identity.Subject, identity.UserId, identity.Email).
+		Scan(&identity.CreatedAt)
+	if err != nil {
+		pgErr, ok := err.(*pq.Error)
+		if ok && pgErr.Code == "23505" { // 23505 - unique_violation
+			return errors.ErrIdentityAlreadyLinked
 		}
 		return err
 	}
+
 	return nil
 }
 
-func (r *PostgresRepository) CreatePost(userId uuid.UUID, title, content, idempotencyKey string, latitude, longitude float64) (*entities.Post, error) {
-	var post entities.Post
+func (r *PostgresRepository) SearchUsers(query string, role entities.Role, count, offset int64) ([]*entities.User, error) {
+	var users []*entities.User
+
+	sqlQuery := fmt.Sprintf(`SELECT %s FROM %s
+			WHERE ($1 = '' OR email ILIKE '%%' || $1 || '%%' ESCAPE '\')
+			  AND ($2 = '' OR role = $2)
+			ORDER BY email LIMIT $3 OFFSET $4`, userColumns, usersTableName)
 
-	query := fmt.Sprintf(`INSERT INTO %s (user_id, title, content, idempotency_key, latitude, longitude)
-			VALUES ($1, $2, $3, $4, $5, $6) RETURNING *`, postsTableName)
+	rows, err := r.postgresDB.Query(sqlQuery, escapeLike(query), role, parsePostgresLimit(count), max(offset, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var user entities.User
+
+		err = rows.Scan(&user.UserId, &user.Email, &user.PasswordHash, &user.Verified, &user.Role, &user.BannedAt, &user.BanReason)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, &user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *PostgresRepository) BanUser(userId uuid.UUID, reason string) error {
+	query := fmt.Sprintf(`UPDATE %s SET banned_at = NOW(), ban_reason = NULLIF($1, '')
+			WHERE user_id = $2`, usersTableName)
+
+	return r.execUserUpdate(query, reason, userId)
+}
+
+func (r *PostgresRepository) UnbanUser(userId uuid.UUID) error {
+	query := fmt.Sprintf(`UPDATE %s SET banned_at = NULL, ban_reason = NULL
+			WHERE user_id = $1`, usersTableName)
+
+	return r.execUserUpdate(query, userId)
+}
+
+func (r *PostgresRepository) SetUserRole(userId uuid.UUID, role entities.Role) error {
+	query := fmt.Sprintf(`UPDATE %s SET role = $1 WHERE user_id = $2`, usersTableName)
+
+	return r.execUserUpdate(query, role, userId)
+}
+
+func (r *PostgresRepository) execUserUpdate(query string, args ...any) error {
+	result, err := r.postgresDB.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	countRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if countRows != 1 {
+		return errors.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) GetProfileByUserId(userId uuid.UUID) (*entities.Profile, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE user_id = $1`, profileColumns, profilesTableName)
+
+	return r.scanProfile(r.postgresDB.QueryRow(query, userId))
+}
 
-	err := r.postgresDB.QueryRow(query, userId, title, content, idempotencyKey, latitude, longitude).
-		Scan(&post.PostId, &post.UserId, &post.Title, &post.Content, &post.IdempotencyKey, &post.Latitude, &post.Longitude, &post.CreatedAt, &post.UpdatedAt)
+func (r *PostgresRepository) GetProfileByHandle(handle string) (*entities.Profile, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE handle = $1`, profileColumns, profilesTableName)
+
+	return r.scanProfile(r.postgresDB.QueryRow(query, handle))
+}
+
+func (r *PostgresRepository) UpdateProfile(userId uuid.UUID, handle, displayName, bio string) (*entities.Profile, error) {
+	query := fmt.Sprintf(`UPDATE %s SET handle = $1, display_name = $2, bio = $3
+			WHERE user_id = $4 RETURNING %s`, profilesTableName, profileColumns)
+
+	profile, err := r.scanProfile(r.postgresDB.QueryRow(query, handle, displayName, bio, userId))
 	if err != nil {
 		pgErr, ok := err.(*pq.Error)
 		if ok && pgErr.Code == "23505" { // 23505 - unique_violation
-			return nil, errors.ErrIdempotencyKeyAlreadyExists
+			return nil, errors.ErrHandleTaken
 		}
 		return nil, err
 	}
 
-	return &post, nil
+	return profile, nil
 }
 
-func (r *PostgresRepository) GetPostsByUserId(userId uuid.UUID, count int64) ([]*entities.Post, error) {
-	var posts []*entities.Post
+func (r *PostgresRepository) SetProfileAvatar(userId uuid.UUID, avatarKey string) (*string, error) {
+	var previousKey *string
 
-	query := fmt.Sprintf(`SELECT * FROM %s WHERE user_id = $1 
-                 ORDER BY created_at DESC LIMIT $2`, postsTableName)
-	rows, err := r.postgresDB.Query(query, userId, parsePostgresLimit(count))
+	query := fmt.Sprintf(`UPDATE %[1]s AS p SET avatar_key = $1
+			FROM (SELECT user_id, avatar_key FROM %[1]s WHERE user_id = $2 FOR UPDATE) AS old
+			WHERE p.user_id = old.user_id
+			RETURNING old.avatar_key`, profilesTableName)
+
+	err := r.postgresDB.QueryRow(query, avatarKey, userId).Scan(&previousKey)
 	if err != nil {
 		if stderr.Is(err, sql.ErrNoRows) {
-			return nil, errors.ErrExpiredToken
+			return nil, errors.ErrProfileNotFound
 		}
 		return nil, err
 	}
 
+	return previousKey, nil
+}
+
+func (r *PostgresRepository) GetProfilesByUserIds(userIds []uuid.UUID) ([]*entities.Profile, error) {
+	var profiles []*entities.Profile
+
+	ids := make([]string, 0, len(userIds))
+	for _, userId := range userIds {
+		ids = append(ids, userId.String())
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE user_id = ANY($1::UUID[])`, profileColumns, profilesTableName)
+
+	rows, err := r.postgresDB.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
 	defer rows.Close()
 	for rows.Next() {
-		var post entities.Post
-
-		err = rows.Scan(&post.PostId, &post.UserId,
-			&post.Title, &post.Content,
-			&post.IdempotencyKey, &post.Latitude,
-			&post.Longitude, &post.CreatedAt, &post.UpdatedAt)
+		profile, err := r.scanProfile(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		posts = append(posts, &post)
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, rows.Err()
+}
+
+func (r *PostgresRepository) scanProfile(row interface{ Scan(dest ...any) error }) (*entities.Profile, error) {
+	var profile entities.Profile
+
+	err := row.Scan(&profile.UserId, &profile.Handle, &profile.DisplayName, &profile.Bio,
+		&profile.AvatarKey, &profile.CreatedAt, &profile.UpdatedAt)
+	if err != nil {
+		if stderr.Is(err, sql.ErrNoRows) {
+			return nil, errors.ErrProfileNotFound
+		}
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+func (r *PostgresRepository) DeleteUser(userId uuid.UUID) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, usersTableName)
+
+	_, err := r.postgresDB.Exec(query, userId)
+	return err
+}
+
+const accountDeletionColumns = `user_id, step, requested_at, scheduled_for, started_at, attempts, last_error`
+
+func (r *PostgresRepository) ScheduleAccountDeletion(userId uuid.UUID, scheduledFor time.Time) (*entities.AccountDeletion, error) {
+	var deletion entities.AccountDeletion
+
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, step, scheduled_for) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+			RETURNING %s`, accountDeletionsTable, accountDeletionColumns)
+
+	err := r.postgresDB.QueryRow(query, userId, entities.DeletionSteps[0], scheduledFor).
+		Scan(&deletion.UserId, &deletion.Step, &deletion.RequestedAt, &deletion.ScheduledFor,
+			&deletion.StartedAt, &deletion.Attempts, &deletion.LastError)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deletion, nil
+}
+
+func (r *PostgresRepository) CancelAccountDeletion(userId uuid.UUID) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1 AND started_at IS NULL`, accountDeletionsTable)
+
+	if _, err := r.postgresDB.Exec(query, userId); err != nil {
+		return err
+	}
+
+	var exists bool
+
+	query = fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE user_id = $1)`, accountDeletionsTable)
+	if err := r.postgresDB.QueryRow(query, userId).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return errors.ErrAccountDeletionInProgress
 	}
 
-	return posts, nil
+	return nil
 }
 
-func (r *PostgresRepository) GetPostsByLocation(latitude, longitude, radius float64, count int64) ([]*entities.Post, error) {
-	var posts []*entities.Post
+func (r *PostgresRepository) ClaimAccountDeletion(lease time.Duration) (*entities.AccountDeletion, error) {
+	var deletion entities.AccountDeletion
 
-	query := fmt.Sprintf(`SELECT * FROM %s
-         WHERE calculate_distance($1, $2, latitude, longitude) <= $3
-         ORDER BY calculate_distance($1, $2, latitude, longitude) 
-         LIMIT $4`, postsTableName)
+	query := fmt.Sprintf(`UPDATE %[1]s SET started_at = COALESCE(started_at, NOW()),
+				locked_until = NOW() + make_interval(secs => $1),
+				attempts = attempts + 1
+			WHERE user_id = (
+				SELECT user_id FROM %[1]s
+				WHERE scheduled_for <= NOW() AND (locked_until IS NULL OR locked_until < NOW())
+				ORDER BY scheduled_for
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING %[2]s`, accountDeletionsTable, accountDeletionColumns)
 
-	rows, err := r.postgresDB.Query(query, latitude, longitude, radius, parsePostgresLimit(count))
+	err := r.postgresDB.QueryRow(query, lease.Seconds()).
+		Scan(&deletion.UserId, &deletion.Step, &deletion.RequestedAt, &deletion.ScheduledFor,
+			&deletion.StartedAt, &deletion.Attempts, &deletion.LastError)
 	if err != nil {
 		if stderr.Is(err, sql.ErrNoRows) {
-			return nil, errors.ErrInvalidCoords
+			return nil, nil
 		}
 		return nil, err
 	}
 
+	return &deletion, nil
+}
+
+func (r *PostgresRepository) AdvanceAccountDeletion(userId uuid.UUID, step string) error {
+	query := fmt.Sprintf(`UPDATE %s SET step = $1, last_error = NULL WHERE user_id = $2`, accountDeletionsTable)
+
+	_, err := r.postgresDB.Exec(query, step, userId)
+	return err
+}
+
+func (r *PostgresRepository) FailAccountDeletion(userId uuid.UUID, reason string) error {
+	query := fmt.Sprintf(`UPDATE %s SET last_error = $1 WHERE user_id = $2`, accountDeletionsTable)
+
+	_, err := r.postgresDB.Exec(query, reason, userId)
+	return err
+}
+
+func (r *PostgresRepository) GetPostIdsByUserId(userId uuid.UUID) ([]uuid.UUID, error) {
+	var postIds []uuid.UUID
+
+	query := fmt.Sprintf(`SELECT post_id FROM %s WHERE user_id = $1`, postsTableName)
+
+	rows, err := r.postgresDB.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+
 	defer rows.Close()
 	for rows.Next() {
-		var post entities.Post
+		var postId uuid.UUID
+		if err = rows.Scan(&postId); err != nil {
+			return nil, err
+		}
 
-		err = rows.Scan(&post.PostId, &post.UserId,
-			&post.Title, &post.Content,
-			&post.IdempotencyKey, &post.Latitude,
-			&post.Longitude, &post.CreatedAt, &post.UpdatedAt)
-		if err != nil {
+		postIds = append(postIds, postId)
+	}
+
+	return postIds, rows.Err()
+}
+
+// GetPostLocationsByUserId returns the public location of every post of the
+// user.
+func (r *PostgresRepository) GetPostLocationsByUserId(userId uuid.UUID) ([]geo.Coordinate, error) {
+	var locations []geo.Coordinate
+
+	query := fmt.Sprintf(`SELECT latitude, longitude FROM %s WHERE user_id = $1`, postsTableName)
+
+	rows, err := r.postgresDB.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var location geo.Coordinate
+		if err = rows.Scan(&location.Latitude, &location.Longitude); err != nil {
 			return nil, err
 		}
 
-		posts = append(posts, &post)
+		locations = append(locations, location)
 	}
 
-	return posts, nil
+	return locations, rows.Err()
 }
 
-func (r *PostgresRepository) GetPostByPostId(postId uuid.UUID) (*entities.Post, error) {
-	var post entities.Post
+func (r *PostgresRepository) CreateUserToken(token *entities.UserToken) error {
+	query := fmt.Sprintf(`INSERT INTO %s (token_id, user_id, purpose, expires_at)
+			VALUES ($1, $2, $3, $4)`, userTokensTableName)
+
+	_, err := r.postgresDB.Exec(query, token.TokenId, token.UserId, token.Purpose, token.ExpiresAt)
+	return err
+}
+
+func (r *PostgresRepository) ConsumeUserToken(tokenId uuid.UUID, purpose string) (uuid.UUID, error) {
+	var userId uuid.UUID
 
-	query := fmt.Sprintf(`SELECT * FROM %s WHERE post_id = $1`, postsTableName)
-	err := r.postgresDB.QueryRow(query, postId).
-		Scan(&post.PostId, &post.UserId,
-			&post.Title, &post.Content,
-			&post.IdempotencyKey, &post.Latitude,
-			&post.Longitude, &post.CreatedAt, &post.UpdatedAt)
+	query := fmt.Sprintf(`UPDATE %s SET used_at = NOW()
+			WHERE token_id = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id`, userTokensTableName)
 
+	err := r.postgresDB.QueryRow(query, tokenId, purpose).Scan(&userId)
 	if err != nil {
 		if stderr.Is(err, sql.ErrNoRows) {
-			return nil, errors.ErrInvalidPostId
+			return uuid.Nil, errors.ErrInvalidToken
 		}
-		return nil, err
+		return uuid.Nil, err
 	}
 
-	return &post, nil
+	return userId, nil
 }
 
-func (r *PostgresRepository) UpdatePostById(title, content string, postId, userId uuid.UUID) (*entities.Post, error) {
-	var post entities.Post
+func (r *PostgresRepository) InvalidateUserTokens(userId uuid.UUID, purpose string) error {
+	query := fmt.Sprintf(`UPDATE %s SET used_at = NOW()
+			WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, userTokensTableName)
 
-	query := fmt.Sprintf(`UPDATE %s SET title = $1, content = $2 
-          WHERE post_id = $3 AND user_id = $4 RETURNING *`, postsTableName)
-	//TODO: по хорошему добавить проверку на доступ к посту (и месаги) а не просто инвалид пост ид
-	err := r.postgresDB.QueryRow(query, title, content, postId, userId).
-		Scan(&post.PostId, &post.UserId, &post.Title,
-			&post.Content, &post.IdempotencyKey,
-			&post.Latitude, &post.Longitude,
-			&post.CreatedAt, &post.UpdatedAt)
+	_, err := r.postgresDB.Exec(query, userId, purpose)
+	return err
+}
+
+func (r *PostgresRepository) GetLatestUserToken(userId uuid.UUID, purpose string) (*entities.UserToken, error) {
+	var token entities.UserToken
+
+	query := fmt.Sprintf(`SELECT token_id, user_id, purpose, expires_at, used_at, created_at
+			FROM %s WHERE user_id = $1 AND purpose = $2
+			ORDER BY created_at DESC LIMIT 1`, userTokensTableName)
+
+	err := r.postgresDB.QueryRow(query, userId, purpose).
+		Scan(&token.TokenId, &token.UserId, &token.Purpose, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
 	if err != nil {
 		if stderr.Is(err, sql.ErrNoRows) {
-			return nil, errors.ErrInvalidPostId
+			return nil, nil
 		}
 		return nil, err
 	}
 
-	return &post, nil
+	return &token, nil
+}
+
+func (r *PostgresRepository) SaveUserTOTP(userId uuid.UUID, secretEncrypted string) error {
+	query := fmt.Sprintf(`INSERT INTO %[1]s (user_id, secret_encrypted) VALUES ($1, $2)
+			ON CONFLICT (user_id) DO UPDATE SET secret_encrypted = EXCLUDED.secret_encrypted, created_at = NOW()
+			WHERE %[1]s.confirmed_at IS NULL`, userTOTPTableName)
+
+	result, err := r.postgresDB.Exec(query, userId, secretEncrypted)
+	if err != nil {
+		return err
+	}
+
+	countRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if countRows != 1 {
+		return errors.ErrTwoFactorAlreadyEnabled
+	}
+
+	return nil
 }
 
-func (r *PostgresRepository) DeletePostById(postId, userId uuid.UUID) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE post_id = $1 AND user_id = $2`, postsTableName)
+func (r *PostgresRepository) GetUserTOTP(userId uuid.UUID) (*entities.UserTOTP, error) {
+	var totp entities.UserTOTP
 
-	result, err := r.postgresDB.Exec(query, postId, userId)
+	query := fmt.Sprintf(`SELECT user_id, secret_encrypted, confirmed_at, last_used_step
+			FROM %s WHERE user_id = $1`, userTOTPTableName)
+
+	err := r.postgresDB.QueryRow(query, userId).
+		Scan(&totp.UserId, &totp.SecretEncrypted, &totp.ConfirmedAt, &totp.LastUsedStep)
 	if err != nil {
 		if stderr.Is(err, sql.ErrNoRows) {
-			return errors.ErrInvalidPostId
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &totp, nil
+}
+
+func (r *PostgresRepository) ConfirmUserTOTP(userId uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.postgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`UPDATE %s SET confirmed_at = NOW(), last_used_step = $2
+			WHERE user_id = $1 AND confirmed_at IS NULL`, userTOTPTableName)
+
+	result, err := tx.Exec(query, userId, step)
+	if err != nil {
+		return err
+	}
+
+	countRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if countRows != 1 {
+		return errors.ErrTwoFactorAlreadyEnabled
+	}
+
+	query = fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, recoveryCodesTableName)
+	if _, err = tx.Exec(query, userId); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (user_id, code_hash) VALUES ($1, $2)`, recoveryCodesTableName)
+	for _, codeHash := range recoveryCodeHashes {
+		if _, err = tx.Exec(query, userId, codeHash); err != nil {
+			return err
 		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) UseTOTPStep(userId uuid.UUID, step int64) error {
+	query := fmt.Sprintf(`UPDATE %s SET last_used_step = $2
+			WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2`, userTOTPTableName)
+
+	result, err := r.postgresDB.Exec(query, userId, step)
+	if err != nil {
 		return err
 	}
 
@@ -242,15 +656,1200 @@ func (r *PostgresRepository) DeletePostById(postId, userId uuid.UUID) error {
 		return err
 	}
 	if countRows != 1 {
-		return errors.ErrInvalidPostId
+		return errors.ErrInvalidTwoFactorCode
 	}
 
 	return nil
 }
 
-func parsePostgresLimit(limit int64) any {
-	if limit < 1 {
-		return nil
+func (r *PostgresRepository) UseRecoveryCode(userId uuid.UUID, codeHash string) error {
+	query := fmt.Sprintf(`UPDATE %s SET used_at = NOW()
+			WHERE code_id = (SELECT code_id FROM %[1]s WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL LIMIT 1)`,
+		recoveryCodesTableName)
+
+	result, err := r.postgresDB.Exec(query, userId, codeHash)
+	if err != nil {
+		return err
 	}
-	return limit
+
+	countRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if countRows != 1 {
+		return errors.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) DeleteUserTOTP(userId uuid.UUID) error {
+	tx, err := r.postgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, recoveryCodesTableName)
+	if _, err = tx.Exec(query, userId); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, userTOTPTableName)
+	if _, err = tx.Exec(query, userId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) GetLoginAttempt(key string) (*entities.LoginAttempt, error) {
+	var attempt entities.LoginAttempt
+
+	query := fmt.Sprintf(`SELECT key, failures, last_failed_at, locked_until
+			FROM %s WHERE key = $1`, loginAttemptsTableName)
+
+	err := r.postgresDB.QueryRow(query, key).
+		Scan(&attempt.Key, &attempt.Failures, &attempt.LastFailedAt, &attempt.LockedUntil)
+	if err != nil {
+		if stderr.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+func (r *PostgresRepository) RecordLoginFailure(key string, resetAfter time.Duration) (*entities.LoginAttempt, error) {
+	var attempt entities.LoginAttempt
+
+	query := fmt.Sprintf(`INSERT INTO %[1]s (key, failures, last_failed_at) VALUES ($1, 1, NOW())
+			ON CONFLICT (key) DO UPDATE SET
+				failures = CASE WHEN %[1]s.last_failed_at < NOW() - make_interval(secs => $2)
+					THEN 1 ELSE %[1]s.failures + 1 END,
+				locked_until = CASE WHEN %[1]s.last_failed_at < NOW() - make_interval(secs => $2)
+					THEN NULL ELSE %[1]s.locked_until END,
+				last_failed_at = NOW()
+			RETURNING key, failures, last_failed_at, locked_until`, loginAttemptsTableName)
+
+	err := r.postgresDB.QueryRow(query, key, resetAfter.Seconds()).
+		Scan(&attempt.Key, &attempt.Failures, &attempt.LastFailedAt, &attempt.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+func (r *PostgresRepository) LockLogin(key string, until time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET locked_until = $1 WHERE key = $2`, loginAttemptsTableName)
+
+	_, err := r.postgresDB.Exec(query, until, key)
+	return err
+}
+
+func (r *PostgresRepository) ResetLoginAttempts(key string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE key = $1`, loginAttemptsTableName)
+
+	_, err := r.postgresDB.Exec(query, key)
+	return err
+}
+
+func (r *PostgresRepository) CreateSession(session *entities.Session, token *entities.RefreshToken) error {
+	tx, err := r.postgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`INSERT INTO %s (session_id, user_id, device_name, user_agent, ip_address)
+			VALUES ($1, $2, $3, $4, $5) RETURNING created_at, last_seen_at`, sessionsTableName)
+
+	err = tx.QueryRow(query, session.SessionId, session.UserId, session.DeviceName, session.UserAgent, session.IpAddress).
+		Scan(&session.CreatedAt, &session.LastSeenAt)
+	if err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (token_id, family_id, user_id, token_hash, expires_at)
+			VALUES ($1, $2, $3, $4, $5)`, refreshTokensTableName)
+
+	_, err = tx.Exec(query, token.TokenId, token.FamilyId, token.UserId, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) GetSessionById(sessionId uuid.UUID) (*entities.Session, error) {
+	var session entities.Session
+
+	query := fmt.Sprintf(`SELECT session_id, user_id, device_name, user_agent, ip_address, created_at, last_seen_at, revoked_at
+			FROM %s WHERE session_id = $1`, sessionsTableName)
+
+	err := r.postgresDB.QueryRow(query, sessionId).
+		Scan(&session.SessionId, &session.UserId, &session.DeviceName, &session.UserAgent,
+			&session.IpAddress, &session.CreatedAt, &session.LastSeenAt, &session.RevokedAt)
+	if err != nil {
+		if stderr.Is(err, sql.ErrNoRows) {
+			return nil, errors.ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *PostgresRepository) GetActiveSessionsByUserId(userId uuid.UUID) ([]*entities.Session, error) {
+	sessions := make([]*entities.Session, 0)
+
+	query := fmt.Sprintf(`SELECT session_id, user_id, device_name, user_agent, ip_address, created_at, last_seen_at, revoked_at
+			FROM %s WHERE user_id = $1 AND revoked_at IS NULL
+			ORDER BY last_seen_at DESC`, sessionsTableName)
+
+	rows, err := r.postgresDB.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var session entities.Session
+
+		err = rows.Scan(&session.SessionId, &session.UserId, &session.DeviceName, &session.UserAgent,
+			&session.IpAddress, &session.CreatedAt, &session.LastSeenAt, &session.RevokedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *PostgresRepository) RevokeSession(sessionId, userId uuid.UUID) error {
+	tx, err := r.postgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`UPDATE %s SET revoked_at = NOW()
+			WHERE session_id = $1 AND user_id = $2 AND revoked_at IS NULL`, sessionsTableName)
+
+	result, err := tx.Exec(query, sessionId, userId)
+	if err != nil {
+		return err
+	}
+
+	countRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if countRows != 1 {
+		return errors.ErrSessionNotFound
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET revoked_at = NOW()
+			WHERE family_id = $1 AND revoked_at IS NULL`, refreshTokensTableName)
+
+	if _, err = tx.Exec(query, sessionId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) RevokeAllSessions(userId uuid.UUID) error {
+	tx, err := r.postgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`UPDATE %s SET revoked_at = NOW()
+			WHERE user_id = $1 AND revoked_at IS NULL`, sessionsTableName)
+
+	if _, err = tx.Exec(query, userId); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET revoked_at = NOW()
+			WHERE user_id = $1 AND revoked_at IS NULL`, refreshTokensTableName)
+
+	if _, err = tx.Exec(query, userId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) GetRefreshTokenById(tokenId uuid.UUID) (*entities.RefreshToken, error) {
+	var token entities.RefreshToken
+
+	query := fmt.Sprintf(`SELECT token_id, family_id, user_id, token_hash, expires_at, used_at, revoked_at, created_at
+			FROM %s WHERE token_id = $1`, refreshTokensTableName)
+
+	err := r.postgresDB.QueryRow(query, tokenId).
+		Scan(&token.TokenId, &token.FamilyId, &token.UserId, &token.TokenHash,
+			&token.ExpiresAt, &token.UsedAt, &token.RevokedAt, &token.CreatedAt)
+	if err != nil {
+		if stderr.Is(err, sql.ErrNoRows) {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *PostgresRepository) RotateRefreshToken(oldTokenId uuid.UUID, newToken *entities.RefreshToken) error {
+	tx, err := r.postgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`UPDATE %s SET used_at = NOW()
+			WHERE token_id = $1 AND used_at IS NULL AND revoked_at IS NULL`, refreshTokensTableName)
+
+	result, err := tx.Exec(query, oldTokenId)
+	if err != nil {
+		return err
+	}
+
+	countRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if countRows != 1 {
+		return errors.ErrRefreshTokenReused
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET last_seen_at = NOW()
+			WHERE session_id = $1 AND revoked_at IS NULL`, sessionsTableName)
+
+	result, err = tx.Exec(query, newToken.FamilyId)
+	if err != nil {
+		return err
+	}
+
+	countRows, err = result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if countRows != 1 {
+		return errors.ErrSessionRevoked
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (token_id, family_id, user_id, token_hash, expires_at)
+			VALUES ($1, $2, $3, $4, $5)`, refreshTokensTableName)
+
+	_, err = tx.Exec(query, newToken.TokenId, newToken.FamilyId, newToken.UserId, newToken.TokenHash, newToken.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const apiKeyColumns = `key_id, user_id, name, prefix, secret_hash, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+func (r *PostgresRepository) CreateApiKey(key *entities.ApiKey) error {
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, name, prefix, secret_hash, scopes, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING key_id, created_at`, apiKeysTableName)
+
+	return r.postgresDB.QueryRow(query, key.UserId, key.Name, key.Prefix, key.SecretHash, pq.Array(scopes), key.ExpiresAt).
+		Scan(&key.KeyId, &key.CreatedAt)
+}
+
+func (r *PostgresRepository) GetApiKeyByPrefix(prefix string) (*entities.ApiKey, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE prefix = $1`, apiKeyColumns, apiKeysTableName)
+
+	key, err := r.scanApiKey(r.postgresDB.QueryRow(query, prefix))
+	if err != nil {
+		if stderr.Is(err, sql.ErrNoRows) {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *PostgresRepository) GetApiKeysByUserId(userId uuid.UUID) ([]*entities.ApiKey, error) {
+	keys := make([]*entities.ApiKey, 0)
+
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE user_id = $1 AND revoked_at IS NULL
+			ORDER BY created_at DESC`, apiKeyColumns, apiKeysTableName)
+
+	rows, err := r.postgresDB.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		key, err := r.scanApiKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *PostgresRepository) RevokeApiKey(keyId, userId uuid.UUID) error {
+	query := fmt.Sprintf(`UPDATE %s SET revoked_at = NOW()
+			WHERE key_id = $1 AND user_id = $2 AND revoked_at IS NULL`, apiKeysTableName)
+
+	result, err := r.postgresDB.Exec(query, keyId, userId)
+	if err != nil {
+		return err
+	}
+
+	countRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if countRows != 1 {
+		return errors.ErrApiKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) RevokeAllApiKeys(userId uuid.UUID) error {
+	query := fmt.Sprintf(`UPDATE %s SET revoked_at = NOW()
+			WHERE user_id = $1 AND revoked_at IS NULL`, apiKeysTableName)
+
+	_, err := r.postgresDB.Exec(query, userId)
+	return err
+}
+
+func (r *PostgresRepository) TouchApiKey(keyId uuid.UUID, interval time.Duration) error {
+	query := fmt.Sprintf(`UPDATE %s SET last_used_at = NOW()
+			WHERE key_id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - make_interval(secs => $2))`, apiKeysTableName)
+
+	_, err := r.postgresDB.Exec(query, keyId, interval.Seconds())
+	return err
+}
+
+func (r *PostgresRepository) scanApiKey(row interface{ Scan(dest ...any) error }) (*entities.ApiKey, error) {
+	var key entities.ApiKey
+	var scopes []string
+
+	err := row.Scan(&key.KeyId, &key.UserId, &key.Name, &key.Prefix, &key.SecretHash, pq.Array(&scopes),
+		&key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Scopes = make([]entities.Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, entities.Scope(scope))
+	}
+
+	return &key, nil
+}
+
+// postColumns selects a post as seen by the viewer bound to the given
+// parameter. The author gets the exact location and everyone else the public
+// one; no finer relationships between users are planned. Filters and ordering
+// always use the public columns, so a post matches a query exactly when the
+// point other users see for it does.
+func postColumns(viewerParam int) string {
+	return fmt.Sprintf(`post_id, user_id, title, content, idempotency_key,
+		CASE WHEN user_id = $%[1]d THEN COALESCE(exact_latitude, latitude) ELSE latitude END,
+		CASE WHEN user_id = $%[1]d THEN COALESCE(exact_longitude, longitude) ELSE longitude END,
+		location_precision, COALESCE(country, ''), COALESCE(region, ''), COALESCE(locality, ''),
+		message_count, created_at, updated_at, `, viewerParam) + postAreasColumn
+}
+
+const postAreasColumn = `(SELECT COALESCE(json_agg(json_build_object('slug', a.slug, 'name', a.name) ORDER BY a.name), '[]')
+		FROM post_areas pa JOIN areas a ON a.area_id = pa.area_id WHERE pa.post_id = posts.post_id)`
+
+const areaColumns = `area_id, slug, name, geometry, created_at`
+
+// The sphere distance used by ST_DWithin differs from calculate_distance only
+// by the Earth radius, so a slightly wider index search followed by the exact
+// filter keeps results identical to the Haversine scan.
+const spatialSearchSlack = 1.0001
+
+// CreatePost inserts the post and tags it with the areas holding its public
+// location in one transaction.
+func (r *PostgresRepository) CreatePost(newPost *entities.Post, exact geo.Coordinate) (*entities.Post, error) {
+	tx, err := r.postgresDB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`INSERT INTO %s (post_id, user_id, title, content, idempotency_key, latitude, longitude,
+			location_precision, exact_latitude, exact_longitude, country, region, locality)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''))
+			RETURNING %s`, postsTableName, postColumns(2))
+
+	post, err := r.scanPost(tx.QueryRow(query, newPost.PostId, newPost.UserId, newPost.Title, newPost.Content,
+		newPost.IdempotencyKey, newPost.Latitude, newPost.Longitude, newPost.Precision, exact.Latitude,
+		exact.Longitude, newPost.Country, newPost.Region, newPost.Locality))
+	if err != nil {
+		pgErr, ok := err.(*pq.Error)
+		if ok && pgErr.Code == "23505" { // 23505 - unique_violation
+			return nil, errors.ErrIdempotencyKeyAlreadyExists
+		}
+		return nil, err
+	}
+
+	areaIds, err := r.areaIdsContaining(tx, newPost.Latitude, newPost.Longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(areaIds) > 0 {
+		query = fmt.Sprintf(`INSERT INTO %s (post_id, area_id)
+				SELECT $1, unnest($2::UUID[]) ON CONFLICT DO NOTHING`, postAreasTableName)
+		if _, err = tx.Exec(query, post.PostId, pq.Array(uuidStrings(areaIds))); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+func (r *PostgresRepository) GetPostsByUserId(userId, viewerId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error) {
+	args := []any{userId, limit + 1}
+
+	keyset := ""
+	if after != nil {
+		afterId, err := uuid.Parse(after.Id)
+		if err != nil {
+			return nil, nil, errors.ErrInvalidCursor
+		}
+		args = append(args, after.CreatedAt, afterId)
+		keyset = "AND (created_at, post_id) < ($3, $4)"
+	}
+
+	args = append(args, viewerId)
+
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE user_id = $1 %s
+                 ORDER BY created_at DESC, post_id DESC LIMIT $2`, postColumns(len(args)), postsTableName, keyset)
+
+	posts, err := r.queryPosts(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pagePosts(posts, nil, limit)
+}
+
+func (r *PostgresRepository) GetPostsByLocation(latitude, longitude, radius float64, viewerId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error) {
+	query, args, err := r.locationQuery(latitude, longitude, radius, viewerId, after, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	posts := make([]*entities.Post, 0)
+	distances := make([]float64, 0)
+	err = r.eachPost(query, args, true, func(post *entities.Post, distance float64) error {
+		posts = append(posts, post)
+		distances = append(distances, distance)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pagePosts(posts, distances, limit)
+}
+
+func (r *PostgresRepository) EachPostByLocation(latitude, longitude, radius float64, viewerId uuid.UUID, limit int64, fn func(*entities.Post) error) error {
+	query, args, err := r.locationQuery(latitude, longitude, radius, viewerId, nil, limit)
+	if err != nil {
+		return err
+	}
+
+	return r.eachPost(query, args, true, func(post *entities.Post, _ float64) error {
+		return fn(post)
+	})
+}
+
+func (r *PostgresRepository) locationQuery(latitude, longitude, radius float64, viewerId uuid.UUID, after *entities.PageCursor, limit int64) (string, []any, error) {
+	args := []any{latitude, longitude, radius, limit}
+
+	// The spatial filter only narrows the rows through an index; ordering and
+	// the cursor both use the Haversine distance in km, so a page and its
+	// cursor agree whichever filter ran.
+	const distance = "calculate_distance($1, $2, latitude, longitude)"
+
+	filter, args := r.spatialFilter(args, latitude, longitude, radius)
+
+	keyset := ""
+	if after != nil {
+		afterId, err := uuid.Parse(after.Id)
+		if err != nil {
+			return "", nil, errors.ErrInvalidCursor
+		}
+		args = append(args, after.Distance, afterId)
+		keyset = fmt.Sprintf("AND (%s, post_id) > ($%d, $%d)", distance, len(args)-1, len(args))
+	}
+
+	args = append(args, viewerId)
+
+	query := fmt.Sprintf(`SELECT %s, %s FROM %s
+			WHERE %s
+				AND %[2]s <= $3 %[5]s
+			ORDER BY %[2]s, post_id
+			LIMIT $4`, postColumns(len(args)), distance, postsTableName, filter, keyset)
+
+	return query, args, nil
+}
+
+// Feed score components. Decays are capped before power() so very old or far
+// posts score as zero instead of underflowing.
+const (
+	feedDistanceScore   = `power(0.5, LEAST(calculate_distance($1, $2, latitude, longitude) / $6::DOUBLE PRECISION, 1000))`
+	feedRecencyScore    = `power(0.5, LEAST(GREATEST(EXTRACT(EPOCH FROM ($5::TIMESTAMPTZ - created_at))::DOUBLE PRECISION, 0) / $7::DOUBLE PRECISION, 1000))`
+	feedEngagementScore = `ln(1 + message_count::DOUBLE PRECISION)`
+	feedTotalScore      = `($8::DOUBLE PRECISION * ` + feedDistanceScore + ` + $9::DOUBLE PRECISION * ` + feedRecencyScore +
+		` + $10::DOUBLE PRECISION * ` + feedEngagementScore + `)`
+)
+
+// GetPostsFeed ranks the posts within radius km of the point by their feed
+// score as of the given time. Engagement comes from the pre-aggregated
+// message_count, so scoring needs no joins.
+func (r *PostgresRepository) GetPostsFeed(latitude, longitude, radius float64, scoring entities.FeedScoring, asOf time.Time, viewerId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error) {
+	args := []any{latitude, longitude, radius, limit + 1, asOf, scoring.DistanceHalfLife, scoring.RecencyHalfLife.Seconds(),
+		scoring.DistanceWeight, scoring.RecencyWeight, scoring.EngagementWeight}
+
+	filter, args := r.spatialFilter(args, latitude, longitude, radius)
+
+	keyset := ""
+	if after != nil {
+		afterId, err := uuid.Parse(after.Id)
+		if err != nil {
+			return nil, nil, errors.ErrInvalidCursor
+		}
+		args = append(args, after.Score, afterId)
+		keyset = fmt.Sprintf("AND (%s, post_id) < ($%d, $%d)", feedTotalScore, len(args)-1, len(args))
+	}
+
+	args = append(args, viewerId)
+
+	query := fmt.Sprintf(`SELECT %s, %s, calculate_distance($1, $2, latitude, longitude), %s, %s, %s FROM %s
+			WHERE %s
+				AND calculate_distance($1, $2, latitude, longitude) <= $3 %s
+			ORDER BY %[2]s DESC, post_id DESC
+			LIMIT $4`, postColumns(len(args)), feedTotalScore, feedDistanceScore, feedRecencyScore, feedEngagementScore,
+		postsTableName, filter, keyset)
+
+	rows, err := r.postgresDB.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+	posts := make([]*entities.Post, 0)
+	for rows.Next() {
+		var score entities.PostScore
+		post, err := r.scanPost(rows, &score.Total, &score.DistanceKm, &score.Distance, &score.Recency, &score.Engagement)
+		if err != nil {
+			return nil, nil, err
+		}
+		post.Score = &score
+		posts = append(posts, post)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if int64(len(posts)) <= limit {
+		return posts, nil, nil
+	}
+
+	posts = posts[:limit]
+	last := posts[limit-1]
+
+	return posts, &entities.PageCursor{Score: last.Score.Total, AsOf: asOf, Id: last.PostId.String()}, nil
+}
+
+func (r *PostgresRepository) GetPostsByBBox(box geo.BBox, order entities.PostOrder, viewerId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error) {
+	query, args, err := r.bboxQuery(box, order, viewerId, after, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	posts, err := r.queryPosts(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	posts, next, err := pagePosts(posts, nil, limit)
+	if next != nil && order == entities.PostOrderScore {
+		next.Score = float64(posts[len(posts)-1].MessageCount)
+	}
+
+	return posts, next, err
+}
+
+func (r *PostgresRepository) EachPostInBBox(box geo.BBox, order entities.PostOrder, viewerId uuid.UUID, limit int64, fn func(*entities.Post) error) error {
+	query, args, err := r.bboxQuery(box, order, viewerId, nil, limit)
+	if err != nil {
+		return err
+	}
+
+	return r.eachPost(query, args, false, func(post *entities.Post, _ float64) error {
+		return fn(post)
+	})
+}
+
+func (r *PostgresRepository) bboxQuery(box geo.BBox, order entities.PostOrder, viewerId uuid.UUID, after *entities.PageCursor, limit int64) (string, []any, error) {
+	filter, args := r.bboxFilter([]any{parsePostgresLimit(limit)}, box)
+
+	keyset := ""
+	if after != nil {
+		afterId, err := uuid.Parse(after.Id)
+		if err != nil {
+			return "", nil, errors.ErrInvalidCursor
+		}
+
+		switch order {
+		case entities.PostOrderScore:
+			args = append(args, int64(after.Score), after.CreatedAt, afterId)
+			keyset = fmt.Sprintf("AND (message_count, created_at, post_id) < ($%d, $%d, $%d)", len(args)-2, len(args)-1, len(args))
+		default:
+			args = append(args, after.CreatedAt, afterId)
+			keyset = fmt.Sprintf("AND (created_at, post_id) < ($%d, $%d)", len(args)-1, len(args))
+		}
+	}
+
+	sort := "created_at DESC, post_id DESC"
+	if order == entities.PostOrderScore {
+		sort = "message_count DESC, " + sort
+	}
+
+	args = append(args, viewerId)
+
+	query := fmt.Sprintf(`SELECT %s FROM %s
+			WHERE (%s) %s
+			ORDER BY %s
+			LIMIT $1`, postColumns(len(args)), postsTableName, filter, keyset, sort)
+
+	return query, args, nil
+}
+
+// bboxFilter matches the public locations inside the box, splitting it at the
+// antimeridian and narrowing each part with the spatial filter first.
+func (r *PostgresRepository) bboxFilter(args []any, box geo.BBox) (string, []any) {
+	parts := make([]string, 0, 2)
+	for _, part := range box.Split() {
+		center, radius := part.BoundingCircle()
+
+		var filter string
+		filter, args = r.spatialFilter(args, center.Latitude, center.Longitude, radius)
+		args = append(args, part.SouthWest.Latitude, part.NorthEast.Latitude, part.SouthWest.Longitude, part.NorthEast.Longitude)
+
+		n := len(args)
+		parts = append(parts, fmt.Sprintf(`(%s AND latitude BETWEEN $%d AND $%d AND longitude BETWEEN $%d AND $%d)`,
+			filter, n-3, n-2, n-1, n))
+	}
+
+	return strings.Join(parts, " OR "), args
+}
+
+// GetPostClusters groups the posts inside the given geohash cells by the
+// first precision characters of their geohash.
+func (r *PostgresRepository) GetPostClusters(cells []string, precision, samples int) ([]*entities.PostCluster, error) {
+	query := fmt.Sprintf(`SELECT left(p.geohash, $2) AS cluster, COUNT(*), AVG(p.latitude), AVG(p.longitude),
+				(array_agg(p.post_id::TEXT ORDER BY p.message_count DESC, p.created_at DESC))[1:$3]
+			FROM unnest($1::TEXT[]) AS cell(prefix)
+			JOIN %s p ON p.geohash >= cell.prefix AND p.geohash < cell.prefix || '~'
+			GROUP BY cluster`, postsTableName)
+
+	rows, err := r.postgresDB.Query(query, pq.Array(cells), precision, samples)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	clusters := make([]*entities.PostCluster, 0)
+	for rows.Next() {
+		var cluster entities.PostCluster
+		var postIds []string
+
+		err = rows.Scan(&cluster.Geohash, &cluster.Count, &cluster.Latitude, &cluster.Longitude, pq.Array(&postIds))
+		if err != nil {
+			return nil, err
+		}
+
+		cluster.PostIds = make([]uuid.UUID, 0, len(postIds))
+		for _, raw := range postIds {
+			postId, err := uuid.Parse(raw)
+			if err != nil {
+				return nil, err
+			}
+			cluster.PostIds = append(cluster.PostIds, postId)
+		}
+
+		clusters = append(clusters, &cluster)
+	}
+
+	return clusters, rows.Err()
+}
+
+func (r *PostgresRepository) UpdatePostMessageCount(postId uuid.UUID, delta int) error {
+	query := fmt.Sprintf(`UPDATE %s SET message_count = GREATEST(message_count + $2, 0) WHERE post_id = $1`, postsTableName)
+
+	_, err := r.postgresDB.Exec(query, postId, delta)
+	return err
+}
+
+func (r *PostgresRepository) IsBackfillDone(name string) (bool, error) {
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE name = $1)`, backfillsTableName)
+
+	var done bool
+	err := r.postgresDB.QueryRow(query, name).Scan(&done)
+	return done, err
+}
+
+// ReplacePostMessageCounts overwrites message_count with the given counts,
+// zeroing posts that are missing from them, and records the backfill as done
+// in the same transaction.
+func (r *PostgresRepository) ReplacePostMessageCounts(counts map[uuid.UUID]int64, backfill string) error {
+	postIds := make([]string, 0, len(counts))
+	values := make([]int64, 0, len(counts))
+	for postId, count := range counts {
+		postIds = append(postIds, postId.String())
+		values = append(values, count)
+	}
+
+	tx, err := r.postgresDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`UPDATE %s SET message_count = 0 WHERE message_count <> 0 AND post_id <> ALL($1::UUID[])`, postsTableName)
+	if _, err = tx.Exec(query, pq.Array(postIds)); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(`UPDATE %s p SET message_count = c.count
+			FROM unnest($1::UUID[], $2::BIGINT[]) AS c(post_id, count)
+			WHERE p.post_id = c.post_id AND p.message_count <> c.count`, postsTableName)
+	if _, err = tx.Exec(query, pq.Array(postIds), pq.Array(values)); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, backfillsTableName)
+	if _, err = tx.Exec(query, backfill); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// spatialFilter appends the arguments of an index-backed prefilter for posts
+// within radius km of the point. Callers still apply the exact condition.
+func (r *PostgresRepository) spatialFilter(args []any, latitude, longitude, radius float64) (string, []any) {
+	if r.hasPostGIS() {
+		args = append(args, latitude, longitude, radius*1000*spatialSearchSlack)
+		n := len(args)
+		return fmt.Sprintf("ST_DWithin(location, ST_SetSRID(ST_MakePoint($%d, $%d), 4326)::geography, $%d, false)",
+			n-1, n-2, n), args
+	}
+
+	cells := geohash.Cover(latitude, longitude, radius)
+	if cells == nil {
+		return "TRUE", args
+	}
+
+	args = append(args, pq.Array(cells))
+	return fmt.Sprintf(`post_id IN (
+				SELECT p.post_id FROM unnest($%d::TEXT[]) AS cell(prefix)
+				JOIN %s p ON p.geohash >= cell.prefix AND p.geohash < cell.prefix || '~'
+			)`, len(args), postsTableName), args
+}
+
+// pagePosts trims the extra row fetched past limit and turns the last kept row
+// into the cursor of the next page.
+func pagePosts(posts []*entities.Post, distances []float64, limit int64) ([]*entities.Post, *entities.PageCursor, error) {
+	if int64(len(posts)) <= limit {
+		return posts, nil, nil
+	}
+
+	posts = posts[:limit]
+	last := posts[limit-1]
+	next := &entities.PageCursor{CreatedAt: last.CreatedAt, Id: last.PostId.String()}
+	if distances != nil {
+		next.Distance = distances[limit-1]
+	}
+
+	return posts, next, nil
+}
+
+func (r *PostgresRepository) GetPostByPostId(postId, viewerId uuid.UUID) (*entities.Post, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE post_id = $1`, postColumns(2), postsTableName)
+
+	post, err := r.scanPost(r.postgresDB.QueryRow(query, postId, viewerId))
+	if err != nil {
+		if stderr.Is(err, sql.ErrNoRows) {
+			return nil, errors.ErrInvalidPostId
+		}
+		return nil, err
+	}
+
+	return post, nil
+}
+
+func (r *PostgresRepository) UpdatePostById(title, content string, postId, userId uuid.UUID) (*entities.Post, error) {
+	query := fmt.Sprintf(`UPDATE %s SET title = $1, content = $2 
+          WHERE post_id = $3 AND user_id = $4 RETURNING %s`, postsTableName, postColumns(4))
+	//TODO: по хорошему добавить проверку на доступ к посту (и месаги) а не просто инвалид пост ид
+	post, err := r.scanPost(r.postgresDB.QueryRow(query, title, content, postId, userId))
+	if err != nil {
+		if stderr.Is(err, sql.ErrNoRows) {
+			return nil, errors.ErrInvalidPostId
+		}
+		return nil, err
+	}
+
+	return post, nil
+}
+
+func (r *PostgresRepository) DeletePostById(postId, userId uuid.UUID) (*entities.Post, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE post_id = $1 AND user_id = $2 RETURNING %s`, postsTableName, postColumns(3))
+
+	post, err := r.scanPost(r.postgresDB.QueryRow(query, postId, userId, uuid.Nil))
+	if err != nil {
+		if stderr.Is(err, sql.ErrNoRows) {
+			return nil, errors.ErrInvalidPostId
+		}
+		return nil, err
+	}
+
+	return post, nil
+}
+
+func (r *PostgresRepository) ForceDeletePostById(postId uuid.UUID) (*entities.Post, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE post_id = $1 RETURNING %s`, postsTableName, postColumns(2))
+
+	post, err := r.scanPost(r.postgresDB.QueryRow(query, postId, uuid.Nil))
+	if err != nil {
+		if stderr.Is(err, sql.ErrNoRows) {
+			return nil, errors.ErrInvalidPostId
+		}
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// likeEscaper makes user input match itself literally inside a LIKE pattern
+// declared with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+func parsePostgresLimit(limit int64) any {
+	if limit < 1 {
+		return nil
+	}
+	return limit
+}
+
+func (r *PostgresRepository) queryPosts(query string, args ...any) ([]*entities.Post, error) {
+	posts := make([]*entities.Post, 0)
+
+	rows, err := r.postgresDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		post, err := r.scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// eachPost hands rows to fn one at a time, so callers streaming a response
+// never hold the whole result set. With distance set the query must select
+// the sort distance after the post columns.
+func (r *PostgresRepository) eachPost(query string, args []any, distance bool, fn func(post *entities.Post, distance float64) error) error {
+	rows, err := r.postgresDB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var d float64
+		var extra []any
+		if distance {
+			extra = append(extra, &d)
+		}
+
+		post, err := r.scanPost(rows, extra...)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(post, d); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *PostgresRepository) scanPost(row interface{ Scan(dest ...any) error }, extra ...any) (*entities.Post, error) {
+	var post entities.Post
+	var areas []byte
+
+	dest := append([]any{&post.PostId, &post.UserId,
+		&post.Title, &post.Content,
+		&post.IdempotencyKey, &post.Latitude,
+		&post.Longitude, &post.Precision,
+		&post.Country, &post.Region, &post.Locality, &post.MessageCount,
+		&post.CreatedAt, &post.UpdatedAt, &areas}, extra...)
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(areas, &post.Areas); err != nil {
+		return nil, err
+	}
+
+	return &post, nil
+}
+
+// CreateArea inserts the area and tags the existing posts whose public
+// location it holds in one transaction, so a failed tagging leaves no area
+// behind and the request can simply be retried.
+func (r *PostgresRepository) CreateArea(slug, name string, polygons geo.MultiPolygon) (*entities.Area, error) {
+	geometry, err := geojson.EncodePolygons(polygons)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.postgresDB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	bounds := polygons.Bounds()
+	query := fmt.Sprintf(`INSERT INTO %s (slug, name, geometry, min_latitude, min_longitude, max_latitude, max_longitude)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING %s`, areasTableName, areaColumns)
+
+	area, err := r.scanArea(tx.QueryRow(query, slug, name, geometry,
+		bounds.SouthWest.Latitude, bounds.SouthWest.Longitude, bounds.NorthEast.Latitude, bounds.NorthEast.Longitude))
+	if err != nil {
+		pgErr, ok := err.(*pq.Error)
+		if ok && pgErr.Code == "23505" { // 23505 - unique_violation
+			return nil, errors.ErrAreaSlugTaken
+		}
+		return nil, err
+	}
+
+	postIds, err := r.postIdsInArea(tx, area)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(postIds) > 0 {
+		query = fmt.Sprintf(`INSERT INTO %s (post_id, area_id)
+				SELECT unnest($2::UUID[]), $1 ON CONFLICT DO NOTHING`, postAreasTableName)
+		if _, err = tx.Exec(query, area.AreaId, pq.Array(uuidStrings(postIds))); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return area, nil
+}
+
+func (r *PostgresRepository) GetAreaBySlug(slug string) (*entities.Area, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE slug = $1`, areaColumns, areasTableName)
+
+	area, err := r.scanArea(r.postgresDB.QueryRow(query, slug))
+	if err != nil {
+		if stderr.Is(err, sql.ErrNoRows) {
+			return nil, errors.ErrAreaNotFound
+		}
+		return nil, err
+	}
+
+	return area, nil
+}
+
+func (r *PostgresRepository) DeleteAreaBySlug(slug string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE slug = $1`, areasTableName)
+
+	result, err := r.postgresDB.Exec(query, slug)
+	if err != nil {
+		return err
+	}
+
+	countRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if countRows != 1 {
+		return errors.ErrAreaNotFound
+	}
+
+	return nil
+}
+
+// areaIdsContaining returns the areas whose polygons hold the point, using the
+// bounding box columns to pick the candidates.
+func (r *PostgresRepository) areaIdsContaining(tx *sql.Tx, latitude, longitude float64) ([]uuid.UUID, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s
+			WHERE min_latitude <= $1 AND max_latitude >= $1
+				AND min_longitude <= $2 AND max_longitude >= $2`, areaColumns, areasTableName)
+
+	rows, err := tx.Query(query, latitude, longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	point := geo.Coordinate{Latitude: latitude, Longitude: longitude}
+
+	areaIds := make([]uuid.UUID, 0)
+	for rows.Next() {
+		area, err := r.scanArea(rows)
+		if err != nil {
+			return nil, err
+		}
+		if area.Geometry.Contains(point) {
+			areaIds = append(areaIds, area.AreaId)
+		}
+	}
+
+	return areaIds, rows.Err()
+}
+
+// postIdsInArea returns the posts whose public location the area's polygons
+// hold, using the spatial filter on the bounding box to pick the candidates.
+func (r *PostgresRepository) postIdsInArea(tx *sql.Tx, area *entities.Area) ([]uuid.UUID, error) {
+	filter, args := r.bboxFilter(nil, area.Geometry.Bounds())
+
+	query := fmt.Sprintf(`SELECT post_id, latitude, longitude FROM %s WHERE %s`, postsTableName, filter)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	postIds := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var postId uuid.UUID
+		var point geo.Coordinate
+
+		if err = rows.Scan(&postId, &point.Latitude, &point.Longitude); err != nil {
+			return nil, err
+		}
+		if area.Geometry.Contains(point) {
+			postIds = append(postIds, postId)
+		}
+	}
+
+	return postIds, rows.Err()
+}
+
+// GetPostsByAreaId pages through the posts tagged with the area, newest first.
+func (r *PostgresRepository) GetPostsByAreaId(areaId, viewerId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error) {
+	args := []any{areaId, limit + 1}
+
+	keyset := ""
+	if after != nil {
+		afterId, err := uuid.Parse(after.Id)
+		if err != nil {
+			return nil, nil, errors.ErrInvalidCursor
+		}
+		args = append(args, after.CreatedAt, afterId)
+		keyset = "AND (created_at, post_id) < ($3, $4)"
+	}
+
+	args = append(args, viewerId)
+
+	query := fmt.Sprintf(`SELECT %s FROM %s JOIN %s tagged USING (post_id)
+			WHERE tagged.area_id = $1 %s
+			ORDER BY created_at DESC, post_id DESC LIMIT $2`, postColumns(len(args)), postsTableName, postAreasTableName, keyset)
+
+	posts, err := r.queryPosts(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pagePosts(posts, nil, limit)
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, id.String())
+	}
+	return strs
+}
+
+func (r *PostgresRepository) scanArea(row interface{ Scan(dest ...any) error }) (*entities.Area, error) {
+	var area entities.Area
+	var geometry []byte
+
+	err := row.Scan(&area.AreaId, &area.Slug, &area.Name, &geometry, &area.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if area.Geometry, err = geojson.DecodePolygons(geometry); err != nil {
+		return nil, err
+	}
+
+	return &area, nil
+}
+
+func (r *PostgresRepository) hasPostGIS() bool {
+	r.spatialOnce.Do(func() {
+		query := `SELECT EXISTS (SELECT 1 FROM information_schema.columns
+				WHERE table_name = $1 AND column_name = 'location')`
+
+		if err := r.postgresDB.QueryRow(query, postsTableName).Scan(&r.postGIS); err != nil {
+			r.postGIS = false
+		}
+	})
+	return r.postGIS
 }
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/skrpld/NearBeee/internal/core/database/postgres"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+)
+
+// testPostgresDSNEnv names a migrated database the location tests may write
+// to. The tests are skipped when it is unset.
+const testPostgresDSNEnv = "NEARBEEE_TEST_POSTGRES_DSN"
+
+const seedPostsQuery = `INSERT INTO posts (user_id, title, content, idempotency_key, latitude, longitude)
+		SELECT $1, 'locationtest', 'locationtest', 'locationtest-' || uuid_generate_v4(), p.lat, p.lon
+		FROM unnest($2::DOUBLE PRECISION[], $3::DOUBLE PRECISION[]) AS p(lat, lon)`
+
+var testCities = [][2]float64{{55.75, 37.62}, {40.71, -74.01}, {35.68, 139.69}, {-33.87, 151.21}}
+
+// locationRepos returns a repository using PostGIS and one forced onto the
+// geohash fallback, both over a freshly seeded set of posts.
+func locationRepos(tb testing.TB, posts int) (indexed, fallback *PostgresRepository) {
+	tb.Helper()
+
+	dsn := os.Getenv(testPostgresDSNEnv)
+	if dsn == "" {
+		tb.Skipf("%s is not set", testPostgresDSNEnv)
+	}
+
+	sqlDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	db := &postgres.PostgresDB{DB: sqlDB}
+	tb.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err = db.PingContext(ctx); err != nil {
+		tb.Fatal(err)
+	}
+
+	indexed = NewPostgresRepository(db)
+	if !indexed.hasPostGIS() {
+		tb.Skip("postgis is not installed")
+	}
+
+	fallback = NewPostgresRepository(db)
+	fallback.spatialOnce.Do(func() {})
+
+	user, err := indexed.CreateUser(fmt.Sprintf("locationtest-%s@nearbeee.invalid", uuid.NewString()), "x")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		if err := indexed.DeleteUser(user.UserId); err != nil {
+			tb.Error(err)
+		}
+	})
+
+	rnd := rand.New(rand.NewSource(1))
+	latitudes := make([]float64, posts)
+	longitudes := make([]float64, posts)
+	for i := range posts {
+		city := testCities[i%len(testCities)]
+		latitudes[i] = city[0] + rnd.Float64() - 0.5
+		longitudes[i] = city[1] + rnd.Float64() - 0.5
+	}
+
+	if _, err = db.Exec(seedPostsQuery, user.UserId, pq.Array(latitudes), pq.Array(longitudes)); err != nil {
+		tb.Fatal(err)
+	}
+	if _, err = db.Exec(`ANALYZE posts`); err != nil {
+		tb.Fatal(err)
+	}
+
+	return indexed, fallback
+}
+
+func postIds(posts []*entities.Post) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.PostId)
+	}
+	return ids
+}
+
+func TestGetPostsByLocationFallbackMatchesPostGIS(t *testing.T) {
+	indexed, fallback := locationRepos(t, 20_000)
+
+	rnd := rand.New(rand.NewSource(2))
+	for i := range 20 {
+		city := testCities[rnd.Intn(len(testCities))]
+		latitude := city[0] + rnd.Float64()*0.6 - 0.3
+		longitude := city[1] + rnd.Float64()*0.6 - 0.3
+		radius := []float64{0.5, 2, 10}[i%3]
+
+		var indexedAfter, fallbackAfter *entities.PageCursor
+		for page := 0; page < 3; page++ {
+			want, wantNext, err := indexed.GetPostsByLocation(latitude, longitude, radius, uuid.Nil, indexedAfter, 25)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, gotNext, err := fallback.GetPostsByLocation(latitude, longitude, radius, uuid.Nil, fallbackAfter, 25)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(postIds(want), postIds(got)) {
+				t.Fatalf("(%f, %f) within %.1f km, page %d: fallback returned %v, postgis %v",
+					latitude, longitude, radius, page, postIds(got), postIds(want))
+			}
+			if (wantNext == nil) != (gotNext == nil) {
+				t.Fatalf("(%f, %f) within %.1f km, page %d: cursors differ", latitude, longitude, radius, page)
+			}
+			if wantNext == nil {
+				break
+			}
+			if wantNext.Distance != gotNext.Distance {
+				t.Fatalf("(%f, %f) within %.1f km, page %d: cursor distances %v and %v differ",
+					latitude, longitude, radius, page, wantNext.Distance, gotNext.Distance)
+			}
+			indexedAfter, fallbackAfter = wantNext, gotNext
+		}
+	}
+}
+
+func BenchmarkGetPostsByLocation(b *testing.B) {
+	indexed, fallback := locationRepos(b, 200_000)
+
+	for _, bench := range []struct {
+		name string
+		repo *PostgresRepository
+	}{
+		{"postgis", indexed},
+		{"geohash", fallback},
+	} {
+		for _, radius := range []float64{1, 5, 25} {
+			b.Run(fmt.Sprintf("%s/radius=%gkm", bench.name, radius), func(b *testing.B) {
+				rnd := rand.New(rand.NewSource(3))
+				for b.Loop() {
+					city := testCities[rnd.Intn(len(testCities))]
+					_, _, err := bench.repo.GetPostsByLocation(city[0]+rnd.Float64()-0.5, city[1]+rnd.Float64()-0.5, radius, uuid.Nil, nil, 50)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"":                 "",
+		"user@example.com": "user@example.com",
+		"100%":             `100\%`,
+		"first_last":       `first\_last`,
+		`back\slash`:       `back\\slash`,
+		`\%_`:              `\\\%\_`,
+	}
+
+	for input, want := range tests {
+		if got := escapeLike(input); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
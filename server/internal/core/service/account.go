@@ -0,0 +1,402 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	stderr "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/logger"
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/geo"
+	"github.com/skrpld/NearBeee/pkg/utils/hash"
+	"github.com/skrpld/NearBeee/pkg/utils/jwt"
+	"github.com/skrpld/NearBeee/pkg/utils/mail"
+	"github.com/skrpld/NearBeee/pkg/utils/storage"
+)
+
+const exportPageSize = 500
+
+type AccountConfig struct {
+	DeletionGracePeriod  time.Duration `env:"ACCOUNT_DELETION_GRACE_PERIOD" env-default:"720h" mapstructure:"ACCOUNT_DELETION_GRACE_PERIOD"`
+	DeletionPollInterval time.Duration `env:"ACCOUNT_DELETION_POLL_INTERVAL" env-default:"1m" mapstructure:"ACCOUNT_DELETION_POLL_INTERVAL"`
+	DeletionLease        time.Duration `env:"ACCOUNT_DELETION_LEASE" env-default:"10m" mapstructure:"ACCOUNT_DELETION_LEASE"`
+	DeletionConfirmTTL   time.Duration `env:"ACCOUNT_DELETION_CONFIRM_TTL" env-default:"30m" mapstructure:"ACCOUNT_DELETION_CONFIRM_TTL"`
+	DeletionConfirmUrl   string        `env:"ACCOUNT_DELETION_CONFIRM_URL" env-default:"nearbeee://confirm-account-deletion?token={token}" mapstructure:"ACCOUNT_DELETION_CONFIRM_URL"`
+	MailResendInterval   time.Duration `env:"MAIL_RESEND_INTERVAL" env-default:"1m" mapstructure:"MAIL_RESEND_INTERVAL"`
+}
+
+type AccountRepository interface {
+	GetUserById(userId uuid.UUID) (*entities.User, error)
+	GetProfileByUserId(userId uuid.UUID) (*entities.Profile, error)
+	GetPostsByUserId(userId, viewerId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error)
+	GetActiveSessionsByUserId(userId uuid.UUID) ([]*entities.Session, error)
+	RevokeAllSessions(userId uuid.UUID) error
+	RevokeAllApiKeys(userId uuid.UUID) error
+	ScheduleAccountDeletion(userId uuid.UUID, scheduledFor time.Time) (*entities.AccountDeletion, error)
+	CreateUserToken(token *entities.UserToken) error
+	ConsumeUserToken(tokenId uuid.UUID, purpose string) (uuid.UUID, error)
+	GetLatestUserToken(userId uuid.UUID, purpose string) (*entities.UserToken, error)
+}
+
+type AccountMessagesRepository interface {
+	GetMessageByUserId(ctx context.Context, userId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Message, *entities.PageCursor, error)
+}
+
+type AccountService struct {
+	repo         AccountRepository
+	messagesRepo AccountMessagesRepository
+	storage      storage.Storage
+	mailer       mail.Mailer
+	cfg          AccountConfig
+}
+
+func NewAccountService(repo AccountRepository, messagesRepo AccountMessagesRepository, storage storage.Storage, mailer mail.Mailer, cfg AccountConfig) *AccountService {
+	return &AccountService{repo: repo, messagesRepo: messagesRepo, storage: storage, mailer: mailer, cfg: cfg}
+}
+
+// DeleteAccount schedules the account for deletion once the user confirms it
+// with their password or, for accounts without one, with the token emailed in
+// reply to a request carrying neither.
+func (s *AccountService) DeleteAccount(rows *dto.DeleteAccountRequest) (*dto.DeleteAccountResponse, error) {
+	user, err := s.repo.GetUserById(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case rows.Token != "":
+		if err = s.consumeDeletionToken(user.UserId, rows.Token); err != nil {
+			return nil, err
+		}
+	case user.HasPassword():
+		if err = hash.CompareHashAndPassword(user.PasswordHash, rows.Password); err != nil {
+			return nil, errors.ErrInvalidPassword
+		}
+	default:
+		if err = s.sendDeletionConfirmation(user); err != nil {
+			return nil, err
+		}
+		return &dto.DeleteAccountResponse{ConfirmationSent: true}, nil
+	}
+
+	deletion, err := s.repo.ScheduleAccountDeletion(user.UserId, time.Now().Add(s.cfg.DeletionGracePeriod))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.RevokeAllSessions(user.UserId); err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.RevokeAllApiKeys(user.UserId); err != nil {
+		return nil, err
+	}
+
+	response := dto.DeleteAccountResponse{
+		ScheduledFor: deletion.ScheduledFor,
+	}
+
+	return &response, nil
+}
+
+func (s *AccountService) consumeDeletionToken(userId uuid.UUID, deletionToken string) error {
+	tokenClaims, err := jwt.ValidateToken(deletionToken, jwt.AccountDeletionTokenType)
+	if err != nil {
+		return err
+	}
+
+	jti, err := jwt.GetStringClaim(tokenClaims, "jti")
+	if err != nil {
+		return err
+	}
+
+	tokenId, err := uuid.Parse(jti)
+	if err != nil {
+		return errors.ErrInvalidToken
+	}
+
+	tokenUserId, err := s.repo.ConsumeUserToken(tokenId, entities.AccountDeletionPurpose)
+	if err != nil {
+		return err
+	}
+	if tokenUserId != userId {
+		return errors.ErrInvalidToken
+	}
+
+	return nil
+}
+
+func (s *AccountService) sendDeletionConfirmation(user *entities.User) error {
+	lastToken, err := s.repo.GetLatestUserToken(user.UserId, entities.AccountDeletionPurpose)
+	if err != nil {
+		return err
+	}
+
+	if lastToken != nil && time.Since(lastToken.CreatedAt) < s.cfg.MailResendInterval {
+		return nil
+	}
+
+	token := &entities.UserToken{
+		TokenId:   uuid.New(),
+		UserId:    user.UserId,
+		Purpose:   entities.AccountDeletionPurpose,
+		ExpiresAt: time.Now().Add(s.cfg.DeletionConfirmTTL),
+	}
+
+	deletionToken, err := jwt.NewOneTimeToken(user.UserId.String(), token.TokenId.String(),
+		jwt.AccountDeletionTokenType, s.cfg.DeletionConfirmTTL)
+	if err != nil {
+		return err
+	}
+
+	if err = s.repo.CreateUserToken(token); err != nil {
+		return err
+	}
+
+	link := strings.ReplaceAll(s.cfg.DeletionConfirmUrl, "{token}", deletionToken)
+
+	return s.mailer.Send(&mail.Message{
+		To:      user.Email,
+		Subject: "Confirm deleting your NearBeee account",
+		Body: "Someone asked to delete your NearBeee account.\n\n" +
+			"Open the link below to confirm the deletion:\n" + link + "\n\n" +
+			"The link expires in " + s.cfg.DeletionConfirmTTL.String() + " and can be used once.\n" +
+			"If it was not you, ignore this email and your account stays as it is.\n",
+	})
+}
+
+func (s *AccountService) ExportAccount(ctx context.Context, rows *dto.ExportAccountRequest) (*dto.ExportAccountResponse, error) {
+	user, err := s.repo.GetUserById(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.repo.GetProfileByUserId(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+	profile.AvatarUrl = avatarUrl(s.storage, profile.AvatarKey)
+
+	var posts []*entities.Post
+	for after := (*entities.PageCursor)(nil); ; {
+		page, next, err := s.repo.GetPostsByUserId(rows.UserId, rows.UserId, after, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, page...)
+		if after = next; after == nil {
+			break
+		}
+	}
+
+	var messages []*entities.Message
+	for after := (*entities.PageCursor)(nil); ; {
+		page, next, err := s.messagesRepo.GetMessageByUserId(ctx, rows.UserId, after, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, page...)
+		if after = next; after == nil {
+			break
+		}
+	}
+
+	sessions, err := s.repo.GetActiveSessionsByUserId(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	account := map[string]any{
+		"user_id":  user.UserId,
+		"email":    user.Email,
+		"verified": user.Verified,
+		"role":     user.Role,
+	}
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"account.json", account},
+		{"profile.json", profile},
+		{"posts.json", emptyIfNil(posts)},
+		{"messages.json", emptyIfNil(messages)},
+		{"sessions.json", emptyIfNil(sessions)},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		return nil, err
+	}
+
+	response := dto.ExportAccountResponse{
+		FileName: fmt.Sprintf("nearbeee-export-%s.zip", time.Now().UTC().Format("20060102")),
+		Data:     buf.Bytes(),
+	}
+
+	return &response, nil
+}
+
+func emptyIfNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+type AccountDeletionRepository interface {
+	ClaimAccountDeletion(lease time.Duration) (*entities.AccountDeletion, error)
+	AdvanceAccountDeletion(userId uuid.UUID, step string) error
+	FailAccountDeletion(userId uuid.UUID, reason string) error
+	GetPostIdsByUserId(userId uuid.UUID) ([]uuid.UUID, error)
+	GetPostLocationsByUserId(userId uuid.UUID) ([]geo.Coordinate, error)
+	GetProfileByUserId(userId uuid.UUID) (*entities.Profile, error)
+	DeleteUser(userId uuid.UUID) error
+}
+
+type AccountDeletionMessagesRepository interface {
+	DeleteMessagesByPostIds(ctx context.Context, postIds []uuid.UUID) error
+	AnonymizeMessagesByUserId(ctx context.Context, userId uuid.UUID) error
+}
+
+type AccountDeletionWorker struct {
+	repo         AccountDeletionRepository
+	messagesRepo AccountDeletionMessagesRepository
+	storage      storage.Storage
+	clusters     *ClusterCache
+	cfg          AccountConfig
+	logger       logger.Logger
+}
+
+func NewAccountDeletionWorker(repo AccountDeletionRepository, messagesRepo AccountDeletionMessagesRepository, storage storage.Storage, clusters *ClusterCache, cfg AccountConfig, logger logger.Logger) *AccountDeletionWorker {
+	return &AccountDeletionWorker{repo: repo, messagesRepo: messagesRepo, storage: storage, clusters: clusters, cfg: cfg, logger: logger}
+}
+
+func (w *AccountDeletionWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.DeletionPollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.processDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *AccountDeletionWorker) processDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		deletion, err := w.repo.ClaimAccountDeletion(w.cfg.DeletionLease)
+		if err != nil {
+			w.logger.Error("account deletion claim failed", logger.Error(err))
+			return
+		}
+		if deletion == nil {
+			return
+		}
+
+		jobLogger := w.logger.With(logger.String("user_id", deletion.UserId.String()))
+
+		if err = w.process(ctx, deletion); err != nil {
+			jobLogger.Error("account deletion failed", logger.String("step", deletion.Step), logger.Error(err))
+
+			if err = w.repo.FailAccountDeletion(deletion.UserId, err.Error()); err != nil {
+				jobLogger.Error("account deletion failure not recorded", logger.Error(err))
+			}
+			continue
+		}
+
+		jobLogger.Info("account deleted")
+	}
+}
+
+func (w *AccountDeletionWorker) process(ctx context.Context, deletion *entities.AccountDeletion) error {
+	start := 0
+	for i, step := range entities.DeletionSteps {
+		if step == deletion.Step {
+			start = i
+			break
+		}
+	}
+
+	for i := start; i < len(entities.DeletionSteps); i++ {
+		deletion.Step = entities.DeletionSteps[i]
+
+		if err := w.runStep(ctx, deletion.UserId, deletion.Step); err != nil {
+			return err
+		}
+
+		if i+1 < len(entities.DeletionSteps) {
+			if err := w.repo.AdvanceAccountDeletion(deletion.UserId, entities.DeletionSteps[i+1]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *AccountDeletionWorker) runStep(ctx context.Context, userId uuid.UUID, step string) error {
+	switch step {
+	case entities.DeletionStepMessages:
+		postIds, err := w.repo.GetPostIdsByUserId(userId)
+		if err != nil {
+			return err
+		}
+		return w.messagesRepo.DeleteMessagesByPostIds(ctx, postIds)
+	case entities.DeletionStepAnonymize:
+		return w.messagesRepo.AnonymizeMessagesByUserId(ctx, userId)
+	case entities.DeletionStepAvatar:
+		profile, err := w.repo.GetProfileByUserId(userId)
+		if err != nil {
+			if stderr.Is(err, errors.ErrProfileNotFound) {
+				return nil
+			}
+			return err
+		}
+		if profile.AvatarKey == nil {
+			return nil
+		}
+		return w.storage.Delete(*profile.AvatarKey)
+	case entities.DeletionStepUser:
+		locations, err := w.repo.GetPostLocationsByUserId(userId)
+		if err != nil {
+			return err
+		}
+		if err = w.repo.DeleteUser(userId); err != nil {
+			return err
+		}
+		for _, location := range locations {
+			w.clusters.Invalidate(location.Latitude, location.Longitude)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown account deletion step %q", step)
+	}
+}
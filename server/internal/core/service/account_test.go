@@ -0,0 +1,139 @@
+package service
+
+import (
+	stderr "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/jwt"
+	"github.com/skrpld/NearBeee/pkg/utils/mail"
+)
+
+const deletionConfirmUrl = "https://nearbeee.test/delete?token="
+
+func newTestAccountService(t *testing.T) (*AccountService, *memoryAccountRepo, *mail.MemoryMailer) {
+	t.Helper()
+
+	if err := jwt.UpdateJWTConfig(&jwt.JWTConfig{
+		AccessTokenExpiryTime:  time.Hour,
+		RefreshTokenExpiryTime: time.Hour,
+		IssuedAt:               "nearbeee-test",
+		Algorithm:              "HS512",
+	}, testSecret); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := newMemoryAccountRepo()
+	mailer := mail.NewMemoryMailer()
+	s := NewAccountService(repo, nil, nil, mailer, AccountConfig{
+		DeletionGracePeriod: 24 * time.Hour,
+		DeletionConfirmTTL:  time.Hour,
+		DeletionConfirmUrl:  deletionConfirmUrl + "{token}",
+		MailResendInterval:  time.Minute,
+	})
+
+	return s, repo, mailer
+}
+
+// emailedToken returns the token in the confirmation link of the last message.
+func emailedToken(t *testing.T, mailer *mail.MemoryMailer) string {
+	t.Helper()
+
+	messages := mailer.Messages()
+	if len(messages) == 0 {
+		t.Fatal("no message sent")
+	}
+
+	_, link, ok := strings.Cut(messages[len(messages)-1].Body, deletionConfirmUrl)
+	if !ok {
+		t.Fatalf("no confirmation link in %q", messages[len(messages)-1].Body)
+	}
+	token, _, _ := strings.Cut(link, "\n")
+	return token
+}
+
+func TestDeleteAccountWithPassword(t *testing.T) {
+	s, repo, mailer := newTestAccountService(t)
+	user := addPasswordUser(t, repo.auth, "user@example.com", "correct horse")
+
+	_, err := s.DeleteAccount(&dto.DeleteAccountRequest{UserId: user.UserId, Password: "battery staple"})
+	if !stderr.Is(err, errors.ErrInvalidPassword) {
+		t.Fatalf("wrong password: err = %v, want %v", err, errors.ErrInvalidPassword)
+	}
+	if _, err = s.DeleteAccount(&dto.DeleteAccountRequest{UserId: user.UserId}); !stderr.Is(err, errors.ErrInvalidPassword) {
+		t.Fatalf("no password: err = %v, want %v", err, errors.ErrInvalidPassword)
+	}
+
+	response, err := s.DeleteAccount(&dto.DeleteAccountRequest{UserId: user.UserId, Password: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ScheduledFor.IsZero() || !repo.deletions[user.UserId].Equal(response.ScheduledFor) {
+		t.Errorf("scheduled for %v, repository has %v", response.ScheduledFor, repo.deletions[user.UserId])
+	}
+	if len(mailer.Messages()) != 0 {
+		t.Error("a password confirmation sent an email")
+	}
+}
+
+func TestDeleteAccountWithoutPassword(t *testing.T) {
+	s, repo, mailer := newTestAccountService(t)
+	user := repo.auth.addUser("user@example.com", true)
+
+	response, err := s.DeleteAccount(&dto.DeleteAccountRequest{UserId: user.UserId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.ConfirmationSent || !response.ScheduledFor.IsZero() {
+		t.Fatalf("response = %+v, want only a confirmation sent", response)
+	}
+	if _, ok := repo.deletions[user.UserId]; ok {
+		t.Fatal("deletion scheduled before confirmation")
+	}
+	if messages := mailer.Messages(); len(messages) != 1 || messages[0].To != "user@example.com" {
+		t.Fatalf("messages = %+v, want one to the account", messages)
+	}
+	token := emailedToken(t, mailer)
+
+	// A repeated request within the resend interval does not send again.
+	if _, err = s.DeleteAccount(&dto.DeleteAccountRequest{UserId: user.UserId}); err != nil {
+		t.Fatal(err)
+	}
+	if len(mailer.Messages()) != 1 {
+		t.Errorf("sent %d messages, want 1", len(mailer.Messages()))
+	}
+
+	response, err = s.DeleteAccount(&dto.DeleteAccountRequest{UserId: user.UserId, Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ScheduledFor.IsZero() || !repo.deletions[user.UserId].Equal(response.ScheduledFor) {
+		t.Errorf("scheduled for %v, repository has %v", response.ScheduledFor, repo.deletions[user.UserId])
+	}
+
+	if _, err = s.DeleteAccount(&dto.DeleteAccountRequest{UserId: user.UserId, Token: token}); !stderr.Is(err, errors.ErrInvalidToken) {
+		t.Errorf("reused token: err = %v, want %v", err, errors.ErrInvalidToken)
+	}
+}
+
+func TestDeleteAccountRejectsAnotherAccountsToken(t *testing.T) {
+	s, repo, mailer := newTestAccountService(t)
+	user := repo.auth.addUser("user@example.com", true)
+	other := repo.auth.addUser("other@example.com", true)
+
+	if _, err := s.DeleteAccount(&dto.DeleteAccountRequest{UserId: user.UserId}); err != nil {
+		t.Fatal(err)
+	}
+	token := emailedToken(t, mailer)
+
+	_, err := s.DeleteAccount(&dto.DeleteAccountRequest{UserId: other.UserId, Token: token})
+	if !stderr.Is(err, errors.ErrInvalidToken) {
+		t.Fatalf("err = %v, want %v", err, errors.ErrInvalidToken)
+	}
+	if len(repo.deletions) != 0 {
+		t.Errorf("deletions = %v, want none", repo.deletions)
+	}
+}
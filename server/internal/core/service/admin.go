@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type AdminUsersRepository interface {
+	GetUserById(userId uuid.UUID) (*entities.User, error)
+	SearchUsers(query string, role entities.Role, count, offset int64) ([]*entities.User, error)
+	BanUser(userId uuid.UUID, reason string) error
+	UnbanUser(userId uuid.UUID) error
+	SetUserRole(userId uuid.UUID, role entities.Role) error
+	RevokeAllSessions(userId uuid.UUID) error
+	ForceDeletePostById(postId uuid.UUID) (*entities.Post, error)
+	UpdatePostMessageCount(postId uuid.UUID, delta int) error
+}
+
+type AdminMessagesRepository interface {
+	ForceDeleteMessageById(ctx context.Context, messageId bson.ObjectID) (*entities.Message, error)
+	DeleteMessagesByPostId(ctx context.Context, postId uuid.UUID) error
+}
+
+type AdminService struct {
+	usersRepo    AdminUsersRepository
+	messagesRepo AdminMessagesRepository
+	clusters     *ClusterCache
+}
+
+func NewAdminService(usersRepo AdminUsersRepository, messagesRepo AdminMessagesRepository, clusters *ClusterCache) *AdminService {
+	return &AdminService{usersRepo: usersRepo, messagesRepo: messagesRepo, clusters: clusters}
+}
+
+func (s *AdminService) SearchUsers(rows *dto.SearchUsersRequest) (*dto.SearchUsersResponse, error) {
+	if rows.Role != "" && !rows.Role.IsValid() {
+		return nil, errors.ErrInvalidRole
+	}
+
+	users, err := s.usersRepo.SearchUsers(rows.Query, rows.Role, rows.Count, rows.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.SearchUsersResponse{
+		Users: make([]*dto.AdminUser, 0, len(users)),
+	}
+	for _, user := range users {
+		response.Users = append(response.Users, newAdminUser(user))
+	}
+
+	return &response, nil
+}
+
+func (s *AdminService) BanUser(rows *dto.BanUserRequest) (*dto.BanUserResponse, error) {
+	user, err := s.getManageableUser(rows.ActorId, rows.ActorRole, rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.usersRepo.BanUser(user.UserId, rows.Reason); err != nil {
+		return nil, err
+	}
+
+	if err = s.usersRepo.RevokeAllSessions(user.UserId); err != nil {
+		return nil, err
+	}
+
+	user, err = s.usersRepo.GetUserById(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.BanUserResponse{
+		User: newAdminUser(user),
+	}
+
+	return &response, nil
+}
+
+func (s *AdminService) UnbanUser(rows *dto.UnbanUserRequest) (*dto.UnbanUserResponse, error) {
+	user, err := s.getManageableUser(rows.ActorId, rows.ActorRole, rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.usersRepo.UnbanUser(user.UserId); err != nil {
+		return nil, err
+	}
+
+	user, err = s.usersRepo.GetUserById(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.UnbanUserResponse{
+		User: newAdminUser(user),
+	}
+
+	return &response, nil
+}
+
+func (s *AdminService) SetUserRole(rows *dto.SetUserRoleRequest) (*dto.SetUserRoleResponse, error) {
+	if !rows.Role.IsValid() {
+		return nil, errors.ErrInvalidRole
+	}
+
+	if rows.Role.Outranks(rows.ActorRole) {
+		return nil, errors.ErrNoPermissions
+	}
+
+	user, err := s.getManageableUser(rows.ActorId, rows.ActorRole, rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.usersRepo.SetUserRole(user.UserId, rows.Role); err != nil {
+		return nil, err
+	}
+
+	user.Role = rows.Role
+
+	response := dto.SetUserRoleResponse{
+		User: newAdminUser(user),
+	}
+
+	return &response, nil
+}
+
+func (s *AdminService) ForceDeletePost(ctx context.Context, rows *dto.ForceDeletePostRequest) (*dto.ForceDeletePostResponse, error) {
+	postId, err := uuid.Parse(rows.PostId)
+	if err != nil {
+		return nil, errors.ErrInvalidPostId
+	}
+
+	post, err := s.usersRepo.ForceDeletePostById(postId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.clusters.Invalidate(post.Latitude, post.Longitude)
+
+	if err = s.messagesRepo.DeleteMessagesByPostId(ctx, postId); err != nil {
+		return nil, err
+	}
+
+	response := dto.ForceDeletePostResponse{
+		PostId: rows.PostId,
+	}
+
+	return &response, nil
+}
+
+func (s *AdminService) ForceDeleteMessage(ctx context.Context, rows *dto.ForceDeleteMessageRequest) (*dto.ForceDeleteMessageResponse, error) {
+	objectId, err := bson.ObjectIDFromHex(rows.MessageId)
+	if err != nil {
+		return nil, errors.ErrInvalidMsgId
+	}
+
+	message, err := s.messagesRepo.ForceDeleteMessageById(ctx, objectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.usersRepo.UpdatePostMessageCount(message.PostId, -1); err != nil {
+		return nil, err
+	}
+
+	response := dto.ForceDeleteMessageResponse{
+		Success: true,
+	}
+
+	return &response, nil
+}
+
+func (s *AdminService) getManageableUser(actorId uuid.UUID, actorRole entities.Role, rawUserId string) (*entities.User, error) {
+	userId, err := uuid.Parse(rawUserId)
+	if err != nil {
+		return nil, errors.ErrInvalidUserId
+	}
+
+	if userId == actorId {
+		return nil, errors.ErrNoPermissions
+	}
+
+	user, err := s.usersRepo.GetUserById(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actorRole.Outranks(user.Role) {
+		return nil, errors.ErrNoPermissions
+	}
+
+	return user, nil
+}
+
+func newAdminUser(user *entities.User) *dto.AdminUser {
+	return &dto.AdminUser{
+		UserId:    user.UserId,
+		Email:     user.Email,
+		Verified:  user.Verified,
+		Role:      user.Role,
+		BannedAt:  user.BannedAt,
+		BanReason: user.BanReason,
+	}
+}
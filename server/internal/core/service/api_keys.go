@@ -0,0 +1,136 @@
+package service
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/apikey"
+	"github.com/skrpld/NearBeee/pkg/utils/hash"
+)
+
+const apiKeyNameMaxLength = 64
+
+func (s *AuthService) CreateApiKey(rows *dto.CreateApiKeyRequest) (*dto.CreateApiKeyResponse, error) {
+	name := strings.TrimSpace(rows.Name)
+	if name == "" || utf8.RuneCountInString(name) > apiKeyNameMaxLength {
+		return nil, errors.ErrInvalidApiKeyName
+	}
+
+	if len(rows.Scopes) == 0 {
+		return nil, errors.ErrInvalidScope
+	}
+	for _, scope := range rows.Scopes {
+		if !scope.IsValid() {
+			return nil, errors.ErrInvalidScope
+		}
+	}
+
+	if rows.ExpiresAt != nil && !rows.ExpiresAt.After(time.Now()) {
+		return nil, errors.ErrExpiredToken
+	}
+
+	key, id, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := &entities.ApiKey{
+		UserId:     rows.UserId,
+		Name:       name,
+		Prefix:     apikey.Prefix + id,
+		SecretHash: hash.HashToken(key),
+		Scopes:     rows.Scopes,
+		ExpiresAt:  rows.ExpiresAt,
+	}
+
+	if err = s.repo.CreateApiKey(apiKey); err != nil {
+		return nil, err
+	}
+
+	response := &dto.CreateApiKeyResponse{
+		ApiKey: apiKey,
+		Key:    key,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) GetApiKeys(rows *dto.GetApiKeysRequest) (*dto.GetApiKeysResponse, error) {
+	keys, err := s.repo.GetApiKeysByUserId(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.GetApiKeysResponse{
+		ApiKeys: keys,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) RevokeApiKey(rows *dto.RevokeApiKeyRequest) (*dto.RevokeApiKeyResponse, error) {
+	keyId, err := uuid.Parse(rows.KeyId)
+	if err != nil {
+		return nil, errors.ErrApiKeyNotFound
+	}
+
+	if err = s.repo.RevokeApiKey(keyId, rows.UserId); err != nil {
+		return nil, err
+	}
+
+	response := &dto.RevokeApiKeyResponse{
+		Success: true,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) AuthorizeApiKey(rows *dto.AuthorizeApiKeyRequest) (*dto.AuthorizeUserResponse, error) {
+	id, ok := apikey.Parse(rows.Key)
+	if !ok {
+		return nil, errors.ErrInvalidToken
+	}
+
+	apiKey, err := s.repo.GetApiKeyByPrefix(apikey.Prefix + id)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey.SecretHash), []byte(hash.HashToken(rows.Key))) != 1 {
+		return nil, errors.ErrInvalidToken
+	}
+
+	if apiKey.RevokedAt != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	if apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt) {
+		return nil, errors.ErrExpiredToken
+	}
+
+	user, err := s.repo.GetUserById(apiKey.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.BannedAt != nil {
+		return nil, errors.ErrUserBanned
+	}
+
+	if err = s.repo.TouchApiKey(apiKey.KeyId, s.cfg.ApiKeyTouchInterval); err != nil {
+		return nil, err
+	}
+
+	response := &dto.AuthorizeUserResponse{
+		User:   user,
+		ApiKey: apiKey,
+	}
+
+	return response, nil
+}
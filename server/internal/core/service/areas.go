@@ -0,0 +1,129 @@
+package service
+
+import (
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/geo"
+	"github.com/skrpld/NearBeee/pkg/geo/geojson"
+)
+
+const (
+	areaPostsCursor = "area_posts"
+
+	maxAreaNameLength = 200
+)
+
+var areaSlugRegex = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+type AreasRepository interface {
+	CreateArea(slug, name string, polygons geo.MultiPolygon) (*entities.Area, error)
+	GetAreaBySlug(slug string) (*entities.Area, error)
+	DeleteAreaBySlug(slug string) error
+	GetPostsByAreaId(areaId, viewerId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error)
+}
+
+type AreasService struct {
+	repo      AreasRepository
+	authors   *AuthorResolver
+	paginator *Paginator
+}
+
+func NewAreasService(repo AreasRepository, authors *AuthorResolver, paginator *Paginator) *AreasService {
+	return &AreasService{repo: repo, authors: authors, paginator: paginator}
+}
+
+func (s *AreasService) CreateArea(rows *dto.CreateAreaRequest) (*dto.CreateAreaResponse, error) {
+	fields := errors.FieldErrors{}
+	if len(rows.Slug) > 64 || !areaSlugRegex.MatchString(rows.Slug) {
+		fields["slug"] = "must be up to 64 lowercase letters, digits and single dashes"
+	}
+	if rows.Name == "" || len(rows.Name) > maxAreaNameLength {
+		fields["name"] = "must be between 1 and 200 characters"
+	}
+
+	polygons, err := geojson.DecodePolygons(rows.Geometry)
+	if err != nil {
+		fields["geometry"] = "must be a GeoJSON Polygon or MultiPolygon"
+	} else {
+		for field, message := range polygons.Validate() {
+			fields[field] = message
+		}
+	}
+
+	if err = fields.AsError(); err != nil {
+		return nil, err
+	}
+
+	area, err := s.repo.CreateArea(rows.Slug, rows.Name, polygons)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CreateAreaResponse{Area: area, Geometry: rows.Geometry}, nil
+}
+
+func (s *AreasService) GetArea(rows *dto.GetAreaRequest) (*dto.GetAreaResponse, error) {
+	area, err := s.repo.GetAreaBySlug(rows.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	geometry, err := geojson.EncodePolygons(area.Geometry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.GetAreaResponse{Area: area, Geometry: geometry}, nil
+}
+
+func (s *AreasService) DeleteArea(rows *dto.DeleteAreaRequest) (*dto.DeleteAreaResponse, error) {
+	if err := s.repo.DeleteAreaBySlug(rows.Slug); err != nil {
+		return nil, err
+	}
+
+	return &dto.DeleteAreaResponse{Slug: rows.Slug}, nil
+}
+
+// GetAreaPosts pages through the posts tagged with the area, newest first.
+// Tags follow the public location, so the author sees the same page as
+// everyone else even though their own posts come back with the exact point.
+func (s *AreasService) GetAreaPosts(rows *dto.GetAreaPostsRequest) (*dto.GetAreaPostsResponse, error) {
+	area, err := s.repo.GetAreaBySlug(rows.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := area.AreaId.String()
+	after, err := s.paginator.Decode(areaPostsCursor, scope, rows.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, next, err := s.repo.GetPostsByAreaId(area.AreaId, rows.ViewerId, after, s.paginator.Limit(rows.Count))
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.ExpandAuthor {
+		if err = s.authors.EmbedPostAuthors(posts...); err != nil {
+			return nil, err
+		}
+	}
+
+	nextCursor, err := s.paginator.Encode(areaPostsCursor, scope, next)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.GetAreaPostsResponse{
+		Posts:      posts,
+		NextCursor: nextCursor,
+		HasMore:    next != nil,
+	}
+
+	return &response, nil
+}
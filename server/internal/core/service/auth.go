@@ -1,137 +1,461 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	stderr "errors"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/skrpld/NearBeee/internal/core/logger"
 	"github.com/skrpld/NearBeee/internal/core/models/dto"
 	"github.com/skrpld/NearBeee/internal/core/models/entities"
 	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/crypt"
 	"github.com/skrpld/NearBeee/pkg/utils/hash"
 	"github.com/skrpld/NearBeee/pkg/utils/jwt"
 	"github.com/skrpld/NearBeee/pkg/utils/mail"
+	"github.com/skrpld/NearBeee/pkg/utils/oidc"
+	"github.com/skrpld/NearBeee/pkg/utils/signer"
+	"github.com/skrpld/NearBeee/pkg/utils/totp"
 
 	"github.com/google/uuid"
 )
 
+type AuthConfig struct {
+	EmailVerificationTTL  time.Duration `env:"EMAIL_VERIFICATION_TTL" env-default:"24h" mapstructure:"EMAIL_VERIFICATION_TTL"`
+	MailResendInterval    time.Duration `env:"MAIL_RESEND_INTERVAL" env-default:"1m" mapstructure:"MAIL_RESEND_INTERVAL"`
+	EmailVerificationUrl  string        `env:"EMAIL_VERIFICATION_URL" env-default:"nearbeee://verify-email?token={token}" mapstructure:"EMAIL_VERIFICATION_URL"`
+	PasswordResetTTL      time.Duration `env:"PASSWORD_RESET_TTL" env-default:"30m" mapstructure:"PASSWORD_RESET_TTL"`
+	PasswordResetUrl      string        `env:"PASSWORD_RESET_URL" env-default:"nearbeee://reset-password?token={token}" mapstructure:"PASSWORD_RESET_URL"`
+	PasswordMinLength     int           `env:"PASSWORD_MIN_LENGTH" env-default:"8" mapstructure:"PASSWORD_MIN_LENGTH"`
+	TOTPEncryptionKey     string        `env:"TOTP_ENCRYPTION_KEY" mapstructure:"TOTP_ENCRYPTION_KEY"`
+	TOTPIssuer            string        `env:"TOTP_ISSUER" env-default:"NearBeee" mapstructure:"TOTP_ISSUER"`
+	TwoFactorChallengeTTL time.Duration `env:"TWO_FACTOR_CHALLENGE_TTL" env-default:"5m" mapstructure:"TWO_FACTOR_CHALLENGE_TTL"`
+	LoginAttemptsStore    string        `env:"LOGIN_ATTEMPTS_STORE" env-default:"postgres" mapstructure:"LOGIN_ATTEMPTS_STORE"`
+	LoginAttemptsWindow   time.Duration `env:"LOGIN_ATTEMPTS_WINDOW" env-default:"1h" mapstructure:"LOGIN_ATTEMPTS_WINDOW"`
+	LoginAccountFailures  int           `env:"LOGIN_ACCOUNT_MAX_FAILURES" env-default:"5" mapstructure:"LOGIN_ACCOUNT_MAX_FAILURES"`
+	LoginIpFailures       int           `env:"LOGIN_IP_MAX_FAILURES" env-default:"20" mapstructure:"LOGIN_IP_MAX_FAILURES"`
+	LoginBackoffBase      time.Duration `env:"LOGIN_BACKOFF_BASE" env-default:"1s" mapstructure:"LOGIN_BACKOFF_BASE"`
+	LoginLockoutDuration  time.Duration `env:"LOGIN_LOCKOUT_DURATION" env-default:"15m" mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	ApiKeyTouchInterval   time.Duration `env:"API_KEY_TOUCH_INTERVAL" env-default:"1m" mapstructure:"API_KEY_TOUCH_INTERVAL"`
+	UnverifiedCanPost     bool          `env:"UNVERIFIED_CAN_POST" env-default:"false" mapstructure:"UNVERIFIED_CAN_POST"`
+	UnverifiedCanMessage  bool          `env:"UNVERIFIED_CAN_MESSAGE" env-default:"true" mapstructure:"UNVERIFIED_CAN_MESSAGE"`
+}
+
+const (
+	totpSkew           = 1
+	recoveryCodesCount = 10
+)
+
 type AuthRepository interface {
-	CreateUser(email, passwordHash, refreshToken string, refreshTokenExpiryTime time.Time) (*entities.User, error)
+	CreateUser(email, passwordHash string) (*entities.User, error)
 	GetUserByEmail(email string) (*entities.User, error)
-	UpdateRefreshTokenByUserId(userId uuid.UUID, refreshToken string, refreshTokenExpiryTime time.Time) error
 	GetUserById(userId uuid.UUID) (*entities.User, error)
+	GetUserByIdentity(provider, subject string) (*entities.User, error)
+	CreateUserIdentity(identity *entities.UserIdentity) error
+	SetUserVerified(userId uuid.UUID) error
+	UpdateUserPassword(userId uuid.UUID, passwordHash string) error
+	CreateUserToken(token *entities.UserToken) error
+	ConsumeUserToken(tokenId uuid.UUID, purpose string) (uuid.UUID, error)
+	InvalidateUserTokens(userId uuid.UUID, purpose string) error
+	GetLatestUserToken(userId uuid.UUID, purpose string) (*entities.UserToken, error)
+	CancelAccountDeletion(userId uuid.UUID) error
+	CreateApiKey(key *entities.ApiKey) error
+	GetApiKeyByPrefix(prefix string) (*entities.ApiKey, error)
+	GetApiKeysByUserId(userId uuid.UUID) ([]*entities.ApiKey, error)
+	RevokeApiKey(keyId, userId uuid.UUID) error
+	TouchApiKey(keyId uuid.UUID, interval time.Duration) error
+	SaveUserTOTP(userId uuid.UUID, secretEncrypted string) error
+	GetUserTOTP(userId uuid.UUID) (*entities.UserTOTP, error)
+	ConfirmUserTOTP(userId uuid.UUID, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(userId uuid.UUID, step int64) error
+	UseRecoveryCode(userId uuid.UUID, codeHash string) error
+	DeleteUserTOTP(userId uuid.UUID) error
+	CreateSession(session *entities.Session, token *entities.RefreshToken) error
+	GetSessionById(sessionId uuid.UUID) (*entities.Session, error)
+	GetActiveSessionsByUserId(userId uuid.UUID) ([]*entities.Session, error)
+	RevokeSession(sessionId, userId uuid.UUID) error
+	RevokeAllSessions(userId uuid.UUID) error
+	GetRefreshTokenById(tokenId uuid.UUID) (*entities.RefreshToken, error)
+	RotateRefreshToken(oldTokenId uuid.UUID, newToken *entities.RefreshToken) error
+}
+
+type LoginAttemptStore interface {
+	GetLoginAttempt(key string) (*entities.LoginAttempt, error)
+	RecordLoginFailure(key string, resetAfter time.Duration) (*entities.LoginAttempt, error)
+	LockLogin(key string, until time.Time) error
+	ResetLoginAttempts(key string) error
 }
 
 type AuthService struct {
-	repo   AuthRepository
-	secret string
+	repo        AuthRepository
+	attempts    LoginAttemptStore
+	mailer      mail.Mailer
+	validator   *mail.Validator
+	cipher      *crypt.Cipher
+	dummyHash   string
+	providers   map[string]*oidc.Client
+	stateSigner *signer.Signer
+	cfg         AuthConfig
+	oidcCfg     oidc.OIDCConfig
+	logger      logger.Logger
 }
 
-func NewAuthService(repo AuthRepository, secret string) *AuthService {
-	return &AuthService{repo, secret}
+func NewAuthService(repo AuthRepository, attempts LoginAttemptStore, mailer mail.Mailer, validator *mail.Validator, secret string, cfg AuthConfig, oidcCfg oidc.OIDCConfig, logger logger.Logger) (*AuthService, error) {
+	var cipher *crypt.Cipher
+	if cfg.TOTPEncryptionKey != "" {
+		var err error
+		if cipher, err = crypt.NewCipher(cfg.TOTPEncryptionKey); err != nil {
+			return nil, err
+		}
+	}
+
+	dummyHash, err := hash.HashString(uuid.NewString())
+	if err != nil {
+		return nil, err
+	}
+
+	stateSigner := signer.New(secret, oidcStatePurpose)
+
+	return &AuthService{repo, attempts, mailer, validator, cipher, dummyHash, newOIDCClients(oidcCfg), stateSigner, cfg, oidcCfg, logger}, nil
 }
 
 func (s *AuthService) RegistrateUser(rows *dto.RegistrateUserRequest) (*dto.RegistrateUserResponse, error) {
-	if !mail.IsEmailValid(rows.Email) {
-		return nil, errors.ErrInvalidEmail
+	email, err := s.validator.Validate(context.Background(), rows.Email)
+	if err != nil {
+		return nil, err
 	}
 
 	hashPassword, err := hash.HashString(rows.Password)
 	if err != nil {
 		return nil, err
 	}
-	refreshToken, refreshTokenExpiryDuration, err := jwt.NewRefreshToken(rows.Email, s.secret)
+
+	newUser, err := s.repo.CreateUser(email, hashPassword)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshTokenExpiryTime := time.Now().Add(refreshTokenExpiryDuration)
+	session := &entities.Session{
+		DeviceName: rows.DeviceName,
+		UserAgent:  rows.UserAgent,
+		IpAddress:  rows.IpAddress,
+	}
 
-	newUser, err := s.repo.CreateUser(rows.Email, hashPassword, refreshToken, refreshTokenExpiryTime)
+	refreshToken, accessToken, err := s.startSession(newUser.UserId, session)
 	if err != nil {
 		return nil, err
 	}
 
-	accessToken, err := jwt.NewAccessToken(newUser.UserId.String(), s.secret)
-	if err != nil {
-		return nil, err
+	// The account exists either way; the client offers a resend when the
+	// email did not go out.
+	verificationSent := true
+	if err = s.sendVerificationEmail(newUser); err != nil {
+		s.logger.Error("verification email not sent", logger.String("user_id", newUser.UserId.String()), logger.Error(err))
+		verificationSent = false
 	}
 
 	response := &dto.RegistrateUserResponse{
-		UserId:       newUser.UserId.String(),
-		RefreshToken: refreshToken,
-		AccessToken:  accessToken,
+		UserId:           newUser.UserId.String(),
+		SessionId:        session.SessionId.String(),
+		VerificationSent: verificationSent,
+		RefreshToken:     refreshToken,
+		AccessToken:      accessToken,
 	}
 
 	return response, nil
 }
 
 func (s *AuthService) LoginUser(rows *dto.LoginUserRequest) (*dto.LoginUserResponse, error) {
-	if !mail.IsEmailValid(rows.Email) {
-		return nil, errors.ErrInvalidEmail
+	email, err := mail.Normalize(rows.Email)
+	if err != nil {
+		return nil, errors.ErrInvalidCredentials
 	}
 
-	user, err := s.repo.GetUserByEmail(rows.Email)
+	accountKey, ipKey := accountAttemptKey(email), ipAttemptKey(rows.IpAddress)
+
+	if err = s.checkLoginAllowed(accountKey, ipKey); err != nil {
+		return nil, err
+	}
+
+	passwordHash := s.dummyHash
+
+	user, err := s.repo.GetUserByEmail(email)
+	if err == nil && user.HasPassword() {
+		passwordHash = user.PasswordHash
+	} else if err != nil && !stderr.Is(err, errors.ErrInvalidEmail) {
+		return nil, err
+	}
+
+	if hash.CompareHashAndPassword(passwordHash, rows.Password) != nil || user == nil {
+		if err = s.registerLoginFailure(accountKey, s.cfg.LoginAccountFailures); err != nil {
+			return nil, err
+		}
+		if err = s.registerLoginFailure(ipKey, s.cfg.LoginIpFailures); err != nil {
+			return nil, err
+		}
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	// A correct password clears the address as well, so users behind a shared
+	// address are not locked out by failures that someone else piled up.
+	if err = s.resetLoginAttempts(accountKey, ipKey); err != nil {
+		return nil, err
+	}
+
+	if user.BannedAt != nil {
+		return nil, errors.ErrUserBanned
+	}
+
+	session := &entities.Session{
+		DeviceName: rows.DeviceName,
+		UserAgent:  rows.UserAgent,
+		IpAddress:  rows.IpAddress,
+	}
+
+	return s.completeLogin(user, session)
+}
+
+func (s *AuthService) VerifyTwoFactor(rows *dto.VerifyTwoFactorRequest) (*dto.LoginUserResponse, error) {
+	tokenClaims, err := jwt.ValidateToken(rows.ChallengeToken, jwt.TwoFactorChallengeTokenType)
 	if err != nil {
-		return nil, errors.ErrInvalidEmail
+		return nil, err
 	}
 
-	err = hash.CompareHashAndPassword(user.PasswordHash, rows.Password)
+	sub, err := tokenClaims.GetSubject()
 	if err != nil {
-		return nil, errors.ErrInvalidPassword
+		return nil, errors.ErrInvalidToken
+	}
+
+	userId, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
 	}
 
-	refreshToken, refreshTokenExpiryDuration, err := jwt.NewRefreshToken(rows.Email, s.secret)
+	jti, err := jwt.GetStringClaim(tokenClaims, "jti")
 	if err != nil {
 		return nil, err
 	}
-	refreshTokenExpiryTime := time.Now().Add(refreshTokenExpiryDuration)
 
-	err = s.repo.UpdateRefreshTokenByUserId(user.UserId, refreshToken, refreshTokenExpiryTime)
+	tokenId, err := uuid.Parse(jti)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	user, err := s.repo.GetUserById(userId)
 	if err != nil {
 		return nil, err
 	}
 
-	accessToken, err := jwt.NewAccessToken(user.UserId.String(), s.secret)
+	if user.BannedAt != nil {
+		return nil, errors.ErrUserBanned
+	}
+
+	accountKey, ipKey := accountAttemptKey(user.Email), ipAttemptKey(rows.IpAddress)
+
+	if err = s.checkLoginAllowed(accountKey, ipKey); err != nil {
+		return nil, err
+	}
+
+	if err = s.checkSecondFactor(user.UserId, rows.Code, rows.RecoveryCode); err != nil {
+		if stderr.Is(err, errors.ErrInvalidTwoFactorCode) {
+			if err := s.registerLoginFailure(accountKey, s.cfg.LoginAccountFailures); err != nil {
+				return nil, err
+			}
+			if err := s.registerLoginFailure(ipKey, s.cfg.LoginIpFailures); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	// The challenge survives mistyped codes and is spent by the first correct
+	// one, so a leaked challenge token cannot start a second session.
+	challengeUserId, err := s.repo.ConsumeUserToken(tokenId, entities.TwoFactorChallengePurpose)
 	if err != nil {
 		return nil, err
 	}
+	if challengeUserId != user.UserId {
+		return nil, errors.ErrInvalidToken
+	}
 
-	response := &dto.LoginUserResponse{
-		UserId:       user.UserId.String(),
-		RefreshToken: refreshToken,
-		AccessToken:  accessToken,
+	if err = s.resetLoginAttempts(accountKey, ipKey); err != nil {
+		return nil, err
+	}
+
+	session := &entities.Session{
+		DeviceName: rows.DeviceName,
+		UserAgent:  rows.UserAgent,
+		IpAddress:  rows.IpAddress,
+	}
+
+	return s.loginSession(user, session)
+}
+
+func (s *AuthService) SetupTwoFactor(rows *dto.SetupTwoFactorRequest) (*dto.SetupTwoFactorResponse, error) {
+	if s.cipher == nil {
+		return nil, errors.ErrTwoFactorUnavailable
+	}
+
+	user, err := s.repo.GetUserById(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	secretEncrypted, err := s.cipher.Encrypt([]byte(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.SaveUserTOTP(user.UserId, secretEncrypted); err != nil {
+		return nil, err
+	}
+
+	response := &dto.SetupTwoFactorResponse{
+		Secret:     secret,
+		OtpauthUri: totp.URI(s.cfg.TOTPIssuer, user.Email, secret),
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) ConfirmTwoFactor(rows *dto.ConfirmTwoFactorRequest) (*dto.ConfirmTwoFactorResponse, error) {
+	userTOTP, err := s.repo.GetUserTOTP(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if userTOTP == nil {
+		return nil, errors.ErrTwoFactorNotEnabled
+	}
+	if userTOTP.ConfirmedAt != nil {
+		return nil, errors.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := s.decryptTOTPSecret(userTOTP)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := totp.Validate(secret, rows.Code, time.Now(), totpSkew)
+	if !ok {
+		return nil, errors.ErrInvalidTwoFactorCode
+	}
+
+	recoveryCodes := make([]string, 0, recoveryCodesCount)
+	recoveryCodeHashes := make([]string, 0, recoveryCodesCount)
+	for range recoveryCodesCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		recoveryCodes = append(recoveryCodes, code)
+		recoveryCodeHashes = append(recoveryCodeHashes, hash.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err = s.repo.ConfirmUserTOTP(rows.UserId, step, recoveryCodeHashes); err != nil {
+		return nil, err
+	}
+
+	response := &dto.ConfirmTwoFactorResponse{
+		RecoveryCodes: recoveryCodes,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) DisableTwoFactor(rows *dto.DisableTwoFactorRequest) (*dto.DisableTwoFactorResponse, error) {
+	if err := s.checkSecondFactor(rows.UserId, rows.Code, rows.RecoveryCode); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.DeleteUserTOTP(rows.UserId); err != nil {
+		return nil, err
+	}
+
+	response := &dto.DisableTwoFactorResponse{
+		Success: true,
 	}
 
 	return response, nil
 }
 
 func (s *AuthService) RefreshUserToken(rows *dto.RefreshUserTokenRequest) (*dto.RefreshUserTokenResponse, error) {
-	tokenClaims, err := jwt.ValidateToken(rows.RefreshToken, s.secret)
+	tokenClaims, err := jwt.ValidateToken(rows.RefreshToken, jwt.RefreshTokenType)
 	if err != nil {
 		return nil, err
 	}
 
-	email, err := tokenClaims.GetSubject()
+	jti, err := jwt.GetStringClaim(tokenClaims, "jti")
 	if err != nil {
-		return nil, errors.ErrInvalidToken
+		return nil, err
 	}
 
-	user, err := s.repo.GetUserByEmail(email)
+	tokenId, err := uuid.Parse(jti)
 	if err != nil {
 		return nil, errors.ErrInvalidToken
 	}
 
-	if rows.RefreshToken != user.RefreshToken {
+	storedToken, err := s.repo.GetRefreshTokenById(tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	if storedToken.TokenHash != hash.HashToken(rows.RefreshToken) {
 		return nil, errors.ErrInvalidToken
 	}
 
-	accessToken, err := jwt.NewAccessToken(user.UserId.String(), s.secret)
+	if storedToken.UsedAt != nil {
+		return nil, s.revokeReusedFamily(storedToken)
+	}
+
+	if storedToken.RevokedAt != nil {
+		return nil, errors.ErrSessionRevoked
+	}
+
+	if time.Now().After(storedToken.ExpiresAt) {
+		return nil, errors.ErrExpiredToken
+	}
+
+	user, err := s.repo.GetUserById(storedToken.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.BannedAt != nil {
+		return nil, errors.ErrUserBanned
+	}
+
+	refreshToken, newToken, err := s.newRefreshToken(storedToken.UserId, storedToken.FamilyId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.RotateRefreshToken(storedToken.TokenId, newToken)
+	if err != nil {
+		if stderr.Is(err, errors.ErrRefreshTokenReused) {
+			return nil, s.revokeReusedFamily(storedToken)
+		}
+		return nil, err
+	}
+
+	accessToken, err := jwt.NewAccessToken(storedToken.UserId.String(), storedToken.FamilyId.String())
 	if err != nil {
 		return nil, err
 	}
 
 	response := &dto.RefreshUserTokenResponse{
-		UserId:       user.UserId.String(),
-		RefreshToken: accessToken,
+		UserId:       storedToken.UserId.String(),
+		RefreshToken: refreshToken,
 		AccessToken:  accessToken,
 	}
 
@@ -139,7 +463,7 @@ func (s *AuthService) RefreshUserToken(rows *dto.RefreshUserTokenRequest) (*dto.
 }
 
 func (s *AuthService) AuthorizeUser(rows *dto.AuthorizeUserRequest) (*dto.AuthorizeUserResponse, error) {
-	tokenClaims, err := jwt.ValidateToken(rows.AccessToken, s.secret)
+	tokenClaims, err := jwt.ValidateToken(rows.AccessToken, jwt.AccessTokenType)
 	if err != nil {
 		return nil, err
 	}
@@ -154,14 +478,572 @@ func (s *AuthService) AuthorizeUser(rows *dto.AuthorizeUserRequest) (*dto.Author
 		return nil, err
 	}
 
+	sid, err := jwt.GetStringClaim(tokenClaims, "sid")
+	if err != nil {
+		return nil, err
+	}
+
+	sessionId, err := uuid.Parse(sid)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	session, err := s.repo.GetSessionById(sessionId)
+	if err != nil {
+		if stderr.Is(err, errors.ErrSessionNotFound) {
+			return nil, errors.ErrSessionRevoked
+		}
+		return nil, err
+	}
+
+	if session.RevokedAt != nil || session.UserId != userId {
+		return nil, errors.ErrSessionRevoked
+	}
+
 	user, err := s.repo.GetUserById(userId)
 	if err != nil {
 		return nil, err
 	}
 
+	if user.BannedAt != nil {
+		return nil, errors.ErrUserBanned
+	}
+
 	response := &dto.AuthorizeUserResponse{
-		User: user,
+		User:    user,
+		Session: session,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) VerifyEmail(rows *dto.VerifyEmailRequest) (*dto.VerifyEmailResponse, error) {
+	tokenClaims, err := jwt.ValidateToken(rows.Token, jwt.EmailVerificationTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	jti, err := jwt.GetStringClaim(tokenClaims, "jti")
+	if err != nil {
+		return nil, err
+	}
+
+	tokenId, err := uuid.Parse(jti)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	userId, err := s.repo.ConsumeUserToken(tokenId, entities.EmailVerificationPurpose)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.SetUserVerified(userId); err != nil {
+		return nil, err
+	}
+
+	response := &dto.VerifyEmailResponse{
+		Success: true,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) ResendVerification(rows *dto.ResendVerificationRequest) (*dto.ResendVerificationResponse, error) {
+	user, err := s.repo.GetUserById(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Verified {
+		return nil, errors.ErrEmailAlreadyVerified
+	}
+
+	lastToken, err := s.repo.GetLatestUserToken(user.UserId, entities.EmailVerificationPurpose)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastToken != nil && time.Since(lastToken.CreatedAt) < s.cfg.MailResendInterval {
+		return nil, errors.ErrTooManyRequests
+	}
+
+	if err = s.sendVerificationEmail(user); err != nil {
+		return nil, err
+	}
+
+	response := &dto.ResendVerificationResponse{
+		Success: true,
 	}
 
 	return response, nil
 }
+
+func (s *AuthService) ForgotPassword(rows *dto.ForgotPasswordRequest) (*dto.ForgotPasswordResponse, error) {
+	response := &dto.ForgotPasswordResponse{
+		Success: true,
+	}
+
+	email, err := mail.Normalize(rows.Email)
+	if err != nil {
+		return nil, errors.ErrInvalidEmail
+	}
+
+	user, err := s.repo.GetUserByEmail(email)
+	if err != nil {
+		if stderr.Is(err, errors.ErrInvalidEmail) {
+			return response, nil
+		}
+		return nil, err
+	}
+
+	lastToken, err := s.repo.GetLatestUserToken(user.UserId, entities.PasswordResetPurpose)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastToken != nil && time.Since(lastToken.CreatedAt) < s.cfg.MailResendInterval {
+		return response, nil
+	}
+
+	if err = s.sendPasswordResetEmail(user); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) ResetPassword(rows *dto.ResetPasswordRequest) (*dto.ResetPasswordResponse, error) {
+	if err := s.validatePassword(rows.NewPassword); err != nil {
+		return nil, err
+	}
+
+	tokenClaims, err := jwt.ValidateToken(rows.Token, jwt.PasswordResetTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	jti, err := jwt.GetStringClaim(tokenClaims, "jti")
+	if err != nil {
+		return nil, err
+	}
+
+	tokenId, err := uuid.Parse(jti)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	userId, err := s.repo.ConsumeUserToken(tokenId, entities.PasswordResetPurpose)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.updatePassword(userId, rows.NewPassword); err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.InvalidateUserTokens(userId, entities.PasswordResetPurpose); err != nil {
+		return nil, err
+	}
+
+	response := &dto.ResetPasswordResponse{
+		Success: true,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) ChangePassword(rows *dto.ChangePasswordRequest) (*dto.ChangePasswordResponse, error) {
+	user, err := s.repo.GetUserById(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPassword() {
+		return nil, errors.ErrPasswordNotSet
+	}
+
+	err = hash.CompareHashAndPassword(user.PasswordHash, rows.CurrentPassword)
+	if err != nil {
+		return nil, errors.ErrInvalidPassword
+	}
+
+	if err = s.validatePassword(rows.NewPassword); err != nil {
+		return nil, err
+	}
+
+	if err = s.updatePassword(user.UserId, rows.NewPassword); err != nil {
+		return nil, err
+	}
+
+	session := &entities.Session{
+		DeviceName: rows.Session.DeviceName,
+		UserAgent:  rows.Session.UserAgent,
+		IpAddress:  rows.Session.IpAddress,
+	}
+
+	refreshToken, accessToken, err := s.startSession(user.UserId, session)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.ChangePasswordResponse{
+		SessionId:    session.SessionId.String(),
+		RefreshToken: refreshToken,
+		AccessToken:  accessToken,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) GetSessions(rows *dto.GetSessionsRequest) (*dto.GetSessionsResponse, error) {
+	sessions, err := s.repo.GetActiveSessionsByUserId(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.GetSessionsResponse{
+		CurrentSessionId: rows.SessionId.String(),
+		Sessions:         sessions,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) RevokeSession(rows *dto.RevokeSessionRequest) (*dto.RevokeSessionResponse, error) {
+	sessionId, err := uuid.Parse(rows.SessionId)
+	if err != nil {
+		return nil, errors.ErrSessionNotFound
+	}
+
+	if err = s.repo.RevokeSession(sessionId, rows.UserId); err != nil {
+		return nil, err
+	}
+
+	response := &dto.RevokeSessionResponse{
+		Success: true,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) LogoutUser(rows *dto.LogoutUserRequest) (*dto.LogoutUserResponse, error) {
+	if err := s.repo.RevokeSession(rows.SessionId, rows.UserId); err != nil {
+		return nil, err
+	}
+
+	response := &dto.LogoutUserResponse{
+		Success: true,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) LogoutAll(rows *dto.LogoutAllRequest) (*dto.LogoutAllResponse, error) {
+	if err := s.repo.RevokeAllSessions(rows.UserId); err != nil {
+		return nil, err
+	}
+
+	response := &dto.LogoutAllResponse{
+		Success: true,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) completeLogin(user *entities.User, session *entities.Session) (*dto.LoginUserResponse, error) {
+	userTOTP, err := s.repo.GetUserTOTP(user.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if userTOTP != nil && userTOTP.ConfirmedAt != nil {
+		token := &entities.UserToken{
+			TokenId:   uuid.New(),
+			UserId:    user.UserId,
+			Purpose:   entities.TwoFactorChallengePurpose,
+			ExpiresAt: time.Now().Add(s.cfg.TwoFactorChallengeTTL),
+		}
+
+		challengeToken, err := jwt.NewOneTimeToken(user.UserId.String(), token.TokenId.String(),
+			jwt.TwoFactorChallengeTokenType, s.cfg.TwoFactorChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = s.repo.CreateUserToken(token); err != nil {
+			return nil, err
+		}
+
+		response := &dto.LoginUserResponse{
+			UserId:            user.UserId.String(),
+			Verified:          user.Verified,
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		}
+
+		return response, nil
+	}
+
+	return s.loginSession(user, session)
+}
+
+func (s *AuthService) loginSession(user *entities.User, session *entities.Session) (*dto.LoginUserResponse, error) {
+	refreshToken, accessToken, err := s.startSession(user.UserId, session)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.LoginUserResponse{
+		UserId:       user.UserId.String(),
+		SessionId:    session.SessionId.String(),
+		Verified:     user.Verified,
+		RefreshToken: refreshToken,
+		AccessToken:  accessToken,
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) startSession(userId uuid.UUID, session *entities.Session) (string, string, error) {
+	if err := s.repo.CancelAccountDeletion(userId); err != nil {
+		return "", "", err
+	}
+
+	session.SessionId = uuid.New()
+	session.UserId = userId
+
+	refreshToken, token, err := s.newRefreshToken(userId, session.SessionId)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err = s.repo.CreateSession(session, token); err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := jwt.NewAccessToken(userId.String(), session.SessionId.String())
+	if err != nil {
+		return "", "", err
+	}
+
+	return refreshToken, accessToken, nil
+}
+
+func (s *AuthService) checkLoginAllowed(keys ...string) error {
+	for _, key := range keys {
+		attempt, err := s.attempts.GetLoginAttempt(key)
+		if err != nil {
+			return err
+		}
+
+		if attempt != nil && attempt.LockedUntil != nil && time.Now().Before(*attempt.LockedUntil) {
+			return errors.ErrTooManyAttempts
+		}
+	}
+
+	return nil
+}
+
+func (s *AuthService) registerLoginFailure(key string, maxFailures int) error {
+	attempt, err := s.attempts.RecordLoginFailure(key, s.cfg.LoginAttemptsWindow)
+	if err != nil {
+		return err
+	}
+
+	if attempt.Failures < maxFailures {
+		return nil
+	}
+
+	delay := s.cfg.LoginLockoutDuration
+	if exp := attempt.Failures - maxFailures; exp < 32 {
+		delay = min(s.cfg.LoginBackoffBase<<exp, s.cfg.LoginLockoutDuration)
+	}
+
+	return s.attempts.LockLogin(key, time.Now().Add(delay))
+}
+
+func (s *AuthService) resetLoginAttempts(keys ...string) error {
+	for _, key := range keys {
+		if err := s.attempts.ResetLoginAttempts(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func accountAttemptKey(email string) string {
+	return "account:" + email
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+func (s *AuthService) checkSecondFactor(userId uuid.UUID, code, recoveryCode string) error {
+	userTOTP, err := s.repo.GetUserTOTP(userId)
+	if err != nil {
+		return err
+	}
+
+	if userTOTP == nil || userTOTP.ConfirmedAt == nil {
+		return errors.ErrTwoFactorNotEnabled
+	}
+
+	if code != "" {
+		secret, err := s.decryptTOTPSecret(userTOTP)
+		if err != nil {
+			return err
+		}
+
+		step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+		if !ok {
+			return errors.ErrInvalidTwoFactorCode
+		}
+
+		return s.repo.UseTOTPStep(userId, step)
+	}
+
+	if recoveryCode != "" {
+		return s.repo.UseRecoveryCode(userId, hash.HashToken(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	return errors.ErrInvalidTwoFactorCode
+}
+
+func (s *AuthService) decryptTOTPSecret(userTOTP *entities.UserTOTP) (string, error) {
+	if s.cipher == nil {
+		return "", errors.ErrTwoFactorUnavailable
+	}
+
+	secret, err := s.cipher.Decrypt(userTOTP.SecretEncrypted)
+	if err != nil {
+		return "", err
+	}
+
+	return string(secret), nil
+}
+
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, 6)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw))
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+func (s *AuthService) sendVerificationEmail(user *entities.User) error {
+	token := &entities.UserToken{
+		TokenId:   uuid.New(),
+		UserId:    user.UserId,
+		Purpose:   entities.EmailVerificationPurpose,
+		ExpiresAt: time.Now().Add(s.cfg.EmailVerificationTTL),
+	}
+
+	verificationToken, err := jwt.NewOneTimeToken(user.UserId.String(), token.TokenId.String(),
+		jwt.EmailVerificationTokenType, s.cfg.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	if err = s.repo.CreateUserToken(token); err != nil {
+		return err
+	}
+
+	link := strings.ReplaceAll(s.cfg.EmailVerificationUrl, "{token}", verificationToken)
+
+	return s.mailer.Send(&mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your NearBeee email",
+		Body: "Welcome to NearBeee!\n\n" +
+			"Open the link below to confirm your email address:\n" + link + "\n\n" +
+			"Or paste this code into the app:\n" + verificationToken + "\n\n" +
+			"The link expires in " + s.cfg.EmailVerificationTTL.String() + ".\n",
+	})
+}
+
+func (s *AuthService) sendPasswordResetEmail(user *entities.User) error {
+	token := &entities.UserToken{
+		TokenId:   uuid.New(),
+		UserId:    user.UserId,
+		Purpose:   entities.PasswordResetPurpose,
+		ExpiresAt: time.Now().Add(s.cfg.PasswordResetTTL),
+	}
+
+	resetToken, err := jwt.NewOneTimeToken(user.UserId.String(), token.TokenId.String(),
+		jwt.PasswordResetTokenType, s.cfg.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	if err = s.repo.CreateUserToken(token); err != nil {
+		return err
+	}
+
+	link := strings.ReplaceAll(s.cfg.PasswordResetUrl, "{token}", resetToken)
+
+	return s.mailer.Send(&mail.Message{
+		To:      user.Email,
+		Subject: "Reset your NearBeee password",
+		Body: "Someone asked to reset the password for your NearBeee account.\n\n" +
+			"Open the link below to choose a new password:\n" + link + "\n\n" +
+			"The link expires in " + s.cfg.PasswordResetTTL.String() + " and can be used once.\n" +
+			"If it was not you, just ignore this email.\n",
+	})
+}
+
+func (s *AuthService) validatePassword(password string) error {
+	if utf8.RuneCountInString(password) < s.cfg.PasswordMinLength {
+		return errors.ErrWeakPassword
+	}
+	return nil
+}
+
+func (s *AuthService) updatePassword(userId uuid.UUID, password string) error {
+	hashPassword, err := hash.HashString(password)
+	if err != nil {
+		return err
+	}
+
+	if err = s.repo.UpdateUserPassword(userId, hashPassword); err != nil {
+		return err
+	}
+
+	return s.repo.RevokeAllSessions(userId)
+}
+
+func (s *AuthService) newRefreshToken(userId, familyId uuid.UUID) (string, *entities.RefreshToken, error) {
+	tokenId := uuid.New()
+
+	refreshToken, refreshTokenExpiryDuration, err := jwt.NewRefreshToken(userId.String(), tokenId.String(), familyId.String())
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := &entities.RefreshToken{
+		TokenId:   tokenId,
+		FamilyId:  familyId,
+		UserId:    userId,
+		TokenHash: hash.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTokenExpiryDuration),
+	}
+
+	return refreshToken, token, nil
+}
+
+func (s *AuthService) revokeReusedFamily(token *entities.RefreshToken) error {
+	err := s.repo.RevokeSession(token.FamilyId, token.UserId)
+	if err != nil && !stderr.Is(err, errors.ErrSessionNotFound) {
+		return err
+	}
+	return errors.ErrRefreshTokenReused
+}
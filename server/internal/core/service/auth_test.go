@@ -0,0 +1,435 @@
+package service
+
+import (
+	"encoding/base64"
+	stderr "errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skrpld/NearBeee/internal/core/logger"
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/internal/core/repository"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/hash"
+	"github.com/skrpld/NearBeee/pkg/utils/jwt"
+	"github.com/skrpld/NearBeee/pkg/utils/mail"
+	"github.com/skrpld/NearBeee/pkg/utils/oidc"
+	"github.com/skrpld/NearBeee/pkg/utils/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testSecret = "test-secret"
+
+// newTestAuthService returns a service with TOTP enabled over an in-memory
+// repository.
+func newTestAuthService(t *testing.T, cfg AuthConfig) (*AuthService, *memoryAuthRepo) {
+	t.Helper()
+
+	if err := jwt.UpdateJWTConfig(&jwt.JWTConfig{
+		AccessTokenExpiryTime:  time.Hour,
+		RefreshTokenExpiryTime: time.Hour,
+		IssuedAt:               "nearbeee-test",
+		Algorithm:              "HS512",
+		AcceptHMAC:             true,
+	}, testSecret); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.TOTPEncryptionKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	cfg.TwoFactorChallengeTTL = time.Minute
+	cfg.LoginAttemptsWindow = time.Hour
+	cfg.LoginBackoffBase = time.Minute
+	cfg.LoginLockoutDuration = time.Hour
+
+	repo := newMemoryAuthRepo()
+	attempts := repository.NewLoginAttemptsMemoryRepository(time.Hour, 100)
+
+	s, err := NewAuthService(repo, attempts, nil, nil, testSecret, cfg, oidc.OIDCConfig{}, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, repo
+}
+
+// addTwoFactorUser adds a user with confirmed two-factor authentication and
+// returns it along with its TOTP secret.
+func addTwoFactorUser(t *testing.T, s *AuthService, repo *memoryAuthRepo, email string) (*entities.User, string) {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretEncrypted, err := s.cipher.Encrypt([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := repo.addUser(email, true)
+	confirmedAt := time.Now()
+	repo.totps[user.UserId] = &entities.UserTOTP{UserId: user.UserId, SecretEncrypted: secretEncrypted, ConfirmedAt: &confirmedAt}
+
+	return user, secret
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestVerifyTwoFactorConsumesChallenge(t *testing.T) {
+	s, repo := newTestAuthService(t, AuthConfig{LoginAccountFailures: 5, LoginIpFailures: 20})
+	user, secret := addTwoFactorUser(t, s, repo, "user@example.com")
+
+	challenge, err := s.completeLogin(user, &entities.Session{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !challenge.TwoFactorRequired {
+		t.Fatal("expected a two-factor challenge")
+	}
+
+	_, err = s.VerifyTwoFactor(&dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: "000000", IpAddress: "192.0.2.1"})
+	if !stderr.Is(err, errors.ErrInvalidTwoFactorCode) {
+		t.Fatalf("wrong code: err = %v, want %v", err, errors.ErrInvalidTwoFactorCode)
+	}
+
+	login, err := s.VerifyTwoFactor(&dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: currentCode(t, secret), IpAddress: "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("a mistyped code should leave the challenge usable: %v", err)
+	}
+	if login.AccessToken == "" || len(repo.sessions) != 1 {
+		t.Fatalf("expected one session, got %d", len(repo.sessions))
+	}
+
+	// Reusing the challenge fails even with a code the replay could know.
+	repo.totps[user.UserId].LastUsedStep = 0
+	_, err = s.VerifyTwoFactor(&dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: currentCode(t, secret), IpAddress: "192.0.2.1"})
+	if !stderr.Is(err, errors.ErrInvalidToken) {
+		t.Errorf("replayed challenge: err = %v, want %v", err, errors.ErrInvalidToken)
+	}
+	if len(repo.sessions) != 1 {
+		t.Errorf("replayed challenge started a session")
+	}
+}
+
+func TestVerifyTwoFactorLimitsFailuresPerIp(t *testing.T) {
+	s, repo := newTestAuthService(t, AuthConfig{LoginAccountFailures: 100, LoginIpFailures: 3})
+
+	// Failures spread over several accounts still lock out the address.
+	for i := range 3 {
+		user, _ := addTwoFactorUser(t, s, repo, fmt.Sprintf("user%d@example.com", i))
+
+		challenge, err := s.completeLogin(user, &entities.Session{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = s.VerifyTwoFactor(&dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: "000000", IpAddress: "192.0.2.1"})
+		if !stderr.Is(err, errors.ErrInvalidTwoFactorCode) {
+			t.Fatalf("attempt %d: err = %v, want %v", i, err, errors.ErrInvalidTwoFactorCode)
+		}
+	}
+
+	user, _ := addTwoFactorUser(t, s, repo, "next@example.com")
+
+	challenge, err := s.completeLogin(user, &entities.Session{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.VerifyTwoFactor(&dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: "000000", IpAddress: "192.0.2.1"})
+	if !stderr.Is(err, errors.ErrTooManyAttempts) {
+		t.Errorf("err = %v, want %v", err, errors.ErrTooManyAttempts)
+	}
+
+	_, err = s.VerifyTwoFactor(&dto.VerifyTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: "000000", IpAddress: "192.0.2.2"})
+	if !stderr.Is(err, errors.ErrInvalidTwoFactorCode) {
+		t.Errorf("another address: err = %v, want %v", err, errors.ErrInvalidTwoFactorCode)
+	}
+}
+
+// addPasswordUser adds a verified user that signs in with the password.
+func addPasswordUser(t *testing.T, repo *memoryAuthRepo, email, password string) *entities.User {
+	t.Helper()
+
+	passwordHash, err := hash.HashString(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := repo.addUser(email, true)
+	user.PasswordHash = passwordHash
+	return user
+}
+
+func TestLoginUserHidesUnknownEmails(t *testing.T) {
+	s, repo := newTestAuthService(t, AuthConfig{LoginAccountFailures: 5, LoginIpFailures: 20})
+	addPasswordUser(t, repo, "user@example.com", "correct horse")
+
+	attempts := map[string]*dto.LoginUserRequest{
+		"unknown email":  {Email: "nobody@example.com", Password: "correct horse", IpAddress: "192.0.2.1"},
+		"wrong password": {Email: "user@example.com", Password: "battery staple", IpAddress: "192.0.2.1"},
+	}
+	for name, rows := range attempts {
+		if _, err := s.LoginUser(rows); !stderr.Is(err, errors.ErrInvalidCredentials) {
+			t.Errorf("%s: err = %v, want %v", name, err, errors.ErrInvalidCredentials)
+		}
+
+		// Unknown emails count against their key like real accounts do.
+		attempt, err := s.attempts.GetLoginAttempt(accountAttemptKey(rows.Email))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attempt == nil || attempt.Failures != 1 {
+			t.Errorf("%s: attempt = %+v, want one failure", name, attempt)
+		}
+	}
+
+	// Unknown emails are checked against the dummy hash, which must be a real
+	// bcrypt hash for the comparison to cost the same as a wrong password.
+	if err := hash.CompareHashAndPassword(s.dummyHash, "correct horse"); !stderr.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		t.Errorf("dummy hash comparison: err = %v, want %v", err, bcrypt.ErrMismatchedHashAndPassword)
+	}
+}
+
+func TestLoginUserBacksOffAndLocksOut(t *testing.T) {
+	s, repo := newTestAuthService(t, AuthConfig{LoginAccountFailures: 2, LoginIpFailures: 20})
+	addPasswordUser(t, repo, "user@example.com", "correct horse")
+
+	wrong := &dto.LoginUserRequest{Email: "user@example.com", Password: "battery staple", IpAddress: "192.0.2.1"}
+	for i := range 2 {
+		if _, err := s.LoginUser(wrong); !stderr.Is(err, errors.ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: err = %v, want %v", i, err, errors.ErrInvalidCredentials)
+		}
+	}
+
+	right := &dto.LoginUserRequest{Email: "user@example.com", Password: "correct horse", IpAddress: "192.0.2.2"}
+	if _, err := s.LoginUser(right); !stderr.Is(err, errors.ErrTooManyAttempts) {
+		t.Fatalf("locked account: err = %v, want %v", err, errors.ErrTooManyAttempts)
+	}
+
+	// Each failure past the limit doubles the delay until it reaches the
+	// lockout duration.
+	key := accountAttemptKey("user@example.com")
+	for _, want := range []time.Duration{2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 32 * time.Minute, time.Hour, time.Hour} {
+		if err := s.registerLoginFailure(key, 2); err != nil {
+			t.Fatal(err)
+		}
+
+		attempt, err := s.attempts.GetLoginAttempt(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if delay := time.Until(*attempt.LockedUntil); delay > want || delay < want-time.Minute {
+			t.Errorf("failure %d: locked for %v, want %v", attempt.Failures, delay.Round(time.Second), want)
+		}
+	}
+}
+
+func TestLoginUserResetsAttempts(t *testing.T) {
+	s, repo := newTestAuthService(t, AuthConfig{LoginAccountFailures: 5, LoginIpFailures: 5})
+	addPasswordUser(t, repo, "user@example.com", "correct horse")
+
+	for _, email := range []string{"user@example.com", "other@example.com"} {
+		_, err := s.LoginUser(&dto.LoginUserRequest{Email: email, Password: "battery staple", IpAddress: "192.0.2.1"})
+		if !stderr.Is(err, errors.ErrInvalidCredentials) {
+			t.Fatalf("%s: err = %v, want %v", email, err, errors.ErrInvalidCredentials)
+		}
+	}
+
+	if _, err := s.LoginUser(&dto.LoginUserRequest{Email: "user@example.com", Password: "correct horse", IpAddress: "192.0.2.1"}); err != nil {
+		t.Fatalf("LoginUser() error = %v", err)
+	}
+
+	for _, key := range []string{accountAttemptKey("user@example.com"), ipAttemptKey("192.0.2.1")} {
+		if attempt, err := s.attempts.GetLoginAttempt(key); err != nil || attempt != nil {
+			t.Errorf("%s: attempt = %+v, err = %v, want it cleared", key, attempt, err)
+		}
+	}
+}
+
+func TestPasswordlessUserHasNoPasswordToUse(t *testing.T) {
+	s, repo := newTestAuthService(t, AuthConfig{LoginAccountFailures: 5, LoginIpFailures: 20})
+	user := repo.addUser("user@example.com", true)
+
+	for _, password := range []string{"", "anything"} {
+		_, err := s.LoginUser(&dto.LoginUserRequest{Email: "user@example.com", Password: password, IpAddress: "192.0.2.1"})
+		if !stderr.Is(err, errors.ErrInvalidCredentials) {
+			t.Errorf("LoginUser(%q): err = %v, want %v", password, err, errors.ErrInvalidCredentials)
+		}
+	}
+
+	_, err := s.ChangePassword(&dto.ChangePasswordRequest{UserId: user.UserId, NewPassword: "correct horse"})
+	if !stderr.Is(err, errors.ErrPasswordNotSet) {
+		t.Errorf("ChangePassword(): err = %v, want %v", err, errors.ErrPasswordNotSet)
+	}
+}
+
+func TestRefreshUserTokenRotates(t *testing.T) {
+	s, repo := newTestAuthService(t, AuthConfig{})
+	user := repo.addUser("user@example.com", true)
+
+	login, err := s.loginSession(user, &entities.Session{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refreshed, err := s.RefreshUserToken(&dto.RefreshUserTokenRequest{RefreshToken: login.RefreshToken})
+	if err != nil {
+		t.Fatalf("RefreshUserToken() error = %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken || refreshed.AccessToken == "" {
+		t.Fatal("expected a new token pair")
+	}
+
+	if _, err = s.RefreshUserToken(&dto.RefreshUserTokenRequest{RefreshToken: refreshed.RefreshToken}); err != nil {
+		t.Errorf("rotated token: err = %v", err)
+	}
+}
+
+func TestRefreshUserTokenReuseRevokesFamily(t *testing.T) {
+	s, repo := newTestAuthService(t, AuthConfig{})
+	user := repo.addUser("user@example.com", true)
+
+	login, err := s.loginSession(user, &entities.Session{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refreshed, err := s.RefreshUserToken(&dto.RefreshUserTokenRequest{RefreshToken: login.RefreshToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.RefreshUserToken(&dto.RefreshUserTokenRequest{RefreshToken: login.RefreshToken})
+	if !stderr.Is(err, errors.ErrRefreshTokenReused) {
+		t.Fatalf("replayed token: err = %v, want %v", err, errors.ErrRefreshTokenReused)
+	}
+	if repo.sessions[0].RevokedAt == nil {
+		t.Error("replay left the session active")
+	}
+
+	// The replay also burns the token the legitimate client rotated to.
+	_, err = s.RefreshUserToken(&dto.RefreshUserTokenRequest{RefreshToken: refreshed.RefreshToken})
+	if !stderr.Is(err, errors.ErrSessionRevoked) {
+		t.Errorf("rotated token after replay: err = %v, want %v", err, errors.ErrSessionRevoked)
+	}
+}
+
+func TestRefreshUserTokenRejectsBannedUser(t *testing.T) {
+	s, repo := newTestAuthService(t, AuthConfig{})
+	user := repo.addUser("user@example.com", true)
+
+	login, err := s.loginSession(user, &entities.Session{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bannedAt := time.Now()
+	user.BannedAt = &bannedAt
+
+	_, err = s.RefreshUserToken(&dto.RefreshUserTokenRequest{RefreshToken: login.RefreshToken})
+	if !stderr.Is(err, errors.ErrUserBanned) {
+		t.Errorf("err = %v, want %v", err, errors.ErrUserBanned)
+	}
+}
+
+type failingMailer struct{}
+
+func (failingMailer) Send(*mail.Message) error {
+	return stderr.New("smtp unavailable")
+}
+
+type recordingLogger struct {
+	nopLogger
+	errors []string
+}
+
+func (l *recordingLogger) Error(msg string, _ ...logger.Field) {
+	l.errors = append(l.errors, msg)
+}
+
+func (l *recordingLogger) With(...logger.Field) logger.Logger { return l }
+
+func newRegistrationService(t *testing.T, mailer mail.Mailer, log logger.Logger) (*AuthService, *memoryAuthRepo) {
+	t.Helper()
+
+	s, repo := newTestAuthService(t, AuthConfig{
+		EmailVerificationTTL: time.Hour,
+		EmailVerificationUrl: "https://nearbeee.test/verify?token={token}",
+	})
+	s.mailer = mailer
+	s.validator = mail.NewValidator(mail.StageFunc(mail.IDNStage))
+	s.logger = log
+
+	return s, repo
+}
+
+func TestRegistrateUserSendsVerificationEmail(t *testing.T) {
+	mailer := mail.NewMemoryMailer()
+	s, repo := newRegistrationService(t, mailer, nopLogger{})
+
+	registered, err := s.RegistrateUser(&dto.RegistrateUserRequest{Email: "New@Example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !registered.VerificationSent {
+		t.Error("VerificationSent = false, want true")
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(messages))
+	}
+	if messages[0].To != "New@example.com" {
+		t.Errorf("sent to %q, want the normalized address", messages[0].To)
+	}
+
+	_, link, ok := strings.Cut(messages[0].Body, "https://nearbeee.test/verify?token=")
+	if !ok {
+		t.Fatalf("no verification link in %q", messages[0].Body)
+	}
+	token, _, _ := strings.Cut(link, "\n")
+
+	if _, err = s.VerifyEmail(&dto.VerifyEmailRequest{Token: token}); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := repo.GetUserByEmail("New@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Verified {
+		t.Error("the emailed token did not verify the account")
+	}
+
+	if _, err = s.VerifyEmail(&dto.VerifyEmailRequest{Token: token}); !stderr.Is(err, errors.ErrInvalidToken) {
+		t.Errorf("reused token: err = %v, want %v", err, errors.ErrInvalidToken)
+	}
+}
+
+func TestRegistrateUserLogsMailerFailure(t *testing.T) {
+	log := &recordingLogger{}
+	s, _ := newRegistrationService(t, failingMailer{}, log)
+
+	registered, err := s.RegistrateUser(&dto.RegistrateUserRequest{Email: "new@example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("a mail failure should not fail the registration: %v", err)
+	}
+	if registered.VerificationSent {
+		t.Error("VerificationSent = true, want false")
+	}
+	if len(log.errors) != 1 {
+		t.Errorf("logged errors = %v, want one", log.errors)
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/utils/storage"
+)
+
+type AuthorsRepository interface {
+	GetProfilesByUserIds(userIds []uuid.UUID) ([]*entities.Profile, error)
+}
+
+type AuthorResolver struct {
+	repo    AuthorsRepository
+	storage storage.Storage
+}
+
+func NewAuthorResolver(repo AuthorsRepository, storage storage.Storage) *AuthorResolver {
+	return &AuthorResolver{repo: repo, storage: storage}
+}
+
+func (a *AuthorResolver) EmbedPostAuthors(posts ...*entities.Post) error {
+	userIds := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		userIds = append(userIds, post.UserId)
+	}
+
+	authors, err := a.authors(userIds)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		post.Author = authors[post.UserId]
+	}
+
+	return nil
+}
+
+func (a *AuthorResolver) EmbedMessageAuthors(messages ...*entities.Message) error {
+	userIds := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		userIds = append(userIds, message.UserId)
+	}
+
+	authors, err := a.authors(userIds)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		message.Author = authors[message.UserId]
+	}
+
+	return nil
+}
+
+func (a *AuthorResolver) authors(userIds []uuid.UUID) (map[uuid.UUID]*entities.Author, error) {
+	authors := make(map[uuid.UUID]*entities.Author, len(userIds))
+
+	unique := make([]uuid.UUID, 0, len(userIds))
+	for _, userId := range userIds {
+		if _, ok := authors[userId]; !ok {
+			authors[userId] = nil
+			unique = append(unique, userId)
+		}
+	}
+
+	if len(unique) == 0 {
+		return authors, nil
+	}
+
+	profiles, err := a.repo.GetProfilesByUserIds(unique)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, profile := range profiles {
+		authors[profile.UserId] = &entities.Author{
+			UserId:      profile.UserId,
+			Handle:      profile.Handle,
+			DisplayName: profile.DisplayName,
+			AvatarUrl:   avatarUrl(a.storage, profile.AvatarKey),
+		}
+	}
+
+	return authors, nil
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/logger"
+)
+
+const messageCountBackfill = "post_message_count"
+
+type MessageCountBackfillRepository interface {
+	IsBackfillDone(name string) (bool, error)
+	ReplacePostMessageCounts(counts map[uuid.UUID]int64, backfill string) error
+}
+
+type MessageCounter interface {
+	CountMessagesByPost(ctx context.Context) (map[uuid.UUID]int64, error)
+}
+
+// BackfillMessageCounts sets message_count from the messages stored before the
+// column existed. It runs once; afterwards the messages service keeps the
+// counts up to date. Messages written while it runs may be missed, so it runs
+// before the server starts serving.
+func BackfillMessageCounts(ctx context.Context, posts MessageCountBackfillRepository, messages MessageCounter, log logger.Logger) error {
+	done, err := posts.IsBackfillDone(messageCountBackfill)
+	if err != nil || done {
+		return err
+	}
+
+	counts, err := messages.CountMessagesByPost(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = posts.ReplacePostMessageCounts(counts, messageCountBackfill); err != nil {
+		return err
+	}
+
+	log.Info("message counts backfilled", logger.Int("posts", len(counts)))
+	return nil
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type fakeBackfillRepo struct {
+	done     map[string]bool
+	counts   map[uuid.UUID]int64
+	replaced int
+}
+
+func (r *fakeBackfillRepo) IsBackfillDone(name string) (bool, error) {
+	return r.done[name], nil
+}
+
+func (r *fakeBackfillRepo) ReplacePostMessageCounts(counts map[uuid.UUID]int64, backfill string) error {
+	r.counts = counts
+	r.done[backfill] = true
+	r.replaced++
+	return nil
+}
+
+type fakeMessageCounter map[uuid.UUID]int64
+
+func (c fakeMessageCounter) CountMessagesByPost(context.Context) (map[uuid.UUID]int64, error) {
+	return c, nil
+}
+
+func TestBackfillMessageCountsRunsOnce(t *testing.T) {
+	postId := uuid.New()
+	repo := &fakeBackfillRepo{done: map[string]bool{}}
+	messages := fakeMessageCounter{postId: 3}
+
+	for range 2 {
+		if err := BackfillMessageCounts(context.Background(), repo, messages, nopLogger{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if repo.replaced != 1 {
+		t.Errorf("counts replaced %d times, want once", repo.replaced)
+	}
+	if repo.counts[postId] != 3 {
+		t.Errorf("count = %d, want 3", repo.counts[postId])
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/geo"
+	"github.com/skrpld/NearBeee/pkg/utils/cache"
+	"github.com/skrpld/NearBeee/pkg/utils/geohash"
+)
+
+const (
+	MaxZoom = 22
+
+	// clusterTileFraction sets the cluster cell size to roughly a quarter of
+	// a 256px map tile at the requested zoom.
+	clusterTileFraction = 4
+	clusterSamples      = 3
+	maxClusterTiles     = 256
+)
+
+func (s *PostsService) GetPostClusters(rows *dto.GetPostClustersRequest) (*dto.GetPostClustersResponse, error) {
+	fields := rows.BBox.Validate()
+	if rows.Zoom < 0 || rows.Zoom > MaxZoom {
+		fields["zoom"] = fmt.Sprintf("must be between 0 and %d", MaxZoom)
+	}
+	if err := fields.AsError(); err != nil {
+		return nil, err
+	}
+
+	box, err := rows.BBox.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.Zoom >= s.cfg.ClusterMaxZoom {
+		posts, _, err := s.repo.GetPostsByBBox(box, entities.PostOrderRecent, rows.ViewerId, nil, s.cfg.ClusterPostLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		if rows.ExpandAuthor {
+			if err = s.authors.EmbedPostAuthors(posts...); err != nil {
+				return nil, err
+			}
+		}
+
+		response := dto.GetPostClustersResponse{
+			Clusters: make([]*entities.PostCluster, 0),
+			Posts:    posts,
+		}
+
+		return &response, nil
+	}
+
+	precision, tiles := clusterTiles(box, rows.Zoom)
+
+	clusters, err := s.clustersForTiles(tiles, precision)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]*entities.PostCluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		if box.Contains(geo.Coordinate{Latitude: cluster.Latitude, Longitude: cluster.Longitude}) {
+			visible = append(visible, cluster)
+		}
+	}
+
+	response := dto.GetPostClustersResponse{
+		Clusters: visible,
+	}
+
+	return &response, nil
+}
+
+// clustersForTiles serves clusters per tile from the cache and loads the
+// missing tiles in a single query. Tiles are one geohash level coarser than
+// the clusters, so every cluster belongs to exactly one tile.
+func (s *PostsService) clustersForTiles(tiles []string, precision int) ([]*entities.PostCluster, error) {
+	clusters := make([]*entities.PostCluster, 0)
+	missing := make([]string, 0, len(tiles))
+
+	for _, tile := range tiles {
+		cached, ok := s.clusters.get(precision, tile)
+		if !ok {
+			missing = append(missing, tile)
+			continue
+		}
+		clusters = append(clusters, cached...)
+	}
+
+	if len(missing) == 0 {
+		return clusters, nil
+	}
+
+	loaded, err := s.repo.GetPostClusters(missing, precision, clusterSamples)
+	if err != nil {
+		return nil, err
+	}
+
+	byTile := make(map[string][]*entities.PostCluster, len(missing))
+	for _, cluster := range loaded {
+		tile := cluster.Geohash[:precision-1]
+		byTile[tile] = append(byTile[tile], cluster)
+	}
+
+	for _, tile := range missing {
+		s.clusters.set(precision, tile, byTile[tile])
+	}
+
+	return append(clusters, loaded...), nil
+}
+
+// clusterTiles picks the cluster precision for the zoom level and the tiles
+// covering the box, coarsening both when the box would need too many tiles.
+func clusterTiles(box geo.BBox, zoom int) (int, []string) {
+	precision := 1
+	target := 2 * geo.MaxLongitude / math.Exp2(float64(zoom)) / clusterTileFraction
+	for p := geohash.MaxPrecision; p > 1; p-- {
+		if _, lonSize := geohash.CellSize(p); lonSize >= target {
+			precision = p
+			break
+		}
+	}
+
+	for ; precision > 1; precision-- {
+		tiles := make([]string, 0)
+		for _, part := range box.Split() {
+			cells := geohash.CoverBox(part.SouthWest.Latitude, part.SouthWest.Longitude,
+				part.NorthEast.Latitude, part.NorthEast.Longitude, precision-1, maxClusterTiles-len(tiles))
+			if cells == nil {
+				tiles = nil
+				break
+			}
+			tiles = append(tiles, cells...)
+		}
+
+		if tiles != nil {
+			return precision, tiles
+		}
+	}
+
+	return 1, []string{""}
+}
+
+// ClusterCache holds the clusters of each geohash tile. It is shared by every
+// service that creates or deletes posts so changes show up on the map without
+// waiting for the TTL.
+type ClusterCache struct {
+	tiles *cache.Cache[string, []*entities.PostCluster]
+}
+
+func NewClusterCache(cfg PostsConfig) *ClusterCache {
+	return &ClusterCache{
+		tiles: cache.New[string, []*entities.PostCluster](cfg.ClusterCacheTTL, cfg.ClusterCacheSize),
+	}
+}
+
+func (c *ClusterCache) get(precision int, tile string) ([]*entities.PostCluster, bool) {
+	return c.tiles.Get(clusterCacheKey(precision, tile))
+}
+
+func (c *ClusterCache) set(precision int, tile string, clusters []*entities.PostCluster) {
+	c.tiles.Set(clusterCacheKey(precision, tile), clusters)
+}
+
+// Invalidate drops the cached tiles containing a post's public location at
+// every precision.
+func (c *ClusterCache) Invalidate(latitude, longitude float64) {
+	hash := geohash.Encode(latitude, longitude, geohash.MaxPrecision)
+	for precision := 1; precision <= geohash.MaxPrecision; precision++ {
+		c.tiles.Delete(clusterCacheKey(precision, hash[:precision-1]))
+	}
+}
+
+func clusterCacheKey(precision int, tile string) string {
+	return fmt.Sprintf("%d:%s", precision, tile)
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/utils/geohash"
+)
+
+func TestClusterCacheInvalidate(t *testing.T) {
+	clusters := NewClusterCache(PostsConfig{ClusterCacheTTL: time.Minute, ClusterCacheSize: 100})
+
+	hash := geohash.Encode(55.75, 37.62, geohash.MaxPrecision)
+	other := geohash.Encode(-33.87, 151.21, geohash.MaxPrecision)
+	for precision := 1; precision <= geohash.MaxPrecision; precision++ {
+		clusters.set(precision, hash[:precision-1], []*entities.PostCluster{})
+		clusters.set(precision, other[:precision-1], []*entities.PostCluster{})
+	}
+
+	clusters.Invalidate(55.75, 37.62)
+
+	for precision := 1; precision <= geohash.MaxPrecision; precision++ {
+		if _, ok := clusters.get(precision, hash[:precision-1]); ok {
+			t.Errorf("tile %q at precision %d still cached", hash[:precision-1], precision)
+		}
+	}
+	// The world tile at precision 1 holds every post; all others are far away.
+	for precision := 2; precision <= geohash.MaxPrecision; precision++ {
+		if _, ok := clusters.get(precision, other[:precision-1]); !ok {
+			t.Errorf("unrelated tile %q at precision %d was dropped", other[:precision-1], precision)
+		}
+	}
+}
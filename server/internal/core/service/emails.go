@@ -0,0 +1,53 @@
+package service
+
+import (
+	stderr "errors"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/logger"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/mail"
+)
+
+type LegacyEmailRepository interface {
+	GetUsersWithNonASCIIEmail() ([]*entities.User, error)
+	UpdateUserEmail(userId uuid.UUID, email string) error
+}
+
+// NormalizeLegacyEmails rewrites addresses with internationalized domains into
+// the punycode form sign-in looks up, finishing what the email normalization
+// migration cannot do in SQL. An address whose normalized form already belongs
+// to another account is left as stored, matching the migration.
+func NormalizeLegacyEmails(repo LegacyEmailRepository, log logger.Logger) error {
+	users, err := repo.GetUsersWithNonASCIIEmail()
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		userLogger := log.With(logger.String("user_id", user.UserId.String()))
+
+		email, err := mail.Normalize(user.Email)
+		if err != nil {
+			userLogger.Error("stored email cannot be normalized", logger.Error(err))
+			continue
+		}
+		if email == user.Email {
+			continue
+		}
+
+		err = repo.UpdateUserEmail(user.UserId, email)
+		if stderr.Is(err, errors.ErrUserAlreadyExists) {
+			userLogger.Error("normalized email belongs to another account", logger.String("email", email))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		userLogger.Info("email normalized")
+	}
+
+	return nil
+}
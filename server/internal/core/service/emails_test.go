@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/logger"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+type legacyEmailRepo struct {
+	users  []*entities.User
+	emails map[string]uuid.UUID
+}
+
+func (r *legacyEmailRepo) GetUsersWithNonASCIIEmail() ([]*entities.User, error) {
+	return r.users, nil
+}
+
+func (r *legacyEmailRepo) UpdateUserEmail(userId uuid.UUID, email string) error {
+	if owner, ok := r.emails[email]; ok && owner != userId {
+		return errors.ErrUserAlreadyExists
+	}
+	r.emails[email] = userId
+	return nil
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...logger.Field)         {}
+func (nopLogger) Error(string, ...logger.Field)        {}
+func (l nopLogger) With(...logger.Field) logger.Logger { return l }
+
+func TestNormalizeLegacyEmails(t *testing.T) {
+	idn, taken, owner := uuid.New(), uuid.New(), uuid.New()
+
+	repo := &legacyEmailRepo{
+		users: []*entities.User{
+			{UserId: idn, Email: "anna@Bücher.de"},
+			{UserId: taken, Email: "max@Straße.de"},
+		},
+		emails: map[string]uuid.UUID{"max@xn--strae-oqa.de": owner},
+	}
+
+	if err := NormalizeLegacyEmails(repo, nopLogger{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.emails["anna@xn--bcher-kva.de"] != idn {
+		t.Errorf("internationalized domain not normalized: %v", repo.emails)
+	}
+	if repo.emails["max@xn--strae-oqa.de"] != owner {
+		t.Errorf("colliding address reassigned: %v", repo.emails)
+	}
+}
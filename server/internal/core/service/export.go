@@ -0,0 +1,56 @@
+package service
+
+import (
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/geo"
+)
+
+// ExportPostsByLocation returns every post in the circle, up to the export
+// limit, as features read lazily while the response is written.
+func (s *PostsService) ExportPostsByLocation(rows *dto.GetPostsByLocationRequest) (geo.FeatureSource, error) {
+	coordinate, err := s.validateLocation(rows.Coordinate, rows.Radius)
+	if err != nil {
+		return nil, err
+	}
+
+	return postFeatures(func(fn func(*entities.Post) error) error {
+		return s.repo.EachPostByLocation(coordinate.Latitude, coordinate.Longitude, rows.Radius, rows.ViewerId, s.cfg.ExportPostLimit, fn)
+	}), nil
+}
+
+func (s *PostsService) ExportPostsByBBox(rows *dto.GetPostsByBBoxRequest) (geo.FeatureSource, error) {
+	box, err := validateBBox(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return postFeatures(func(fn func(*entities.Post) error) error {
+		return s.repo.EachPostInBBox(box, rows.Order, rows.ViewerId, s.cfg.ExportPostLimit, fn)
+	}), nil
+}
+
+type postFeatures func(fn func(*entities.Post) error) error
+
+func (each postFeatures) EachFeature(fn func(geo.Feature) error) error {
+	return each(func(post *entities.Post) error {
+		return fn(geo.Feature{
+			Id:         post.PostId.String(),
+			Name:       post.Title,
+			Coordinate: geo.Coordinate{Latitude: post.Latitude, Longitude: post.Longitude},
+			Properties: []geo.Property{
+				{Key: "post_id", Value: post.PostId.String()},
+				{Key: "user_id", Value: post.UserId.String()},
+				{Key: "title", Value: post.Title},
+				{Key: "content", Value: post.Content},
+				{Key: "precision", Value: string(post.Precision)},
+				{Key: "country", Value: post.Country},
+				{Key: "region", Value: post.Region},
+				{Key: "locality", Value: post.Locality},
+				{Key: "message_count", Value: post.MessageCount},
+				{Key: "created_at", Value: post.CreatedAt},
+				{Key: "updated_at", Value: post.UpdatedAt},
+			},
+		})
+	})
+}
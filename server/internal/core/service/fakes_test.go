@@ -0,0 +1,324 @@
+package service
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/geo"
+)
+
+// memoryAuthRepo keeps users and identities in memory. Methods a test does not
+// exercise fall through to the nil embedded interface and panic.
+type memoryAuthRepo struct {
+	AuthRepository
+
+	mu         sync.Mutex
+	users      map[uuid.UUID]*entities.User
+	identities map[string]*entities.UserIdentity
+	tokens     map[uuid.UUID]*entities.UserToken
+	totps      map[uuid.UUID]*entities.UserTOTP
+	sessions   []*entities.Session
+	refresh    map[uuid.UUID]*entities.RefreshToken
+}
+
+func newMemoryAuthRepo() *memoryAuthRepo {
+	return &memoryAuthRepo{
+		users:      make(map[uuid.UUID]*entities.User),
+		identities: make(map[string]*entities.UserIdentity),
+		tokens:     make(map[uuid.UUID]*entities.UserToken),
+		totps:      make(map[uuid.UUID]*entities.UserTOTP),
+		refresh:    make(map[uuid.UUID]*entities.RefreshToken),
+	}
+}
+
+func (r *memoryAuthRepo) addUser(email string, verified bool) *entities.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user := &entities.User{UserId: uuid.New(), Email: email, Verified: verified, Role: entities.UserRole}
+	r.users[user.UserId] = user
+	return user
+}
+
+func (r *memoryAuthRepo) CreateUser(email, passwordHash string) (*entities.User, error) {
+	if _, err := r.GetUserByEmail(email); err == nil {
+		return nil, errors.ErrUserAlreadyExists
+	}
+
+	user := r.addUser(email, false)
+	user.PasswordHash = passwordHash
+	return user, nil
+}
+
+func (r *memoryAuthRepo) GetUserByEmail(email string) (*entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, errors.ErrInvalidEmail
+}
+
+func (r *memoryAuthRepo) GetUserById(userId uuid.UUID) (*entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return nil, errors.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *memoryAuthRepo) GetUserByIdentity(provider, subject string) (*entities.User, error) {
+	r.mu.Lock()
+	identity, ok := r.identities[provider+"/"+subject]
+	r.mu.Unlock()
+
+	if !ok {
+		return nil, errors.ErrUserNotFound
+	}
+	return r.GetUserById(identity.UserId)
+}
+
+func (r *memoryAuthRepo) CreateUserIdentity(identity *entities.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := identity.Provider + "/" + identity.Subject
+	if _, ok := r.identities[key]; ok {
+		return errors.ErrIdentityAlreadyLinked
+	}
+	r.identities[key] = identity
+	return nil
+}
+
+func (r *memoryAuthRepo) SetUserVerified(userId uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return errors.ErrUserNotFound
+	}
+	user.Verified = true
+	return nil
+}
+
+func (r *memoryAuthRepo) CreateUserToken(token *entities.UserToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.CreatedAt = time.Now()
+	r.tokens[token.TokenId] = token
+	return nil
+}
+
+func (r *memoryAuthRepo) GetLatestUserToken(userId uuid.UUID, purpose string) (*entities.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest *entities.UserToken
+	for _, token := range r.tokens {
+		if token.UserId == userId && token.Purpose == purpose && (latest == nil || token.CreatedAt.After(latest.CreatedAt)) {
+			latest = token
+		}
+	}
+	return latest, nil
+}
+
+func (r *memoryAuthRepo) ConsumeUserToken(tokenId uuid.UUID, purpose string) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenId]
+	if !ok || token.Purpose != purpose || token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return uuid.Nil, errors.ErrInvalidToken
+	}
+
+	now := time.Now()
+	token.UsedAt = &now
+	return token.UserId, nil
+}
+
+func (r *memoryAuthRepo) GetUserTOTP(userId uuid.UUID) (*entities.UserTOTP, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.totps[userId], nil
+}
+
+func (r *memoryAuthRepo) UseTOTPStep(userId uuid.UUID, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	userTOTP, ok := r.totps[userId]
+	if !ok || userTOTP.ConfirmedAt == nil || userTOTP.LastUsedStep >= step {
+		return errors.ErrInvalidTwoFactorCode
+	}
+	userTOTP.LastUsedStep = step
+	return nil
+}
+
+func (r *memoryAuthRepo) CancelAccountDeletion(uuid.UUID) error {
+	return nil
+}
+
+func (r *memoryAuthRepo) CreateSession(session *entities.Session, token *entities.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions = append(r.sessions, session)
+	r.refresh[token.TokenId] = token
+	return nil
+}
+
+func (r *memoryAuthRepo) RevokeSession(sessionId, userId uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, session := range r.sessions {
+		if session.SessionId == sessionId && session.UserId == userId && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			for _, token := range r.refresh {
+				if token.FamilyId == sessionId && token.RevokedAt == nil {
+					token.RevokedAt = &now
+				}
+			}
+			return nil
+		}
+	}
+	return errors.ErrSessionNotFound
+}
+
+func (r *memoryAuthRepo) GetRefreshTokenById(tokenId uuid.UUID) (*entities.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.refresh[tokenId]
+	if !ok {
+		return nil, errors.ErrInvalidToken
+	}
+	stored := *token
+	return &stored, nil
+}
+
+func (r *memoryAuthRepo) RotateRefreshToken(oldTokenId uuid.UUID, newToken *entities.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.refresh[oldTokenId]
+	if !ok || token.UsedAt != nil || token.RevokedAt != nil {
+		return errors.ErrRefreshTokenReused
+	}
+
+	now := time.Now()
+	token.UsedAt = &now
+	r.refresh[newToken.TokenId] = newToken
+	return nil
+}
+
+// memoryFeedRepo ranks its posts like the repository's feed query and records
+// the moment each page was scored for.
+type memoryFeedRepo struct {
+	PostsRepository
+
+	posts []*entities.Post
+	asOfs []time.Time
+}
+
+func (r *memoryFeedRepo) GetPostsFeed(latitude, longitude, radius float64, scoring entities.FeedScoring, asOf time.Time, _ uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error) {
+	r.asOfs = append(r.asOfs, asOf)
+	origin := geo.Coordinate{Latitude: latitude, Longitude: longitude}
+
+	ranked := make([]*entities.Post, 0, len(r.posts))
+	for _, stored := range r.posts {
+		distance := geo.Distance(origin, geo.Coordinate{Latitude: stored.Latitude, Longitude: stored.Longitude})
+		if distance > radius {
+			continue
+		}
+
+		post := *stored
+		score := entities.PostScore{
+			DistanceKm: distance,
+			Distance:   math.Pow(0.5, distance/scoring.DistanceHalfLife),
+			Recency:    math.Pow(0.5, max(asOf.Sub(post.CreatedAt).Seconds(), 0)/scoring.RecencyHalfLife.Seconds()),
+			Engagement: math.Log1p(float64(post.MessageCount)),
+		}
+		score.Total = scoring.DistanceWeight*score.Distance + scoring.RecencyWeight*score.Recency + scoring.EngagementWeight*score.Engagement
+		post.Score = &score
+
+		if after != nil && (score.Total > after.Score || score.Total == after.Score && post.PostId.String() >= after.Id) {
+			continue
+		}
+		ranked = append(ranked, &post)
+	}
+
+	slices.SortFunc(ranked, func(a, b *entities.Post) int {
+		if c := cmp.Compare(b.Score.Total, a.Score.Total); c != 0 {
+			return c
+		}
+		return strings.Compare(b.PostId.String(), a.PostId.String())
+	})
+
+	if int64(len(ranked)) <= limit {
+		return ranked, nil, nil
+	}
+
+	ranked = ranked[:limit]
+	last := ranked[limit-1]
+	return ranked, &entities.PageCursor{Score: last.Score.Total, AsOf: asOf, Id: last.PostId.String()}, nil
+}
+
+// memoryAccountRepo keeps users and tokens in a memoryAuthRepo and records
+// scheduled deletions.
+type memoryAccountRepo struct {
+	AccountRepository
+
+	auth      *memoryAuthRepo
+	deletions map[uuid.UUID]time.Time
+}
+
+func newMemoryAccountRepo() *memoryAccountRepo {
+	return &memoryAccountRepo{auth: newMemoryAuthRepo(), deletions: make(map[uuid.UUID]time.Time)}
+}
+
+func (r *memoryAccountRepo) GetUserById(userId uuid.UUID) (*entities.User, error) {
+	return r.auth.GetUserById(userId)
+}
+
+func (r *memoryAccountRepo) CreateUserToken(token *entities.UserToken) error {
+	return r.auth.CreateUserToken(token)
+}
+
+func (r *memoryAccountRepo) ConsumeUserToken(tokenId uuid.UUID, purpose string) (uuid.UUID, error) {
+	return r.auth.ConsumeUserToken(tokenId, purpose)
+}
+
+func (r *memoryAccountRepo) GetLatestUserToken(userId uuid.UUID, purpose string) (*entities.UserToken, error) {
+	return r.auth.GetLatestUserToken(userId, purpose)
+}
+
+func (r *memoryAccountRepo) ScheduleAccountDeletion(userId uuid.UUID, scheduledFor time.Time) (*entities.AccountDeletion, error) {
+	r.deletions[userId] = scheduledFor
+	return &entities.AccountDeletion{UserId: userId, RequestedAt: time.Now(), ScheduledFor: scheduledFor}, nil
+}
+
+func (r *memoryAccountRepo) RevokeAllSessions(uuid.UUID) error {
+	return nil
+}
+
+func (r *memoryAccountRepo) RevokeAllApiKeys(uuid.UUID) error {
+	return nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+)
+
+// GetPostsFeed ranks the posts in the circle by their feed score. Scores decay
+// with time, so the first page fixes the moment they are computed for and the
+// cursor carries it to later pages, keeping the order stable while paging.
+func (s *PostsService) GetPostsFeed(rows *dto.GetPostsFeedRequest) (*dto.GetPostsFeedResponse, error) {
+	coordinate, err := s.validateLocation(rows.Coordinate, rows.Radius)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := fmt.Sprintf("%g,%g,%g", coordinate.Latitude, coordinate.Longitude, rows.Radius)
+	after, err := s.paginator.Decode(feedPostsCursor, scope, rows.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf := time.Now()
+	if after != nil {
+		asOf = after.AsOf
+	}
+
+	posts, next, err := s.repo.GetPostsFeed(coordinate.Latitude, coordinate.Longitude, rows.Radius, s.feedScoring(), asOf, rows.ViewerId, after, s.paginator.Limit(rows.Count))
+	if err != nil {
+		return nil, err
+	}
+
+	if !rows.DebugScore {
+		for _, post := range posts {
+			post.Score = nil
+		}
+	}
+
+	if rows.ExpandAuthor {
+		if err = s.authors.EmbedPostAuthors(posts...); err != nil {
+			return nil, err
+		}
+	}
+
+	nextCursor, err := s.paginator.Encode(feedPostsCursor, scope, next)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.GetPostsFeedResponse{
+		Posts:      posts,
+		NextCursor: nextCursor,
+		HasMore:    next != nil,
+	}
+
+	return &response, nil
+}
+
+func (s *PostsService) feedScoring() entities.FeedScoring {
+	return entities.FeedScoring{
+		DistanceWeight:   s.cfg.FeedDistanceWeight,
+		RecencyWeight:    s.cfg.FeedRecencyWeight,
+		EngagementWeight: s.cfg.FeedEngagementWeight,
+		DistanceHalfLife: s.cfg.FeedDistanceHalfLife,
+		RecencyHalfLife:  s.cfg.FeedRecencyHalfLife,
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/geo"
+)
+
+func newTestFeedService() (*PostsService, *memoryFeedRepo) {
+	// Wall-clock times only, so scores computed from a decoded cursor's
+	// moment match the first page's exactly.
+	now := time.Now().Round(0)
+	repo := &memoryFeedRepo{}
+
+	// Posts at growing distances and ages, some busier than their neighbours.
+	for i := range 7 {
+		repo.posts = append(repo.posts, &entities.Post{
+			PostId:       uuid.New(),
+			Latitude:     55.75 + float64(i)*0.005,
+			Longitude:    37.62,
+			MessageCount: int64(i % 3 * 10),
+			CreatedAt:    now.Add(-time.Duration(i) * 6 * time.Hour),
+		})
+	}
+
+	s := NewPostsService(repo, nil, newTestPaginator(), nil, nil, PostsConfig{
+		SearchRadiusMin:      0.1,
+		SearchRadiusMax:      50,
+		FeedDistanceWeight:   1,
+		FeedRecencyWeight:    1,
+		FeedEngagementWeight: 0.25,
+		FeedDistanceHalfLife: 1,
+		FeedRecencyHalfLife:  24 * time.Hour,
+	})
+
+	return s, repo
+}
+
+func feedRequest(cursor string, debug bool) *dto.GetPostsFeedRequest {
+	return &dto.GetPostsFeedRequest{
+		Coordinate: geo.Coordinate{Latitude: 55.75, Longitude: 37.62},
+		Radius:     10,
+		Count:      3,
+		Cursor:     cursor,
+		DebugScore: debug,
+	}
+}
+
+func TestGetPostsFeedPagesAtOneMoment(t *testing.T) {
+	s, repo := newTestFeedService()
+
+	ids := make(map[uuid.UUID]bool)
+	cursor := ""
+	for page := 0; ; page++ {
+		if page > len(repo.posts) {
+			t.Fatal("the feed does not end")
+		}
+
+		response, err := s.GetPostsFeed(feedRequest(cursor, false))
+		if err != nil {
+			t.Fatalf("page %d: GetPostsFeed() error = %v", page, err)
+		}
+
+		for _, post := range response.Posts {
+			if ids[post.PostId] {
+				t.Errorf("page %d repeats post %s", page, post.PostId)
+			}
+			ids[post.PostId] = true
+		}
+
+		if !response.HasMore {
+			break
+		}
+		cursor = response.NextCursor
+		time.Sleep(time.Millisecond)
+	}
+
+	if len(ids) != len(repo.posts) {
+		t.Errorf("paged through %d posts, want %d", len(ids), len(repo.posts))
+	}
+
+	// Later pages are scored as of the first one, not when they are fetched.
+	for i, asOf := range repo.asOfs {
+		if !asOf.Equal(repo.asOfs[0]) {
+			t.Errorf("page %d scored as of %v, want %v", i, asOf, repo.asOfs[0])
+		}
+	}
+}
+
+func TestGetPostsFeedDebugScore(t *testing.T) {
+	s, _ := newTestFeedService()
+
+	for _, debug := range []bool{false, true} {
+		response, err := s.GetPostsFeed(feedRequest("", debug))
+		if err != nil {
+			t.Fatalf("GetPostsFeed() error = %v", err)
+		}
+		if len(response.Posts) == 0 {
+			t.Fatal("empty feed")
+		}
+
+		for _, post := range response.Posts {
+			if (post.Score != nil) != debug {
+				t.Errorf("debug = %v: score = %+v", debug, post.Score)
+			}
+		}
+	}
+}
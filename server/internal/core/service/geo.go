@@ -0,0 +1,38 @@
+package service
+
+import (
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/geocoder"
+)
+
+type GeoService struct {
+	geocoder *geocoder.Geocoder
+}
+
+func NewGeoService(geocoder *geocoder.Geocoder) *GeoService {
+	return &GeoService{geocoder: geocoder}
+}
+
+func (s *GeoService) ReverseGeocode(rows *dto.ReverseGeocodeRequest) (*dto.ReverseGeocodeResponse, error) {
+	if !s.geocoder.Enabled() {
+		return nil, errors.ErrGeocoderUnavailable
+	}
+
+	coordinate, err := rows.Coordinate.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	place, distance, ok := s.geocoder.Reverse(coordinate.Latitude, coordinate.Longitude)
+	if !ok {
+		return nil, errors.ErrPlaceNotFound
+	}
+
+	response := dto.ReverseGeocodeResponse{
+		Place:    place,
+		Distance: distance,
+	}
+
+	return &response, nil
+}
@@ -13,18 +13,25 @@ import (
 type MessagesRepository interface {
 	CreateMessage(ctx context.Context, postId, userId uuid.UUID, content string) (*entities.Message, error)
 	GetMessageByMessageId(ctx context.Context, messageId bson.ObjectID) (*entities.Message, error)
-	GetMessageByUserId(ctx context.Context, userId uuid.UUID, count int64) ([]*entities.Message, error)
-	GetMessagesByPostId(ctx context.Context, postId uuid.UUID, count int64) ([]*entities.Message, error)
+	GetMessageByUserId(ctx context.Context, userId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Message, *entities.PageCursor, error)
+	GetMessagesByPostId(ctx context.Context, postId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Message, *entities.PageCursor, error)
 	UpdateMessageById(ctx context.Context, messageId bson.ObjectID, userId uuid.UUID, content string) (*entities.Message, error)
-	DeleteMessageById(ctx context.Context, messageId bson.ObjectID, userId uuid.UUID) error
+	DeleteMessageById(ctx context.Context, messageId bson.ObjectID, userId uuid.UUID) (*entities.Message, error)
+}
+
+type MessageCountRepository interface {
+	UpdatePostMessageCount(postId uuid.UUID, delta int) error
 }
 
 type MessagesService struct {
-	repo MessagesRepository
+	repo      MessagesRepository
+	counts    MessageCountRepository
+	authors   *AuthorResolver
+	paginator *Paginator
 }
 
-func NewMessagesService(repo MessagesRepository) *MessagesService {
-	return &MessagesService{repo: repo}
+func NewMessagesService(repo MessagesRepository, counts MessageCountRepository, authors *AuthorResolver, paginator *Paginator) *MessagesService {
+	return &MessagesService{repo: repo, counts: counts, authors: authors, paginator: paginator}
 }
 
 func (s *MessagesService) CreateMessage(ctx context.Context, rows *dto.CreateMessageRequest) (*dto.CreateMessageResponse, error) {
@@ -38,6 +45,10 @@ func (s *MessagesService) CreateMessage(ctx context.Context, rows *dto.CreateMes
 		return nil, err
 	}
 
+	if err = s.counts.UpdatePostMessageCount(postId, 1); err != nil {
+		return nil, err
+	}
+
 	response := dto.CreateMessageResponse{
 		Message: message,
 	}
@@ -56,6 +67,12 @@ func (s *MessagesService) GetMessageByMessageId(ctx context.Context, rows *dto.G
 		return nil, err
 	}
 
+	if rows.ExpandAuthor {
+		if err = s.authors.EmbedMessageAuthors(message); err != nil {
+			return nil, err
+		}
+	}
+
 	response := dto.GetMessageByMessageIdResponse{
 		Message: message,
 	}
@@ -64,13 +81,32 @@ func (s *MessagesService) GetMessageByMessageId(ctx context.Context, rows *dto.G
 }
 
 func (s *MessagesService) GetMessageByUserId(ctx context.Context, rows *dto.GetMessageByUserIdRequest) (*dto.GetMessageByUserIdResponse, error) {
-	messages, err := s.repo.GetMessageByUserId(ctx, rows.UserId, rows.Count)
+	scope := rows.UserId.String()
+	after, err := s.paginator.Decode(userMessagesCursor, scope, rows.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, next, err := s.repo.GetMessageByUserId(ctx, rows.UserId, after, s.paginator.Limit(rows.Count))
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.ExpandAuthor {
+		if err = s.authors.EmbedMessageAuthors(messages...); err != nil {
+			return nil, err
+		}
+	}
+
+	nextCursor, err := s.paginator.Encode(userMessagesCursor, scope, next)
 	if err != nil {
 		return nil, err
 	}
 
 	response := dto.GetMessageByUserIdResponse{
-		Messages: messages,
+		Messages:   messages,
+		NextCursor: nextCursor,
+		HasMore:    next != nil,
 	}
 
 	return &response, nil
@@ -82,13 +118,32 @@ func (s *MessagesService) GetMessagesByPostId(ctx context.Context, rows *dto.Get
 		return nil, errors.ErrInvalidPostId
 	}
 
-	messages, err := s.repo.GetMessagesByPostId(ctx, postId, rows.Count)
+	scope := postId.String()
+	after, err := s.paginator.Decode(postMessagesCursor, scope, rows.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, next, err := s.repo.GetMessagesByPostId(ctx, postId, after, s.paginator.Limit(rows.Count))
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.ExpandAuthor {
+		if err = s.authors.EmbedMessageAuthors(messages...); err != nil {
+			return nil, err
+		}
+	}
+
+	nextCursor, err := s.paginator.Encode(postMessagesCursor, scope, next)
 	if err != nil {
 		return nil, err
 	}
 
 	response := dto.GetMessagesByPostIdResponse{
-		Messages: messages,
+		Messages:   messages,
+		NextCursor: nextCursor,
+		HasMore:    next != nil,
 	}
 
 	return &response, nil
@@ -118,11 +173,15 @@ func (s *MessagesService) DeleteMessageById(ctx context.Context, rows *dto.Delet
 		return nil, errors.ErrInvalidMsgId
 	}
 
-	err = s.repo.DeleteMessageById(ctx, objectId, rows.UserId)
+	message, err := s.repo.DeleteMessageById(ctx, objectId, rows.UserId)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = s.counts.UpdatePostMessageCount(message.PostId, -1); err != nil {
+		return nil, err
+	}
+
 	response := dto.DeleteMessageByIdResponse{
 		Success: true,
 	}
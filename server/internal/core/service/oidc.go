@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	stderr "errors"
+	"strings"
+	"time"
+
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/mail"
+	"github.com/skrpld/NearBeee/pkg/utils/oidc"
+)
+
+const oidcStatePurpose = "oidc-state"
+
+type oidcState struct {
+	Provider   string `json:"p"`
+	State      string `json:"s"`
+	Nonce      string `json:"n"`
+	Verifier   string `json:"v"`
+	DeviceName string `json:"d,omitempty"`
+	ExpiresAt  int64  `json:"e"`
+}
+
+func (s *AuthService) StartOIDC(rows *dto.StartOIDCRequest) (*dto.StartOIDCResponse, error) {
+	client, ok := s.providers[rows.Provider]
+	if !ok {
+		return nil, errors.ErrUnknownOIDCProvider
+	}
+
+	stateValue, err := oidc.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	state := oidcState{
+		Provider:   rows.Provider,
+		State:      stateValue,
+		Nonce:      nonce,
+		Verifier:   verifier,
+		DeviceName: rows.DeviceName,
+		ExpiresAt:  time.Now().Add(s.oidcCfg.StateTTL).Unix(),
+	}
+
+	authUrl, err := client.AuthCodeURL(context.Background(), state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		return nil, errors.ErrOIDCProviderFailed
+	}
+
+	encoded, err := s.stateSigner.Encode(state)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.StartOIDCResponse{
+		AuthUrl:   authUrl,
+		State:     encoded,
+		ExpiresAt: time.Unix(state.ExpiresAt, 0),
+		Secure:    strings.HasPrefix(s.oidcCfg.ProviderConfigs[rows.Provider].RedirectUrl, "https://"),
+	}
+
+	return response, nil
+}
+
+func (s *AuthService) OIDCCallback(rows *dto.OIDCCallbackRequest) (*dto.LoginUserResponse, error) {
+	client, ok := s.providers[rows.Provider]
+	if !ok {
+		return nil, errors.ErrUnknownOIDCProvider
+	}
+
+	var state oidcState
+	if err := s.stateSigner.Decode(rows.Cookie, &state); err != nil {
+		return nil, errors.ErrInvalidOIDCState
+	}
+
+	if state.Provider != rows.Provider || state.State == "" || state.State != rows.State ||
+		time.Now().Unix() > state.ExpiresAt {
+		return nil, errors.ErrInvalidOIDCState
+	}
+
+	if rows.Error != "" || rows.Code == "" {
+		return nil, errors.ErrOIDCProviderFailed
+	}
+
+	ctx := context.Background()
+
+	token, err := client.Exchange(ctx, rows.Code, state.Verifier)
+	if err != nil {
+		return nil, errors.ErrOIDCProviderFailed
+	}
+
+	claims, err := client.VerifyIDToken(ctx, token.IDToken, state.Nonce)
+	if err != nil {
+		if stderr.Is(err, oidc.ErrInvalidIDToken) {
+			return nil, errors.ErrInvalidToken
+		}
+		return nil, errors.ErrOIDCProviderFailed
+	}
+
+	user, err := s.resolveOIDCUser(rows.Provider, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.BannedAt != nil {
+		return nil, errors.ErrUserBanned
+	}
+
+	session := &entities.Session{
+		DeviceName: state.DeviceName,
+		UserAgent:  rows.UserAgent,
+		IpAddress:  rows.IpAddress,
+	}
+
+	return s.completeLogin(user, session)
+}
+
+func (s *AuthService) resolveOIDCUser(provider string, claims *oidc.IDTokenClaims) (*entities.User, error) {
+	user, err := s.repo.GetUserByIdentity(provider, claims.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !stderr.Is(err, errors.ErrUserNotFound) {
+		return nil, err
+	}
+
+	if !claims.EmailVerified {
+		return nil, errors.ErrOIDCEmailNotVerified
+	}
+
+	email, err := mail.Normalize(claims.Email)
+	if err != nil {
+		return nil, errors.ErrOIDCEmailNotVerified
+	}
+
+	user, err = s.repo.GetUserByEmail(email)
+	switch {
+	case err == nil:
+		if !user.Verified {
+			return nil, errors.ErrOIDCAccountNotVerified
+		}
+	case stderr.Is(err, errors.ErrInvalidEmail):
+		if user, err = s.createOIDCUser(email); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	identity := &entities.UserIdentity{
+		Provider: provider,
+		Subject:  claims.Subject,
+		UserId:   user.UserId,
+		Email:    email,
+	}
+
+	if err = s.repo.CreateUserIdentity(identity); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// createOIDCUser creates a verified account without a password. Its owner
+// confirms account deletion by email and sets a password with a reset.
+func (s *AuthService) createOIDCUser(email string) (*entities.User, error) {
+	user, err := s.repo.CreateUser(email, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.SetUserVerified(user.UserId); err != nil {
+		return nil, err
+	}
+	user.Verified = true
+
+	return user, nil
+}
+
+func newOIDCClients(cfg oidc.OIDCConfig) map[string]*oidc.Client {
+	providers := make(map[string]*oidc.Client, len(cfg.ProviderConfigs))
+	for name, providerCfg := range cfg.ProviderConfigs {
+		providers[name] = oidc.NewClient(providerCfg, nil)
+	}
+	return providers
+}
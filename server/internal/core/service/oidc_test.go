@@ -0,0 +1,95 @@
+package service
+
+import (
+	stderr "errors"
+	"testing"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/oidc"
+)
+
+func TestResolveOIDCUserLinksVerifiedEmail(t *testing.T) {
+	repo := newMemoryAuthRepo()
+	existing := repo.addUser("user@example.com", true)
+	s := &AuthService{repo: repo}
+
+	user, err := s.resolveOIDCUser("test", &oidc.IDTokenClaims{Subject: "sub-1", Email: "user@EXAMPLE.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UserId != existing.UserId {
+		t.Errorf("linked user %v, want existing %v", user.UserId, existing.UserId)
+	}
+
+	identity, ok := repo.identities["test/sub-1"]
+	if !ok || identity.UserId != existing.UserId || identity.Email != "user@example.com" {
+		t.Errorf("identity = %+v, want a link to %v", identity, existing.UserId)
+	}
+
+	again, err := s.resolveOIDCUser("test", &oidc.IDTokenClaims{Subject: "sub-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.UserId != existing.UserId {
+		t.Errorf("second sign-in resolved %v, want %v", again.UserId, existing.UserId)
+	}
+}
+
+func TestResolveOIDCUserCreatesVerifiedAccount(t *testing.T) {
+	repo := newMemoryAuthRepo()
+	s := &AuthService{repo: repo}
+
+	user, err := s.resolveOIDCUser("test", &oidc.IDTokenClaims{Subject: "sub-1", Email: "new@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Verified || user.Email != "new@example.com" {
+		t.Errorf("created user = %+v, want a verified new@example.com", user)
+	}
+	if user.HasPassword() {
+		t.Error("the created account has a password nobody knows")
+	}
+}
+
+func TestResolveOIDCUserRefuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		verified bool
+		claims   oidc.IDTokenClaims
+		want     error
+	}{
+		{
+			name:     "unverified provider email",
+			verified: true,
+			claims:   oidc.IDTokenClaims{Subject: "sub-1", Email: "user@example.com", EmailVerified: false},
+			want:     errors.ErrOIDCEmailNotVerified,
+		},
+		{
+			name:     "invalid provider email",
+			verified: true,
+			claims:   oidc.IDTokenClaims{Subject: "sub-1", Email: "not-an-email", EmailVerified: true},
+			want:     errors.ErrOIDCEmailNotVerified,
+		},
+		{
+			name:     "unverified local account",
+			verified: false,
+			claims:   oidc.IDTokenClaims{Subject: "sub-1", Email: "user@example.com", EmailVerified: true},
+			want:     errors.ErrOIDCAccountNotVerified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryAuthRepo()
+			repo.addUser("user@example.com", tt.verified)
+			s := &AuthService{repo: repo}
+
+			if _, err := s.resolveOIDCUser("test", &tt.claims); !stderr.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if len(repo.identities) != 0 {
+				t.Errorf("identity linked despite refusal: %v", repo.identities)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/signer"
+)
+
+type PaginationConfig struct {
+	PageSizeDefault int64 `env:"PAGE_SIZE_DEFAULT" env-default:"20" mapstructure:"PAGE_SIZE_DEFAULT"`
+	PageSizeMax     int64 `env:"PAGE_SIZE_MAX" env-default:"100" mapstructure:"PAGE_SIZE_MAX"`
+}
+
+const cursorPurpose = "page-cursor"
+
+const (
+	userPostsCursor    = "user_posts"
+	nearbyPostsCursor  = "nearby_posts"
+	feedPostsCursor    = "feed_posts"
+	bboxPostsCursor    = "bbox_posts"
+	userMessagesCursor = "user_messages"
+	postMessagesCursor = "post_messages"
+)
+
+// Paginator signs keyset cursors together with the listing they were issued
+// for, so a cursor cannot be replayed against another query.
+type Paginator struct {
+	signer *signer.Signer
+	cfg    PaginationConfig
+}
+
+type cursorPayload struct {
+	Kind   string              `json:"k"`
+	Scope  string              `json:"s"`
+	Cursor entities.PageCursor `json:"c"`
+}
+
+func NewPaginator(secret string, cfg PaginationConfig) *Paginator {
+	return &Paginator{signer: signer.New(secret, cursorPurpose), cfg: cfg}
+}
+
+func (p *Paginator) Limit(count int64) int64 {
+	if count < 1 {
+		return p.cfg.PageSizeDefault
+	}
+	return min(count, p.cfg.PageSizeMax)
+}
+
+func (p *Paginator) Encode(kind, scope string, cursor *entities.PageCursor) (string, error) {
+	if cursor == nil {
+		return "", nil
+	}
+	return p.signer.Encode(cursorPayload{Kind: kind, Scope: scope, Cursor: *cursor})
+}
+
+func (p *Paginator) Decode(kind, scope, token string) (*entities.PageCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	var payload cursorPayload
+	if err := p.signer.Decode(token, &payload); err != nil {
+		return nil, errors.ErrInvalidCursor
+	}
+	if payload.Kind != kind || payload.Scope != scope {
+		return nil, errors.ErrInvalidCursor
+	}
+
+	return &payload.Cursor, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	stderr "errors"
+	"testing"
+	"time"
+
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+func newTestPaginator() *Paginator {
+	return NewPaginator("secret", PaginationConfig{PageSizeDefault: 20, PageSizeMax: 100})
+}
+
+func TestPaginatorLimit(t *testing.T) {
+	paginator := newTestPaginator()
+
+	tests := map[int64]int64{-1: 20, 0: 20, 1: 1, 50: 50, 100: 100, 101: 100}
+	for count, want := range tests {
+		if got := paginator.Limit(count); got != want {
+			t.Errorf("Limit(%d) = %d, want %d", count, got, want)
+		}
+	}
+}
+
+func TestPaginatorRoundTrip(t *testing.T) {
+	paginator := newTestPaginator()
+	cursor := &entities.PageCursor{
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Distance:  1.5,
+		Id:        "0b8a6f47-5c1e-4f4e-9c55-1a2b3c4d5e6f",
+	}
+
+	token, err := paginator.Encode(nearbyPostsCursor, "55.75,37.62,5", cursor)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	got, err := paginator.Decode(nearbyPostsCursor, "55.75,37.62,5", token)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !got.CreatedAt.Equal(cursor.CreatedAt) || got.Distance != cursor.Distance || got.Id != cursor.Id {
+		t.Errorf("Decode() = %+v, want %+v", got, cursor)
+	}
+}
+
+func TestPaginatorEmptyCursor(t *testing.T) {
+	paginator := newTestPaginator()
+
+	token, err := paginator.Encode(userPostsCursor, "scope", nil)
+	if err != nil || token != "" {
+		t.Errorf("Encode(nil) = %q, %v, want an empty token", token, err)
+	}
+
+	cursor, err := paginator.Decode(userPostsCursor, "scope", "")
+	if err != nil || cursor != nil {
+		t.Errorf("Decode(\"\") = %+v, %v, want the first page", cursor, err)
+	}
+}
+
+func TestPaginatorRejectsForeignCursors(t *testing.T) {
+	paginator := newTestPaginator()
+
+	token, err := paginator.Encode(userPostsCursor, "scope", &entities.PageCursor{Id: "id"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		paginator *Paginator
+		kind      string
+		scope     string
+		token     string
+	}{
+		{"other kind", paginator, postMessagesCursor, "scope", token},
+		{"other scope", paginator, userPostsCursor, "other", token},
+		{"other secret", NewPaginator("other", PaginationConfig{}), userPostsCursor, "scope", token},
+		{"garbage", paginator, userPostsCursor, "scope", "not-a-cursor"},
+		{"truncated", paginator, userPostsCursor, "scope", token[:len(token)-1]},
+	}
+
+	for _, tt := range tests {
+		if _, err := tt.paginator.Decode(tt.kind, tt.scope, tt.token); !stderr.Is(err, errors.ErrInvalidCursor) {
+			t.Errorf("%s: Decode() error = %v, want %v", tt.name, err, errors.ErrInvalidCursor)
+		}
+	}
+}
@@ -1,36 +1,126 @@
 package service
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/skrpld/NearBeee/internal/core/models/dto"
 	"github.com/skrpld/NearBeee/internal/core/models/entities"
 	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/geo"
+	"github.com/skrpld/NearBeee/pkg/utils/geocoder"
+	"github.com/skrpld/NearBeee/pkg/utils/geohash"
 
 	"github.com/google/uuid"
 )
 
+type PostsConfig struct {
+	SearchRadiusMin float64 `env:"SEARCH_RADIUS_MIN_KM" env-default:"0.1" mapstructure:"SEARCH_RADIUS_MIN_KM"`
+	SearchRadiusMax float64 `env:"SEARCH_RADIUS_MAX_KM" env-default:"50" mapstructure:"SEARCH_RADIUS_MAX_KM"`
+
+	ClusterMaxZoom   int           `env:"CLUSTER_MAX_ZOOM" env-default:"16" mapstructure:"CLUSTER_MAX_ZOOM"`
+	ClusterPostLimit int64         `env:"CLUSTER_POST_LIMIT" env-default:"500" mapstructure:"CLUSTER_POST_LIMIT"`
+	ClusterCacheTTL  time.Duration `env:"CLUSTER_CACHE_TTL" env-default:"1m" mapstructure:"CLUSTER_CACHE_TTL"`
+	ClusterCacheSize int           `env:"CLUSTER_CACHE_SIZE" env-default:"10000" mapstructure:"CLUSTER_CACHE_SIZE"`
+
+	TilePostLimit int64         `env:"TILE_POST_LIMIT" env-default:"1000" mapstructure:"TILE_POST_LIMIT"`
+	TileMaxAge    time.Duration `env:"TILE_MAX_AGE" env-default:"1m" mapstructure:"TILE_MAX_AGE"`
+
+	ExportPostLimit int64 `env:"EXPORT_POST_LIMIT" env-default:"50000" mapstructure:"EXPORT_POST_LIMIT"`
+
+	FeedDistanceWeight   float64       `env:"FEED_DISTANCE_WEIGHT" env-default:"1" mapstructure:"FEED_DISTANCE_WEIGHT"`
+	FeedRecencyWeight    float64       `env:"FEED_RECENCY_WEIGHT" env-default:"1" mapstructure:"FEED_RECENCY_WEIGHT"`
+	FeedEngagementWeight float64       `env:"FEED_ENGAGEMENT_WEIGHT" env-default:"0.25" mapstructure:"FEED_ENGAGEMENT_WEIGHT"`
+	FeedDistanceHalfLife float64       `env:"FEED_DISTANCE_HALF_LIFE_KM" env-default:"1" mapstructure:"FEED_DISTANCE_HALF_LIFE_KM"`
+	FeedRecencyHalfLife  time.Duration `env:"FEED_RECENCY_HALF_LIFE" env-default:"24h" mapstructure:"FEED_RECENCY_HALF_LIFE"`
+
+	DefaultPrecision entities.LocationPrecision `env:"POST_DEFAULT_PRECISION" env-default:"exact" mapstructure:"POST_DEFAULT_PRECISION"`
+}
+
 type PostsRepository interface {
-	CreatePost(userId uuid.UUID, title, content, idempotencyKey string, latitude, longitude float64) (*entities.Post, error)
-	GetPostsByUserId(userId uuid.UUID, count int64) ([]*entities.Post, error)
-	GetPostsByLocation(latitude, longitude, radius float64, count int64) ([]*entities.Post, error)
-	GetPostByPostId(postId uuid.UUID) (*entities.Post, error)
+	CreatePost(post *entities.Post, exact geo.Coordinate) (*entities.Post, error)
+	GetPostsByUserId(userId, viewerId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error)
+	GetPostsByLocation(latitude, longitude, radius float64, viewerId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error)
+	GetPostsFeed(latitude, longitude, radius float64, scoring entities.FeedScoring, asOf time.Time, viewerId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error)
+	GetPostsByBBox(box geo.BBox, order entities.PostOrder, viewerId uuid.UUID, after *entities.PageCursor, limit int64) ([]*entities.Post, *entities.PageCursor, error)
+	GetPostClusters(cells []string, precision, samples int) ([]*entities.PostCluster, error)
+	EachPostByLocation(latitude, longitude, radius float64, viewerId uuid.UUID, limit int64, fn func(*entities.Post) error) error
+	EachPostInBBox(box geo.BBox, order entities.PostOrder, viewerId uuid.UUID, limit int64, fn func(*entities.Post) error) error
+	GetPostByPostId(postId, viewerId uuid.UUID) (*entities.Post, error)
 	UpdatePostById(title, content string, postId, userId uuid.UUID) (*entities.Post, error)
-	DeletePostById(postId, userId uuid.UUID) error
+	DeletePostById(postId, userId uuid.UUID) (*entities.Post, error)
 }
 
 type PostsService struct {
-	repo PostsRepository
+	repo      PostsRepository
+	authors   *AuthorResolver
+	paginator *Paginator
+	geocoder  *geocoder.Geocoder
+	clusters  *ClusterCache
+	cfg       PostsConfig
 }
 
-func NewPostsService(repo PostsRepository) *PostsService {
-	return &PostsService{repo: repo}
+func NewPostsService(repo PostsRepository, authors *AuthorResolver, paginator *Paginator, geocoder *geocoder.Geocoder, clusters *ClusterCache, cfg PostsConfig) *PostsService {
+	return &PostsService{
+		repo:      repo,
+		authors:   authors,
+		paginator: paginator,
+		geocoder:  geocoder,
+		clusters:  clusters,
+		cfg:       cfg,
+	}
 }
 
 func (s *PostsService) CreatePost(rows *dto.CreatePostRequest) (*dto.CreatePostResponse, error) {
-	post, err := s.repo.CreatePost(rows.UserId, rows.Title, rows.Content, rows.IdempotencyKey, rows.Latitude, rows.Longitude)
+	if rows.Precision == "" {
+		rows.Precision = s.cfg.DefaultPrecision
+	}
+
+	fields := rows.Coordinate.Validate()
+	if !rows.Precision.IsValid() {
+		fields["precision"] = fmt.Sprintf("must be one of %s, %s, %s, %s", entities.PrecisionExact,
+			entities.PrecisionStreet, entities.PrecisionNeighbourhood, entities.PrecisionCity)
+	}
+	if err := fields.AsError(); err != nil {
+		return nil, err
+	}
+
+	coordinate, err := rows.Coordinate.Normalize()
 	if err != nil {
 		return nil, err
 	}
 
+	newPost := &entities.Post{
+		PostId:         uuid.New(),
+		UserId:         rows.UserId,
+		Title:          rows.Title,
+		Content:        rows.Content,
+		IdempotencyKey: rows.IdempotencyKey,
+		Latitude:       coordinate.Latitude,
+		Longitude:      coordinate.Longitude,
+		Precision:      rows.Precision,
+	}
+
+	// The public point is confined to the precision's geohash cell, which is
+	// what every spatial query filters on. The jitter inside the cell is seeded
+	// by the post id so it never changes.
+	if cell := rows.Precision.GeohashPrecision(); cell > 0 {
+		newPost.Latitude, newPost.Longitude = geohash.Jitter(coordinate.Latitude, coordinate.Longitude, cell, newPost.PostId[:])
+	}
+
+	// Geocoding the public location keeps the place names no finer than the
+	// post's precision.
+	if place, _, ok := s.geocoder.Reverse(newPost.Latitude, newPost.Longitude); ok {
+		newPost.Country, newPost.Region, newPost.Locality = place.Country, place.Region, place.Locality
+	}
+
+	post, err := s.repo.CreatePost(newPost, coordinate)
+	if err != nil {
+		return nil, err
+	}
+
+	s.clusters.Invalidate(newPost.Latitude, newPost.Longitude)
+
 	response := dto.CreatePostResponse{
 		PostId: post.PostId.String(),
 	}
@@ -39,26 +129,107 @@ func (s *PostsService) CreatePost(rows *dto.CreatePostRequest) (*dto.CreatePostR
 }
 
 func (s *PostsService) GetPostsByUserId(rows *dto.GetPostsByUserIdRequest) (*dto.GetPostsByUserIdResponse, error) {
-	posts, err := s.repo.GetPostsByUserId(rows.UserId, rows.Count)
+	scope := rows.UserId.String()
+	after, err := s.paginator.Decode(userPostsCursor, scope, rows.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, next, err := s.repo.GetPostsByUserId(rows.UserId, rows.UserId, after, s.paginator.Limit(rows.Count))
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.ExpandAuthor {
+		if err = s.authors.EmbedPostAuthors(posts...); err != nil {
+			return nil, err
+		}
+	}
+
+	nextCursor, err := s.paginator.Encode(userPostsCursor, scope, next)
 	if err != nil {
 		return nil, err
 	}
 
 	response := dto.GetPostsByUserIdResponse{
-		Posts: posts,
+		Posts:      posts,
+		NextCursor: nextCursor,
+		HasMore:    next != nil,
 	}
 
 	return &response, nil
 }
 
 func (s *PostsService) GetPostsByLocation(rows *dto.GetPostsByLocationRequest) (*dto.GetPostsByLocationResponse, error) {
-	posts, err := s.repo.GetPostsByLocation(rows.Latitude, rows.Longitude, rows.Radius, rows.Count)
+	coordinate, err := s.validateLocation(rows.Coordinate, rows.Radius)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := fmt.Sprintf("%g,%g,%g", coordinate.Latitude, coordinate.Longitude, rows.Radius)
+	after, err := s.paginator.Decode(nearbyPostsCursor, scope, rows.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, next, err := s.repo.GetPostsByLocation(coordinate.Latitude, coordinate.Longitude, rows.Radius, rows.ViewerId, after, s.paginator.Limit(rows.Count))
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.ExpandAuthor {
+		if err = s.authors.EmbedPostAuthors(posts...); err != nil {
+			return nil, err
+		}
+	}
+
+	nextCursor, err := s.paginator.Encode(nearbyPostsCursor, scope, next)
 	if err != nil {
 		return nil, err
 	}
 
 	response := dto.GetPostsByLocationResponse{
-		Posts: posts,
+		Posts:      posts,
+		NextCursor: nextCursor,
+		HasMore:    next != nil,
+	}
+
+	return &response, nil
+}
+
+func (s *PostsService) GetPostsByBBox(rows *dto.GetPostsByBBoxRequest) (*dto.GetPostsByBBoxResponse, error) {
+	box, err := validateBBox(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := fmt.Sprintf("%g,%g,%g,%g,%s", box.SouthWest.Latitude, box.SouthWest.Longitude,
+		box.NorthEast.Latitude, box.NorthEast.Longitude, rows.Order)
+	after, err := s.paginator.Decode(bboxPostsCursor, scope, rows.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, next, err := s.repo.GetPostsByBBox(box, rows.Order, rows.ViewerId, after, s.paginator.Limit(rows.Count))
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.ExpandAuthor {
+		if err = s.authors.EmbedPostAuthors(posts...); err != nil {
+			return nil, err
+		}
+	}
+
+	nextCursor, err := s.paginator.Encode(bboxPostsCursor, scope, next)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.GetPostsByBBoxResponse{
+		Posts:      posts,
+		NextCursor: nextCursor,
+		HasMore:    next != nil,
 	}
 
 	return &response, nil
@@ -70,11 +241,17 @@ func (s *PostsService) GetPostByPostId(rows *dto.GetPostByPostIdRequest) (*dto.G
 		return nil, errors.ErrInvalidPostId
 	}
 
-	post, err := s.repo.GetPostByPostId(postId)
+	post, err := s.repo.GetPostByPostId(postId, rows.ViewerId)
 	if err != nil {
 		return nil, err
 	}
 
+	if rows.ExpandAuthor {
+		if err = s.authors.EmbedPostAuthors(post); err != nil {
+			return nil, err
+		}
+	}
+
 	response := dto.GetPostByPostIdResponse{
 		Post: post,
 	}
@@ -106,14 +283,48 @@ func (s *PostsService) DeletePostById(rows *dto.DeletePostByIdRequest) (*dto.Del
 		return nil, errors.ErrInvalidPostId
 	}
 
-	err = s.repo.DeletePostById(postId, rows.UserId)
+	post, err := s.repo.DeletePostById(postId, rows.UserId)
 	if err != nil {
 		return nil, err
 	}
 
+	s.clusters.Invalidate(post.Latitude, post.Longitude)
+
 	response := dto.DeletePostResponse{
 		PostId: rows.PostId,
 	}
 
 	return &response, nil
 }
+
+func (s *PostsService) isValidRadius(radius float64) bool {
+	return geo.IsFinite(radius) && radius >= s.cfg.SearchRadiusMin && radius <= s.cfg.SearchRadiusMax
+}
+
+func (s *PostsService) validateLocation(coordinate geo.Coordinate, radius float64) (geo.Coordinate, error) {
+	fields := coordinate.Validate()
+	if !s.isValidRadius(radius) {
+		fields["radius"] = fmt.Sprintf("must be between %g and %g km", s.cfg.SearchRadiusMin, s.cfg.SearchRadiusMax)
+	}
+	if err := fields.AsError(); err != nil {
+		return geo.Coordinate{}, err
+	}
+
+	return coordinate.Normalize()
+}
+
+func validateBBox(rows *dto.GetPostsByBBoxRequest) (geo.BBox, error) {
+	if rows.Order == "" {
+		rows.Order = entities.PostOrderRecent
+	}
+
+	fields := rows.BBox.Validate()
+	if !rows.Order.IsValid() {
+		fields["order"] = fmt.Sprintf("must be one of %s, %s", entities.PostOrderRecent, entities.PostOrderScore)
+	}
+	if err := fields.AsError(); err != nil {
+		return geo.BBox{}, err
+	}
+
+	return rows.BBox.Normalize()
+}
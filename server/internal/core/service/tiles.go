@@ -0,0 +1,68 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/geo"
+	"github.com/skrpld/NearBeee/pkg/mvt"
+)
+
+const (
+	postsLayerName = "posts"
+
+	// tileBuffer includes points just outside the tile so markers drawn on
+	// tile edges are not cut off.
+	tileBuffer = 64
+)
+
+func (s *PostsService) GetPostsTile(rows *dto.GetPostsTileRequest) (*dto.GetPostsTileResponse, error) {
+	if !rows.Tile.IsValid() {
+		return nil, errors.ErrInvalidTile
+	}
+
+	south, west, north, east := rows.Tile.Bounds()
+	latBuffer := (north - south) * tileBuffer / mvt.DefaultExtent
+	lonBuffer := (east - west) * tileBuffer / mvt.DefaultExtent
+
+	box := geo.BBox{
+		SouthWest: geo.Coordinate{Latitude: max(south-latBuffer, -geo.MaxLatitude), Longitude: max(west-lonBuffer, -geo.MaxLongitude)},
+		NorthEast: geo.Coordinate{Latitude: min(north+latBuffer, geo.MaxLatitude), Longitude: min(east+lonBuffer, geo.MaxLongitude)},
+	}
+
+	posts, _, err := s.repo.GetPostsByBBox(box, entities.PostOrderScore, uuid.Nil, nil, s.cfg.TilePostLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	layer := mvt.NewLayer(postsLayerName, mvt.DefaultExtent)
+	for _, post := range posts {
+		x, y := rows.Tile.Project(post.Latitude, post.Longitude, mvt.DefaultExtent)
+		layer.AddPoint(0, x, y,
+			mvt.Property{Key: "post_id", Value: post.PostId.String()},
+			mvt.Property{Key: "title", Value: post.Title},
+			mvt.Property{Key: "created_at", Value: post.CreatedAt.UTC().Format(time.RFC3339)},
+			mvt.Property{Key: "message_count", Value: post.MessageCount},
+		)
+	}
+
+	body, err := mvt.Encode(layer)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(body)
+
+	response := dto.GetPostsTileResponse{
+		Body:   body,
+		ETag:   `"` + hex.EncodeToString(sum[:16]) + `"`,
+		MaxAge: s.cfg.TileMaxAge,
+	}
+
+	return &response, nil
+}
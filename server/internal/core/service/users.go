@@ -0,0 +1,170 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/storage"
+)
+
+type ProfileConfig struct {
+	AvatarMaxSize int64 `env:"AVATAR_MAX_SIZE" env-default:"2097152" mapstructure:"AVATAR_MAX_SIZE"`
+}
+
+const (
+	displayNameMaxLength = 64
+	bioMaxLength         = 500
+)
+
+var (
+	handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,32}$`)
+
+	avatarExtensions = map[string]string{
+		"image/png":  ".png",
+		"image/jpeg": ".jpg",
+		"image/gif":  ".gif",
+		"image/webp": ".webp",
+	}
+)
+
+type UsersRepository interface {
+	GetProfileByUserId(userId uuid.UUID) (*entities.Profile, error)
+	GetProfileByHandle(handle string) (*entities.Profile, error)
+	UpdateProfile(userId uuid.UUID, handle, displayName, bio string) (*entities.Profile, error)
+	SetProfileAvatar(userId uuid.UUID, avatarKey string) (*string, error)
+}
+
+type UsersService struct {
+	repo    UsersRepository
+	storage storage.Storage
+	cfg     ProfileConfig
+}
+
+func NewUsersService(repo UsersRepository, storage storage.Storage, cfg ProfileConfig) *UsersService {
+	return &UsersService{repo: repo, storage: storage, cfg: cfg}
+}
+
+func (s *UsersService) GetMyProfile(rows *dto.GetMyProfileRequest) (*dto.GetMyProfileResponse, error) {
+	profile, err := s.repo.GetProfileByUserId(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.GetMyProfileResponse{
+		Profile: s.withAvatarUrl(profile),
+	}
+
+	return &response, nil
+}
+
+func (s *UsersService) UpdateMyProfile(rows *dto.UpdateMyProfileRequest) (*dto.UpdateMyProfileResponse, error) {
+	profile, err := s.repo.GetProfileByUserId(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, displayName, bio := profile.Handle, profile.DisplayName, profile.Bio
+	if rows.Handle != nil {
+		handle = strings.ToLower(strings.TrimSpace(*rows.Handle))
+	}
+	if rows.DisplayName != nil {
+		displayName = strings.TrimSpace(*rows.DisplayName)
+	}
+	if rows.Bio != nil {
+		bio = strings.TrimSpace(*rows.Bio)
+	}
+
+	if !handlePattern.MatchString(handle) {
+		return nil, errors.ErrInvalidHandle
+	}
+	if utf8.RuneCountInString(displayName) > displayNameMaxLength || utf8.RuneCountInString(bio) > bioMaxLength {
+		return nil, errors.ErrInvalidProfile
+	}
+
+	profile, err = s.repo.UpdateProfile(rows.UserId, handle, displayName, bio)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.UpdateMyProfileResponse{
+		Profile: s.withAvatarUrl(profile),
+	}
+
+	return &response, nil
+}
+
+func (s *UsersService) UpdateAvatar(rows *dto.UpdateAvatarRequest) (*dto.UpdateAvatarResponse, error) {
+	data, err := io.ReadAll(io.LimitReader(rows.Body, s.cfg.AvatarMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > s.cfg.AvatarMaxSize {
+		return nil, errors.ErrAvatarTooLarge
+	}
+
+	ext, ok := avatarExtensions[http.DetectContentType(data)]
+	if !ok {
+		return nil, errors.ErrInvalidAvatar
+	}
+
+	key := fmt.Sprintf("avatars/%s/%s%s", rows.UserId, uuid.NewString(), ext)
+	if err = s.storage.Save(key, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	previousKey, err := s.repo.SetProfileAvatar(rows.UserId, key)
+	if err != nil {
+		_ = s.storage.Delete(key)
+		return nil, err
+	}
+
+	if previousKey != nil {
+		_ = s.storage.Delete(*previousKey)
+	}
+
+	profile, err := s.repo.GetProfileByUserId(rows.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.UpdateAvatarResponse{
+		Profile: s.withAvatarUrl(profile),
+	}
+
+	return &response, nil
+}
+
+func (s *UsersService) GetProfileByHandle(rows *dto.GetProfileByHandleRequest) (*dto.GetProfileByHandleResponse, error) {
+	profile, err := s.repo.GetProfileByHandle(strings.ToLower(rows.Handle))
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.GetProfileByHandleResponse{
+		Profile: s.withAvatarUrl(profile),
+	}
+
+	return &response, nil
+}
+
+func (s *UsersService) withAvatarUrl(profile *entities.Profile) *entities.Profile {
+	profile.AvatarUrl = avatarUrl(s.storage, profile.AvatarKey)
+	return profile
+}
+
+func avatarUrl(storage storage.Storage, avatarKey *string) string {
+	if avatarKey == nil {
+		return ""
+	}
+	return storage.URL(*avatarKey)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+type AdminService interface {
+	SearchUsers(rows *dto.SearchUsersRequest) (*dto.SearchUsersResponse, error)
+	BanUser(rows *dto.BanUserRequest) (*dto.BanUserResponse, error)
+	UnbanUser(rows *dto.UnbanUserRequest) (*dto.UnbanUserResponse, error)
+	SetUserRole(rows *dto.SetUserRoleRequest) (*dto.SetUserRoleResponse, error)
+	ForceDeletePost(ctx context.Context, rows *dto.ForceDeletePostRequest) (*dto.ForceDeletePostResponse, error)
+	ForceDeleteMessage(ctx context.Context, rows *dto.ForceDeleteMessageRequest) (*dto.ForceDeleteMessageResponse, error)
+}
+
+type AdminController struct {
+	adminSrv AdminService
+}
+
+func NewAdminController(adminSrv AdminService) *AdminController {
+	return &AdminController{adminSrv: adminSrv}
+}
+
+func (c *AdminController) SearchUsers(r *http.Request) (any, error) {
+	var request dto.SearchUsersRequest
+
+	request.Query = r.FormValue("q")
+	request.Role = entities.Role(r.FormValue("role"))
+
+	var err error
+	if request.Count, err = parseQueryInt(r, "count"); err != nil {
+		return nil, err
+	}
+	if request.Offset, err = parseQueryInt(r, "offset"); err != nil {
+		return nil, err
+	}
+
+	return c.adminSrv.SearchUsers(&request)
+}
+
+func (c *AdminController) BanUser(r *http.Request) (any, error) {
+	var request dto.BanUserRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.ActorId = user.UserId
+	request.ActorRole = user.Role
+	request.UserId = r.PathValue(web.UserPathValue)
+
+	return c.adminSrv.BanUser(&request)
+}
+
+func (c *AdminController) UnbanUser(r *http.Request) (any, error) {
+	var request dto.UnbanUserRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.ActorId = user.UserId
+	request.ActorRole = user.Role
+	request.UserId = r.PathValue(web.UserPathValue)
+
+	return c.adminSrv.UnbanUser(&request)
+}
+
+func (c *AdminController) SetUserRole(r *http.Request) (any, error) {
+	var request dto.SetUserRoleRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.ActorId = user.UserId
+	request.ActorRole = user.Role
+	request.UserId = r.PathValue(web.UserPathValue)
+
+	return c.adminSrv.SetUserRole(&request)
+}
+
+func (c *AdminController) ForceDeletePost(r *http.Request) (any, error) {
+	var request dto.ForceDeletePostRequest
+
+	request.PostId = r.PathValue(web.PostPathValue)
+
+	return c.adminSrv.ForceDeletePost(r.Context(), &request)
+}
+
+func (c *AdminController) ForceDeleteMessage(r *http.Request) (any, error) {
+	var request dto.ForceDeleteMessageRequest
+
+	request.MessageId = r.PathValue(web.MsgPathValue)
+
+	return c.adminSrv.ForceDeleteMessage(r.Context(), &request)
+}
+
+func parseQueryInt(r *http.Request, key string) (int64, error) {
+	value := r.FormValue(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errors.ErrInvalidQuery
+	}
+	return parsed, nil
+}
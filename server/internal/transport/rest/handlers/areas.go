@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+)
+
+type AreasService interface {
+	CreateArea(rows *dto.CreateAreaRequest) (*dto.CreateAreaResponse, error)
+	GetArea(rows *dto.GetAreaRequest) (*dto.GetAreaResponse, error)
+	DeleteArea(rows *dto.DeleteAreaRequest) (*dto.DeleteAreaResponse, error)
+	GetAreaPosts(rows *dto.GetAreaPostsRequest) (*dto.GetAreaPostsResponse, error)
+}
+
+type AreasController struct {
+	areasSrv AreasService
+}
+
+func NewAreasController(areasSrv AreasService) *AreasController {
+	return &AreasController{areasSrv: areasSrv}
+}
+
+func (c *AreasController) CreateArea(r *http.Request) (any, error) {
+	var request dto.CreateAreaRequest
+	err := web.DecodeJSON(r, &request)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.areasSrv.CreateArea(&request)
+}
+
+func (c *AreasController) GetArea(r *http.Request) (any, error) {
+	var request dto.GetAreaRequest
+
+	request.Slug = r.PathValue(web.AreaPathValue)
+
+	return c.areasSrv.GetArea(&request)
+}
+
+func (c *AreasController) DeleteArea(r *http.Request) (any, error) {
+	var request dto.DeleteAreaRequest
+
+	request.Slug = r.PathValue(web.AreaPathValue)
+
+	return c.areasSrv.DeleteArea(&request)
+}
+
+func (c *AreasController) GetAreaPosts(r *http.Request) (any, error) {
+	var request dto.GetAreaPostsRequest
+	err := web.DecodeJSON(r, &request)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Slug = r.PathValue(web.AreaPathValue)
+	request.ExpandAuthor = web.HasExpand(r, web.AuthorExpand)
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.ViewerId = user.UserId
+
+	return c.areasSrv.GetAreaPosts(&request)
+}
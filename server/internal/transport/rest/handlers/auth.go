@@ -2,15 +2,36 @@ package handlers
 
 import (
 	"encoding/json"
+	stderr "errors"
+	"io"
 	"net/http"
 
 	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
 )
 
 type AuthService interface {
 	RegistrateUser(rows *dto.RegistrateUserRequest) (*dto.RegistrateUserResponse, error)
 	LoginUser(rows *dto.LoginUserRequest) (*dto.LoginUserResponse, error)
+	VerifyTwoFactor(rows *dto.VerifyTwoFactorRequest) (*dto.LoginUserResponse, error)
+	SetupTwoFactor(rows *dto.SetupTwoFactorRequest) (*dto.SetupTwoFactorResponse, error)
+	ConfirmTwoFactor(rows *dto.ConfirmTwoFactorRequest) (*dto.ConfirmTwoFactorResponse, error)
+	DisableTwoFactor(rows *dto.DisableTwoFactorRequest) (*dto.DisableTwoFactorResponse, error)
+	StartOIDC(rows *dto.StartOIDCRequest) (*dto.StartOIDCResponse, error)
+	OIDCCallback(rows *dto.OIDCCallbackRequest) (*dto.LoginUserResponse, error)
+	CreateApiKey(rows *dto.CreateApiKeyRequest) (*dto.CreateApiKeyResponse, error)
+	GetApiKeys(rows *dto.GetApiKeysRequest) (*dto.GetApiKeysResponse, error)
+	RevokeApiKey(rows *dto.RevokeApiKeyRequest) (*dto.RevokeApiKeyResponse, error)
 	RefreshUserToken(rows *dto.RefreshUserTokenRequest) (*dto.RefreshUserTokenResponse, error)
+	VerifyEmail(rows *dto.VerifyEmailRequest) (*dto.VerifyEmailResponse, error)
+	ResendVerification(rows *dto.ResendVerificationRequest) (*dto.ResendVerificationResponse, error)
+	ForgotPassword(rows *dto.ForgotPasswordRequest) (*dto.ForgotPasswordResponse, error)
+	ResetPassword(rows *dto.ResetPasswordRequest) (*dto.ResetPasswordResponse, error)
+	ChangePassword(rows *dto.ChangePasswordRequest) (*dto.ChangePasswordResponse, error)
+	GetSessions(rows *dto.GetSessionsRequest) (*dto.GetSessionsResponse, error)
+	RevokeSession(rows *dto.RevokeSessionRequest) (*dto.RevokeSessionResponse, error)
+	LogoutUser(rows *dto.LogoutUserRequest) (*dto.LogoutUserResponse, error)
+	LogoutAll(rows *dto.LogoutAllRequest) (*dto.LogoutAllResponse, error)
 }
 
 type AuthController struct {
@@ -28,6 +49,9 @@ func (c *AuthController) RegistrateUserHandler(r *http.Request) (any, error) {
 		return nil, err
 	}
 
+	request.UserAgent = r.UserAgent()
+	request.IpAddress = web.GetClientIp(r)
+
 	return c.authService.RegistrateUser(&request)
 }
 
@@ -38,15 +62,279 @@ func (c *AuthController) LoginUserHandler(r *http.Request) (any, error) {
 		return nil, err
 	}
 
+	request.UserAgent = r.UserAgent()
+	request.IpAddress = web.GetClientIp(r)
+
 	return c.authService.LoginUser(&request)
 }
 
+func (c *AuthController) VerifyTwoFactorHandler(r *http.Request) (any, error) {
+	var request dto.VerifyTwoFactorRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserAgent = r.UserAgent()
+	request.IpAddress = web.GetClientIp(r)
+
+	return c.authService.VerifyTwoFactor(&request)
+}
+
+func (c *AuthController) SetupTwoFactorHandler(r *http.Request) (any, error) {
+	var request dto.SetupTwoFactorRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+
+	return c.authService.SetupTwoFactor(&request)
+}
+
+func (c *AuthController) ConfirmTwoFactorHandler(r *http.Request) (any, error) {
+	var request dto.ConfirmTwoFactorRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+
+	return c.authService.ConfirmTwoFactor(&request)
+}
+
+func (c *AuthController) DisableTwoFactorHandler(r *http.Request) (any, error) {
+	var request dto.DisableTwoFactorRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+
+	return c.authService.DisableTwoFactor(&request)
+}
+
 func (c *AuthController) RefreshUserTokenHandler(r *http.Request) (any, error) {
 	var request dto.RefreshUserTokenRequest
 	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil {
+	if err != nil && !stderr.Is(err, io.EOF) {
 		return nil, err
 	}
 
+	if request.RefreshToken == "" {
+		request.RefreshToken = web.GetCookieValue(r, web.RefreshTokenCookie)
+		if request.RefreshToken != "" {
+			if err = web.CheckCSRF(r); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return c.authService.RefreshUserToken(&request)
 }
+
+func (c *AuthController) VerifyEmailHandler(r *http.Request) (any, error) {
+	var request dto.VerifyEmailRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.authService.VerifyEmail(&request)
+}
+
+func (c *AuthController) ResendVerificationHandler(r *http.Request) (any, error) {
+	var request dto.ResendVerificationRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+
+	return c.authService.ResendVerification(&request)
+}
+
+func (c *AuthController) ForgotPasswordHandler(r *http.Request) (any, error) {
+	var request dto.ForgotPasswordRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.authService.ForgotPassword(&request)
+}
+
+func (c *AuthController) ResetPasswordHandler(r *http.Request) (any, error) {
+	var request dto.ResetPasswordRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.authService.ResetPassword(&request)
+}
+
+func (c *AuthController) ChangePasswordHandler(r *http.Request) (any, error) {
+	var request dto.ChangePasswordRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := web.GetSessionFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+	request.Session = session
+
+	return c.authService.ChangePassword(&request)
+}
+
+func (c *AuthController) GetSessionsHandler(r *http.Request) (any, error) {
+	var request dto.GetSessionsRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := web.GetSessionFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+	request.SessionId = session.SessionId
+
+	return c.authService.GetSessions(&request)
+}
+
+func (c *AuthController) RevokeSessionHandler(r *http.Request) (any, error) {
+	var request dto.RevokeSessionRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+	request.SessionId = r.PathValue(web.SessionPathValue)
+
+	return c.authService.RevokeSession(&request)
+}
+
+func (c *AuthController) LogoutUserHandler(r *http.Request) (any, error) {
+	var request dto.LogoutUserRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := web.GetSessionFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+	request.SessionId = session.SessionId
+
+	response, err := c.authService.LogoutUser(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return withExpiredAuthCookies(r, response), nil
+}
+
+func (c *AuthController) LogoutAllHandler(r *http.Request) (any, error) {
+	var request dto.LogoutAllRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+
+	response, err := c.authService.LogoutAll(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return withExpiredAuthCookies(r, response), nil
+}
+
+func withExpiredAuthCookies(r *http.Request, data any) any {
+	if !web.IsCookieAuthMode(r) {
+		return data
+	}
+	return &web.WithCookies{Data: data, Cookies: web.ExpiredAuthCookies()}
+}
+
+func (c *AuthController) CreateApiKeyHandler(r *http.Request) (any, error) {
+	var request dto.CreateApiKeyRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+
+	return c.authService.CreateApiKey(&request)
+}
+
+func (c *AuthController) GetApiKeysHandler(r *http.Request) (any, error) {
+	var request dto.GetApiKeysRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+
+	return c.authService.GetApiKeys(&request)
+}
+
+func (c *AuthController) RevokeApiKeyHandler(r *http.Request) (any, error) {
+	var request dto.RevokeApiKeyRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+	request.KeyId = r.PathValue(web.ApiKeyPathValue)
+
+	return c.authService.RevokeApiKey(&request)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+type GeoService interface {
+	ReverseGeocode(rows *dto.ReverseGeocodeRequest) (*dto.ReverseGeocodeResponse, error)
+}
+
+type GeoController struct {
+	geoSrv GeoService
+}
+
+func NewGeoController(geoSrv GeoService) *GeoController {
+	return &GeoController{geoSrv: geoSrv}
+}
+
+func (c *GeoController) ReverseGeocode(r *http.Request) (any, error) {
+	var request dto.ReverseGeocodeRequest
+	query := r.URL.Query()
+	fields := errors.FieldErrors{}
+
+	var err error
+	if request.Latitude, err = strconv.ParseFloat(query.Get(web.LatQuery), 64); err != nil {
+		fields[web.LatQuery] = "must be a number"
+	}
+	if request.Longitude, err = strconv.ParseFloat(query.Get(web.LonQuery), 64); err != nil {
+		fields[web.LonQuery] = "must be a number"
+	}
+
+	if err = fields.AsError(); err != nil {
+		return nil, err
+	}
+
+	return c.geoSrv.ReverseGeocode(&request)
+}
@@ -0,0 +1,11 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/skrpld/NearBeee/pkg/utils/jwt"
+)
+
+func JWKSHandler(r *http.Request) (any, error) {
+	return jwt.GetJWKS(), nil
+}
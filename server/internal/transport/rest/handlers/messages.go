@@ -61,13 +61,14 @@ func (c *MessagesController) GetMessageByMessageId(r *http.Request) (any, error)
 	var request dto.GetMessageByMessageIdRequest
 
 	request.MessageId = r.PathValue(web.MsgPathValue)
+	request.ExpandAuthor = web.HasExpand(r, web.AuthorExpand)
 
 	return c.messagesSrv.GetMessageByMessageId(r.Context(), &request)
 }
 
 func (c *MessagesController) GetMessageByUserId(r *http.Request) (any, error) {
 	var request dto.GetMessageByUserIdRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := web.DecodeJSON(r, &request)
 	if err != nil {
 		return nil, err
 	}
@@ -78,17 +79,20 @@ func (c *MessagesController) GetMessageByUserId(r *http.Request) (any, error) {
 	}
 
 	request.UserId = user.UserId
+	request.ExpandAuthor = web.HasExpand(r, web.AuthorExpand)
 
 	return c.messagesSrv.GetMessageByUserId(r.Context(), &request)
 }
 
 func (c *MessagesController) GetMessagesByPostId(r *http.Request) (any, error) {
 	var request dto.GetMessagesByPostIdRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := web.DecodeJSON(r, &request)
 	if err != nil {
 		return nil, err
 	}
 
+	request.ExpandAuthor = web.HasExpand(r, web.AuthorExpand)
+
 	return c.messagesSrv.GetMessagesByPostId(r.Context(), &request)
 }
 
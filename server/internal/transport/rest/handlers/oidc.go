@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+)
+
+func (c *AuthController) StartOIDCHandler(r *http.Request) (any, error) {
+	request := dto.StartOIDCRequest{
+		Provider:   r.PathValue(web.ProviderPathValue),
+		DeviceName: r.URL.Query().Get("device_name"),
+	}
+
+	response, err := c.authService.StartOIDC(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	cookies := []*http.Cookie{
+		oidcCookie(web.OIDCStateCookie, response.State, response.ExpiresAt, response.Secure),
+	}
+	if r.URL.Query().Get("auth_mode") == web.CookieAuthMode {
+		cookies = append(cookies, oidcCookie(web.OIDCAuthModeCookie, web.CookieAuthMode, response.ExpiresAt, response.Secure))
+	}
+
+	return &web.Redirect{Url: response.AuthUrl, Cookies: cookies}, nil
+}
+
+func (c *AuthController) OIDCCallbackHandler(r *http.Request) (any, error) {
+	query := r.URL.Query()
+
+	request := dto.OIDCCallbackRequest{
+		Provider:  r.PathValue(web.ProviderPathValue),
+		State:     query.Get("state"),
+		Code:      query.Get("code"),
+		Error:     query.Get("error"),
+		Cookie:    web.GetCookieValue(r, web.OIDCStateCookie),
+		UserAgent: r.UserAgent(),
+		IpAddress: web.GetClientIp(r),
+	}
+
+	response, err := c.authService.OIDCCallback(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	cookies := []*http.Cookie{
+		oidcCookie(web.OIDCStateCookie, "", time.Time{}, false),
+		oidcCookie(web.OIDCAuthModeCookie, "", time.Time{}, false),
+	}
+
+	if web.GetCookieValue(r, web.OIDCAuthModeCookie) == web.CookieAuthMode {
+		authCookies, err := web.AuthCookies(response.AccessToken, response.RefreshToken)
+		if err != nil {
+			return nil, err
+		}
+
+		cookies = append(cookies, authCookies...)
+		response.AccessToken, response.RefreshToken = "", ""
+	}
+
+	return &web.WithCookies{Data: response, Cookies: cookies}, nil
+}
+
+func oidcCookie(name, value string, expires time.Time, secure bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     web.OIDCStateCookiePath,
+		Expires:  expires,
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+	return cookie
+}
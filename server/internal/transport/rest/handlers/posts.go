@@ -1,18 +1,28 @@
 package handlers
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/skrpld/NearBeee/internal/core/models/dto"
 	"github.com/skrpld/NearBeee/internal/transport/rest/web"
 	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/geo"
+	"github.com/skrpld/NearBeee/pkg/mvt"
 )
 
 type PostsService interface {
 	CreatePost(rows *dto.CreatePostRequest) (*dto.CreatePostResponse, error)
 	GetPostsByUserId(rows *dto.GetPostsByUserIdRequest) (*dto.GetPostsByUserIdResponse, error)
 	GetPostsByLocation(rows *dto.GetPostsByLocationRequest) (*dto.GetPostsByLocationResponse, error)
+	GetPostsFeed(rows *dto.GetPostsFeedRequest) (*dto.GetPostsFeedResponse, error)
+	GetPostsByBBox(rows *dto.GetPostsByBBoxRequest) (*dto.GetPostsByBBoxResponse, error)
+	ExportPostsByLocation(rows *dto.GetPostsByLocationRequest) (geo.FeatureSource, error)
+	ExportPostsByBBox(rows *dto.GetPostsByBBoxRequest) (geo.FeatureSource, error)
+	GetPostClusters(rows *dto.GetPostClustersRequest) (*dto.GetPostClustersResponse, error)
+	GetPostsTile(rows *dto.GetPostsTileRequest) (*dto.GetPostsTileResponse, error)
 	GetPostByPostId(rows *dto.GetPostByPostIdRequest) (*dto.GetPostByPostIdResponse, error)
 	UpdatePostById(rows *dto.UpdatePostByIdRequest) (*dto.UpdatePostByIdResponse, error)
 	DeletePostById(rows *dto.DeletePostByIdRequest) (*dto.DeletePostResponse, error)
@@ -27,7 +37,7 @@ func NewPostsController(postsSrv PostsService) *PostsController {
 
 func (c *PostsController) CreatePostHandler(r *http.Request) (any, error) {
 	var request dto.CreatePostRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := web.DecodeJSON(r, &request)
 	if err != nil {
 		return nil, err
 	}
@@ -43,11 +53,23 @@ func (c *PostsController) CreatePostHandler(r *http.Request) (any, error) {
 }
 
 func (c *PostsController) GetPosts(r *http.Request) (any, error) {
-	switch web.FormType(r.FormValue(web.FormValue)) {
+	form := web.FormType(r.FormValue(web.FormValue))
+
+	// Only location and bbox queries come as features; the others refuse a
+	// feature format before running their query.
+	if web.ResponseType(r) != web.JSONContentType && form != web.LocationForm && form != web.BBoxForm {
+		return nil, errors.ErrNotAcceptable
+	}
+
+	switch form {
 	case web.UserForm:
 		return c.GetPostsByUserId(r)
 	case web.LocationForm:
 		return c.GetPostsByLocation(r)
+	case web.FeedForm:
+		return c.GetPostsFeed(r)
+	case web.BBoxForm:
+		return c.GetPostsByBBox(r)
 	case web.PostForm, web.NullForm:
 		return c.GetPostByPostId(r)
 	default:
@@ -57,7 +79,7 @@ func (c *PostsController) GetPosts(r *http.Request) (any, error) {
 
 func (c *PostsController) GetPostsByUserId(r *http.Request) (any, error) {
 	var request dto.GetPostsByUserIdRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := web.DecodeJSON(r, &request)
 	if err != nil {
 		return nil, err
 	}
@@ -68,35 +90,166 @@ func (c *PostsController) GetPostsByUserId(r *http.Request) (any, error) {
 	}
 
 	request.UserId = user.UserId
+	request.ExpandAuthor = web.HasExpand(r, web.AuthorExpand)
 
 	return c.postsSrv.GetPostsByUserId(&request)
 }
 
 func (c *PostsController) GetPostsByLocation(r *http.Request) (any, error) {
 	var request dto.GetPostsByLocationRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := web.DecodeJSON(r, &request)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ExpandAuthor = web.HasExpand(r, web.AuthorExpand)
+
+	user, err := web.GetUserFromCtx(r.Context())
 	if err != nil {
 		return nil, err
 	}
 
+	request.ViewerId = user.UserId
+
+	if web.ResponseType(r) != web.JSONContentType {
+		return c.postsSrv.ExportPostsByLocation(&request)
+	}
+
 	return c.postsSrv.GetPostsByLocation(&request)
 }
 
+func (c *PostsController) GetPostsFeed(r *http.Request) (any, error) {
+	var request dto.GetPostsFeedRequest
+	err := web.DecodeJSON(r, &request)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ExpandAuthor = web.HasExpand(r, web.AuthorExpand)
+	request.DebugScore = r.FormValue(web.DebugValue) == web.ScoreDebug
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.ViewerId = user.UserId
+
+	return c.postsSrv.GetPostsFeed(&request)
+}
+
+func (c *PostsController) GetPostsByBBox(r *http.Request) (any, error) {
+	var request dto.GetPostsByBBoxRequest
+	err := web.DecodeJSON(r, &request)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ExpandAuthor = web.HasExpand(r, web.AuthorExpand)
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.ViewerId = user.UserId
+
+	if web.ResponseType(r) != web.JSONContentType {
+		return c.postsSrv.ExportPostsByBBox(&request)
+	}
+
+	return c.postsSrv.GetPostsByBBox(&request)
+}
+
+func (c *PostsController) GetPostClusters(r *http.Request) (any, error) {
+	var request dto.GetPostClustersRequest
+	query := r.URL.Query()
+	fields := errors.FieldErrors{}
+
+	box, ok := geo.ParseBBox(query.Get(web.BBoxQuery))
+	if !ok {
+		fields[web.BBoxQuery] = "must be west,south,east,north"
+	}
+
+	zoom, err := strconv.Atoi(query.Get(web.ZoomQuery))
+	if err != nil {
+		fields[web.ZoomQuery] = "must be an integer"
+	}
+
+	if err = fields.AsError(); err != nil {
+		return nil, err
+	}
+
+	request.BBox = box
+	request.Zoom = zoom
+	request.ExpandAuthor = web.HasExpand(r, web.AuthorExpand)
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.ViewerId = user.UserId
+
+	return c.postsSrv.GetPostClusters(&request)
+}
+
+func (c *PostsController) GetPostsTile(r *http.Request) (any, error) {
+	var request dto.GetPostsTileRequest
+
+	rawY, ok := strings.CutSuffix(r.PathValue(web.TileYPathValue), web.MVTExtension)
+	if !ok {
+		return nil, errors.ErrInvalidTile
+	}
+
+	coordinates := make([]int, 0, 3)
+	for _, raw := range []string{r.PathValue(web.TileZPathValue), r.PathValue(web.TileXPathValue), rawY} {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, errors.ErrInvalidTile
+		}
+		coordinates = append(coordinates, value)
+	}
+
+	request.Tile = mvt.Tile{Z: coordinates[0], X: coordinates[1], Y: coordinates[2]}
+
+	response, err := c.postsSrv.GetPostsTile(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &web.Blob{
+		ContentType:  web.MVTContentType,
+		Body:         response.Body,
+		ETag:         response.ETag,
+		CacheControl: fmt.Sprintf("private, max-age=%d", int(response.MaxAge.Seconds())),
+		NotModified:  web.ETagMatches(r, response.ETag),
+	}, nil
+}
+
 func (c *PostsController) GetPostByPostId(r *http.Request) (any, error) {
 	var request dto.GetPostByPostIdRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := web.DecodeJSON(r, &request)
 	if err != nil {
 		return nil, err
 	}
 
 	request.PostId = r.PathValue(web.PostPathValue)
-	
+	request.ExpandAuthor = web.HasExpand(r, web.AuthorExpand)
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.ViewerId = user.UserId
+
 	return c.postsSrv.GetPostByPostId(&request)
 }
 
 func (c *PostsController) UpdatePostById(r *http.Request) (any, error) {
 	var request dto.UpdatePostByIdRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := web.DecodeJSON(r, &request)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +267,7 @@ func (c *PostsController) UpdatePostById(r *http.Request) (any, error) {
 
 func (c *PostsController) DeletePostById(r *http.Request) (any, error) {
 	var request dto.DeletePostByIdRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := web.DecodeJSON(r, &request)
 	if err != nil {
 		return nil, err
 	}
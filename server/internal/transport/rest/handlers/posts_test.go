@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+func TestGetPostsRefusesFeaturesBeforeQuerying(t *testing.T) {
+	// The controller has no service, so any query it ran would panic.
+	handler := web.Handle(NewPostsController(nil).GetPosts, web.GeoJSONEncoder{}, web.KMLEncoder{})
+
+	for _, form := range []web.FormType{web.UserForm, web.FeedForm, web.PostForm} {
+		r := httptest.NewRequest(http.MethodGet, "/posts/?"+web.FormValue+"="+string(form), nil)
+		r.Header.Set("Accept", web.GeoJSONContentType)
+		httpError := &errors.HttpError{}
+		r = r.WithContext(context.WithValue(r.Context(), web.CtxErrorKey, httpError))
+
+		handler(httptest.NewRecorder(), r)
+
+		if httpError.Code != http.StatusNotAcceptable {
+			t.Errorf("%s: code = %d, want %d", form, httpError.Code, http.StatusNotAcceptable)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+)
+
+type UsersService interface {
+	GetMyProfile(rows *dto.GetMyProfileRequest) (*dto.GetMyProfileResponse, error)
+	UpdateMyProfile(rows *dto.UpdateMyProfileRequest) (*dto.UpdateMyProfileResponse, error)
+	UpdateAvatar(rows *dto.UpdateAvatarRequest) (*dto.UpdateAvatarResponse, error)
+	GetProfileByHandle(rows *dto.GetProfileByHandleRequest) (*dto.GetProfileByHandleResponse, error)
+}
+
+type AccountService interface {
+	DeleteAccount(rows *dto.DeleteAccountRequest) (*dto.DeleteAccountResponse, error)
+	ExportAccount(ctx context.Context, rows *dto.ExportAccountRequest) (*dto.ExportAccountResponse, error)
+}
+
+type UsersController struct {
+	usersSrv   UsersService
+	accountSrv AccountService
+}
+
+func NewUsersController(usersSrv UsersService, accountSrv AccountService) *UsersController {
+	return &UsersController{usersSrv: usersSrv, accountSrv: accountSrv}
+}
+
+func (c *UsersController) GetMyProfile(r *http.Request) (any, error) {
+	var request dto.GetMyProfileRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+
+	return c.usersSrv.GetMyProfile(&request)
+}
+
+func (c *UsersController) UpdateMyProfile(r *http.Request) (any, error) {
+	var request dto.UpdateMyProfileRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+
+	return c.usersSrv.UpdateMyProfile(&request)
+}
+
+func (c *UsersController) UpdateAvatar(r *http.Request) (any, error) {
+	var request dto.UpdateAvatarRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+	request.Body = r.Body
+
+	return c.usersSrv.UpdateAvatar(&request)
+}
+
+func (c *UsersController) GetProfileByHandle(r *http.Request) (any, error) {
+	var request dto.GetProfileByHandleRequest
+
+	request.Handle = r.PathValue(web.HandlePathValue)
+
+	return c.usersSrv.GetProfileByHandle(&request)
+}
+
+func (c *UsersController) DeleteAccount(r *http.Request) (any, error) {
+	var request dto.DeleteAccountRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+
+	return c.accountSrv.DeleteAccount(&request)
+}
+
+func (c *UsersController) ExportAccount(r *http.Request) (any, error) {
+	var request dto.ExportAccountRequest
+
+	user, err := web.GetUserFromCtx(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	request.UserId = user.UserId
+
+	return c.accountSrv.ExportAccount(r.Context(), &request)
+}
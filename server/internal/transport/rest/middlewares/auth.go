@@ -8,10 +8,12 @@ import (
 	"github.com/skrpld/NearBeee/internal/core/models/dto"
 	"github.com/skrpld/NearBeee/internal/transport/rest/web"
 	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/apikey"
 )
 
 type AuthService interface {
 	AuthorizeUser(rows *dto.AuthorizeUserRequest) (*dto.AuthorizeUserResponse, error)
+	AuthorizeApiKey(rows *dto.AuthorizeApiKeyRequest) (*dto.AuthorizeUserResponse, error)
 }
 type AuthMiddlewareHandler struct {
 	srv AuthService
@@ -25,20 +27,22 @@ func (a *AuthMiddlewareHandler) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		httpError := web.GetHttpErrorFromCtx(r.Context())
 
-		header := r.Header.Get("Authorization")
-		tokenParts := strings.Split(header, " ")
-
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			parsedError := errors.ParseHttpError(errors.ErrInvalidToken)
+		token, fromCookie, err := a.extractToken(r)
+		if err != nil {
+			parsedError := errors.ParseHttpError(err)
 			httpError.Err = parsedError.Err
 			httpError.Code = parsedError.Code
 
 			return
 		}
 
-		token := tokenParts[1]
+		var user *dto.AuthorizeUserResponse
 
-		user, err := a.srv.AuthorizeUser(&dto.AuthorizeUserRequest{AccessToken: token})
+		if apikey.IsApiKey(token) && !fromCookie {
+			user, err = a.srv.AuthorizeApiKey(&dto.AuthorizeApiKeyRequest{Key: token})
+		} else {
+			user, err = a.srv.AuthorizeUser(&dto.AuthorizeUserRequest{AccessToken: token})
+		}
 		if err != nil {
 			parsedError := errors.ParseHttpError(err)
 			httpError.Err = parsedError.Err
@@ -48,7 +52,37 @@ func (a *AuthMiddlewareHandler) AuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		ctx := context.WithValue(r.Context(), web.CtxUserKey, user.User)
+		if user.Session != nil {
+			ctx = context.WithValue(ctx, web.CtxSessionKey, user.Session)
+		}
+		if user.ApiKey != nil {
+			ctx = context.WithValue(ctx, web.CtxApiKeyKey, user.ApiKey)
+		}
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+func (a *AuthMiddlewareHandler) extractToken(r *http.Request) (string, bool, error) {
+	header := r.Header.Get("Authorization")
+
+	if header == "" {
+		token := web.GetCookieValue(r, web.AccessTokenCookie)
+		if token == "" {
+			return "", false, errors.ErrInvalidToken
+		}
+
+		if err := web.CheckCSRF(r); err != nil {
+			return "", false, err
+		}
+
+		return token, true, nil
+	}
+
+	tokenParts := strings.Split(header, " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		return "", false, errors.ErrInvalidToken
+	}
+
+	return tokenParts[1], false, nil
+}
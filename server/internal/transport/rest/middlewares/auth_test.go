@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skrpld/NearBeee/internal/core/models/dto"
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+type acceptingAuthService struct{}
+
+func (acceptingAuthService) AuthorizeUser(*dto.AuthorizeUserRequest) (*dto.AuthorizeUserResponse, error) {
+	return &dto.AuthorizeUserResponse{User: &entities.User{}}, nil
+}
+
+func (acceptingAuthService) AuthorizeApiKey(*dto.AuthorizeApiKeyRequest) (*dto.AuthorizeUserResponse, error) {
+	return &dto.AuthorizeUserResponse{User: &entities.User{}}, nil
+}
+
+func TestAuthMiddlewareCSRF(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		cookies       bool
+		csrfHeader    string
+		wantCode      int
+	}{
+		{name: "cookie with matching header", method: http.MethodPost, cookies: true, csrfHeader: "csrf"},
+		{name: "cookie without header", method: http.MethodPost, cookies: true, wantCode: http.StatusForbidden},
+		{name: "cookie with mismatched header", method: http.MethodPost, cookies: true, csrfHeader: "other", wantCode: http.StatusForbidden},
+		{name: "cookie safe method", method: http.MethodGet, cookies: true},
+		{name: "bearer", method: http.MethodPost, authorization: "Bearer token"},
+		{name: "bearer with stale cookies", method: http.MethodPost, authorization: "Bearer token", cookies: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/api/posts", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.cookies {
+				r.AddCookie(&http.Cookie{Name: web.AccessTokenCookie, Value: "token"})
+				r.AddCookie(&http.Cookie{Name: web.CSRFTokenCookie, Value: "csrf"})
+			}
+			if tt.csrfHeader != "" {
+				r.Header.Set(web.CSRFHeader, tt.csrfHeader)
+			}
+
+			httpError := &errors.HttpError{}
+			r = r.WithContext(context.WithValue(r.Context(), web.CtxErrorKey, httpError))
+
+			called := false
+			next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true })
+
+			NewAuthMiddlewareHandler(acceptingAuthService{}).AuthMiddleware(next).ServeHTTP(httptest.NewRecorder(), r)
+
+			if httpError.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d (%v)", httpError.Code, tt.wantCode, httpError.Err)
+			}
+			if called != (tt.wantCode == 0) {
+				t.Errorf("next called = %v", called)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+func RequirePermission(permission entities.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			httpError := web.GetHttpErrorFromCtx(r.Context())
+
+			if _, err := web.CheckPermission(r.Context(), permission); err != nil {
+				parsedError := errors.ParseHttpError(err)
+				httpError.Err = parsedError.Err
+				httpError.Code = parsedError.Code
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+func RequireScope(scope entities.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			httpError := web.GetHttpErrorFromCtx(r.Context())
+
+			if err := web.CheckScope(r.Context(), scope); err != nil {
+				parsedError := errors.ParseHttpError(err)
+				httpError.Err = parsedError.Err
+				httpError.Code = parsedError.Code
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func RejectApiKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpError := web.GetHttpErrorFromCtx(r.Context())
+
+		if _, ok := web.GetApiKeyFromCtx(r.Context()); ok {
+			parsedError := errors.ParseHttpError(errors.ErrInsufficientScope)
+			httpError.Err = parsedError.Err
+			httpError.Code = parsedError.Code
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
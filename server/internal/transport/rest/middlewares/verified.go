@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+func RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpError := web.GetHttpErrorFromCtx(r.Context())
+
+		user, err := web.GetUserFromCtx(r.Context())
+		if err == nil && !user.Verified {
+			err = errors.ErrEmailNotVerified
+		}
+
+		if err != nil {
+			parsedError := errors.ParseHttpError(err)
+			httpError.Err = parsedError.Err
+			httpError.Code = parsedError.Code
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,37 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/internal/core/repository"
+	"github.com/skrpld/NearBeee/internal/core/service"
+	"github.com/skrpld/NearBeee/internal/transport/rest/handlers"
+	"github.com/skrpld/NearBeee/internal/transport/rest/middlewares"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+)
+
+func NewAdminRouter(postgresRepo *repository.PostgresRepository, mongodbRepo *repository.MongodbRepository, areasSrv *service.AreasService, clusters *service.ClusterCache) *http.ServeMux {
+	srv := service.NewAdminService(postgresRepo, mongodbRepo, clusters)
+	controller := handlers.NewAdminController(srv)
+	areas := handlers.NewAreasController(areasSrv)
+	router := http.NewServeMux()
+
+	viewUsers := middlewares.RequirePermission(entities.ViewUsersPermission)
+	banUsers := middlewares.RequirePermission(entities.BanUsersPermission)
+	manageRoles := middlewares.RequirePermission(entities.ManageRolesPermission)
+	deleteAnyPost := middlewares.RequirePermission(entities.DeleteAnyPostPermission)
+	deleteAnyMessage := middlewares.RequirePermission(entities.DeleteAnyMessagePermission)
+	manageAreas := middlewares.RequirePermission(entities.ManageAreasPermission)
+
+	router.Handle("GET /admin/users", viewUsers(web.Handle(controller.SearchUsers)))
+	router.Handle("POST /admin/users/{user_id}/ban", banUsers(web.Handle(controller.BanUser)))
+	router.Handle("DELETE /admin/users/{user_id}/ban", banUsers(web.Handle(controller.UnbanUser)))
+	router.Handle("PUT /admin/users/{user_id}/role", manageRoles(web.Handle(controller.SetUserRole)))
+	router.Handle("DELETE /admin/posts/{post_id}", deleteAnyPost(web.Handle(controller.ForceDeletePost)))
+	router.Handle("DELETE /admin/messages/{msg_id}", deleteAnyMessage(web.Handle(controller.ForceDeleteMessage)))
+	router.Handle("POST /admin/areas", manageAreas(web.Handle(areas.CreateArea)))
+	router.Handle("DELETE /admin/areas/{slug}", manageAreas(web.Handle(areas.DeleteArea)))
+
+	return router
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/internal/core/service"
+	"github.com/skrpld/NearBeee/internal/transport/rest/handlers"
+	"github.com/skrpld/NearBeee/internal/transport/rest/middlewares"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+)
+
+func NewAreasRouter(srv *service.AreasService) *http.ServeMux {
+	controller := handlers.NewAreasController(srv)
+	router := http.NewServeMux()
+
+	readPosts := middlewares.RequireScope(entities.PostsReadScope)
+
+	router.Handle("GET /areas/{slug}", readPosts(web.Handle(controller.GetArea)))
+	router.Handle("GET /areas/{slug}/posts", readPosts(web.Handle(controller.GetAreaPosts)))
+
+	return router
+}
@@ -3,20 +3,62 @@ package routers
 import (
 	"net/http"
 
+	"github.com/skrpld/NearBeee/internal/core/logger"
 	"github.com/skrpld/NearBeee/internal/core/repository"
 	"github.com/skrpld/NearBeee/internal/core/service"
 	"github.com/skrpld/NearBeee/internal/transport/rest/handlers"
+	"github.com/skrpld/NearBeee/internal/transport/rest/middlewares"
 	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/utils/mail"
+	"github.com/skrpld/NearBeee/pkg/utils/oidc"
 )
 
-func NewAuthRouter(repo *repository.PostgresRepository, secret string) (*http.ServeMux, *service.AuthService) {
-	srv := service.NewAuthService(repo, secret)
+const (
+	loginAttemptsMemoryStore = "memory"
+	loginAttemptsMaxEntries  = 100000
+)
+
+func NewAuthRouter(repo *repository.PostgresRepository, mailer mail.Mailer, validator *mail.Validator, secret string, cfg service.AuthConfig, oidcCfg oidc.OIDCConfig, logger logger.Logger) (*http.ServeMux, *service.AuthService, error) {
+	var attempts service.LoginAttemptStore = repo
+	if cfg.LoginAttemptsStore == loginAttemptsMemoryStore {
+		attempts = repository.NewLoginAttemptsMemoryRepository(max(cfg.LoginAttemptsWindow, cfg.LoginLockoutDuration), loginAttemptsMaxEntries)
+	}
+
+	srv, err := service.NewAuthService(repo, attempts, mailer, validator, secret, cfg, oidcCfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	controller := handlers.NewAuthController(srv)
+	authMiddleware := middlewares.NewAuthMiddlewareHandler(srv).AuthMiddleware
+	sessionAuth := func(next http.Handler) http.Handler {
+		return authMiddleware(middlewares.RejectApiKeys(next))
+	}
 	router := http.NewServeMux()
 
 	router.HandleFunc("POST /auth/register", web.Handle(controller.RegistrateUserHandler))
 	router.HandleFunc("POST /auth/login", web.Handle(controller.LoginUserHandler))
+	router.HandleFunc("POST /auth/2fa/verify", web.Handle(controller.VerifyTwoFactorHandler))
 	router.HandleFunc("POST /auth/refresh-token", web.Handle(controller.RefreshUserTokenHandler))
+	router.HandleFunc("POST /auth/verify-email", web.Handle(controller.VerifyEmailHandler))
+	router.HandleFunc("POST /auth/password/forgot", web.Handle(controller.ForgotPasswordHandler))
+	router.HandleFunc("POST /auth/password/reset", web.Handle(controller.ResetPasswordHandler))
+	router.HandleFunc("GET /auth/oidc/{provider}/start", web.Handle(controller.StartOIDCHandler))
+	router.HandleFunc("GET /auth/oidc/{provider}/callback", web.Handle(controller.OIDCCallbackHandler))
+
+	router.Handle("POST /auth/resend-verification", sessionAuth(web.Handle(controller.ResendVerificationHandler)))
+	router.Handle("POST /auth/2fa/setup", sessionAuth(web.Handle(controller.SetupTwoFactorHandler)))
+	router.Handle("POST /auth/2fa/confirm", sessionAuth(web.Handle(controller.ConfirmTwoFactorHandler)))
+	router.Handle("DELETE /auth/2fa", sessionAuth(web.Handle(controller.DisableTwoFactorHandler)))
+	router.Handle("PUT /auth/password", sessionAuth(web.Handle(controller.ChangePasswordHandler)))
+	router.Handle("GET /auth/sessions", sessionAuth(web.Handle(controller.GetSessionsHandler)))
+	router.Handle("DELETE /auth/sessions/{session_id}", sessionAuth(web.Handle(controller.RevokeSessionHandler)))
+	router.Handle("POST /auth/logout", sessionAuth(web.Handle(controller.LogoutUserHandler)))
+	router.Handle("POST /auth/logout-all", sessionAuth(web.Handle(controller.LogoutAllHandler)))
+
+	router.Handle("POST /auth/api-keys", sessionAuth(web.Handle(controller.CreateApiKeyHandler)))
+	router.Handle("GET /auth/api-keys", sessionAuth(web.Handle(controller.GetApiKeysHandler)))
+	router.Handle("DELETE /auth/api-keys/{key_id}", sessionAuth(web.Handle(controller.RevokeApiKeyHandler)))
 
-	return router, srv
+	return router, srv, nil
 }
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
+	"github.com/skrpld/NearBeee/internal/core/service"
+	"github.com/skrpld/NearBeee/internal/transport/rest/handlers"
+	"github.com/skrpld/NearBeee/internal/transport/rest/middlewares"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/utils/geocoder"
+)
+
+func NewGeoRouter(geocoder *geocoder.Geocoder) *http.ServeMux {
+	srv := service.NewGeoService(geocoder)
+	controller := handlers.NewGeoController(srv)
+	router := http.NewServeMux()
+
+	readPosts := middlewares.RequireScope(entities.PostsReadScope)
+
+	router.Handle("GET /geo/reverse", readPosts(web.Handle(controller.ReverseGeocode)))
+
+	return router
+}
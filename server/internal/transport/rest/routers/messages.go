@@ -3,22 +3,32 @@ package routers
 import (
 	"net/http"
 
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
 	"github.com/skrpld/NearBeee/internal/core/repository"
 	"github.com/skrpld/NearBeee/internal/core/service"
 	"github.com/skrpld/NearBeee/internal/transport/rest/handlers"
+	"github.com/skrpld/NearBeee/internal/transport/rest/middlewares"
 	"github.com/skrpld/NearBeee/internal/transport/rest/web"
 )
 
-func NewMessagesRouter(repo *repository.MongodbRepository) *http.ServeMux {
-	srv := service.NewMessagesService(repo)
+func NewMessagesRouter(repo *repository.MongodbRepository, postsRepo *repository.PostgresRepository, authors *service.AuthorResolver, paginator *service.Paginator, cfg service.AuthConfig) *http.ServeMux {
+	srv := service.NewMessagesService(repo, postsRepo, authors, paginator)
 	controller := handlers.NewMessagesController(srv)
 	router := http.NewServeMux()
 
-	router.HandleFunc("POST /messages/", web.Handle(controller.CreateMessage))
-	router.HandleFunc("GET /messages/", web.Handle(controller.GetMessage))
-	router.HandleFunc("GET /messages/{msg_id}", web.Handle(controller.GetMessage))
-	router.HandleFunc("PUT /messages/{msg_id}", web.Handle(controller.UpdateMessageById))
-	router.HandleFunc("DELETE /messages/{msg_id}", web.Handle(controller.DeleteMessageById))
+	var createMessage http.Handler = web.Handle(controller.CreateMessage)
+	if !cfg.UnverifiedCanMessage {
+		createMessage = middlewares.RequireVerifiedEmail(createMessage)
+	}
+
+	readMessages := middlewares.RequireScope(entities.MessagesReadScope)
+	writeMessages := middlewares.RequireScope(entities.MessagesWriteScope)
+
+	router.Handle("POST /messages/", writeMessages(createMessage))
+	router.Handle("GET /messages/", readMessages(web.Handle(controller.GetMessage)))
+	router.Handle("GET /messages/{msg_id}", readMessages(web.Handle(controller.GetMessage)))
+	router.Handle("PUT /messages/{msg_id}", writeMessages(web.Handle(controller.UpdateMessageById)))
+	router.Handle("DELETE /messages/{msg_id}", writeMessages(web.Handle(controller.DeleteMessageById)))
 
 	return router
 }
@@ -3,22 +3,35 @@ package routers
 import (
 	"net/http"
 
+	"github.com/skrpld/NearBeee/internal/core/models/entities"
 	"github.com/skrpld/NearBeee/internal/core/repository"
 	"github.com/skrpld/NearBeee/internal/core/service"
 	"github.com/skrpld/NearBeee/internal/transport/rest/handlers"
+	"github.com/skrpld/NearBeee/internal/transport/rest/middlewares"
 	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/utils/geocoder"
 )
 
-func NewPostsRouter(repo *repository.PostgresRepository) *http.ServeMux {
-	srv := service.NewPostsService(repo)
+func NewPostsRouter(repo *repository.PostgresRepository, authors *service.AuthorResolver, paginator *service.Paginator, geocoder *geocoder.Geocoder, clusters *service.ClusterCache, cfg service.AuthConfig, postsCfg service.PostsConfig) *http.ServeMux {
+	srv := service.NewPostsService(repo, authors, paginator, geocoder, clusters, postsCfg)
 	controller := handlers.NewPostsController(srv)
 	router := http.NewServeMux()
 
-	router.HandleFunc("POST /posts/", web.Handle(controller.CreatePostHandler))
-	router.HandleFunc("GET /posts/", web.Handle(controller.GetPosts))
-	router.HandleFunc("GET /posts/{post_id}", web.Handle(controller.GetPosts))
-	router.HandleFunc("PUT /posts/{post_id}", web.Handle(controller.UpdatePostById))
-	router.HandleFunc("DELETE /posts/{post_id}", web.Handle(controller.DeletePostById))
+	var createPost http.Handler = web.Handle(controller.CreatePostHandler)
+	if !cfg.UnverifiedCanPost {
+		createPost = middlewares.RequireVerifiedEmail(createPost)
+	}
+
+	readPosts := middlewares.RequireScope(entities.PostsReadScope)
+	writePosts := middlewares.RequireScope(entities.PostsWriteScope)
+
+	router.Handle("POST /posts/", writePosts(createPost))
+	router.Handle("GET /posts/", readPosts(web.Handle(controller.GetPosts, web.GeoJSONEncoder{}, web.KMLEncoder{DocumentName: "NearBeee posts"})))
+	router.Handle("GET /posts/clusters", readPosts(web.Handle(controller.GetPostClusters)))
+	router.Handle("GET /tiles/posts/{z}/{x}/{y}", readPosts(web.Handle(controller.GetPostsTile)))
+	router.Handle("GET /posts/{post_id}", readPosts(web.Handle(controller.GetPosts)))
+	router.Handle("PUT /posts/{post_id}", writePosts(web.Handle(controller.UpdatePostById)))
+	router.Handle("DELETE /posts/{post_id}", writePosts(web.Handle(controller.DeletePostById)))
 
 	return router
 }
@@ -0,0 +1,28 @@
+package routers
+
+import (
+	"net/http"
+
+	"github.com/skrpld/NearBeee/internal/core/repository"
+	"github.com/skrpld/NearBeee/internal/core/service"
+	"github.com/skrpld/NearBeee/internal/transport/rest/handlers"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/utils/mail"
+	"github.com/skrpld/NearBeee/pkg/utils/storage"
+)
+
+func NewUsersRouter(repo *repository.PostgresRepository, messagesRepo *repository.MongodbRepository, storage storage.Storage, mailer mail.Mailer, cfg service.ProfileConfig, accountCfg service.AccountConfig) *http.ServeMux {
+	srv := service.NewUsersService(repo, storage, cfg)
+	accountSrv := service.NewAccountService(repo, messagesRepo, storage, mailer, accountCfg)
+	controller := handlers.NewUsersController(srv, accountSrv)
+	router := http.NewServeMux()
+
+	router.HandleFunc("GET /users/me", web.Handle(controller.GetMyProfile))
+	router.HandleFunc("PUT /users/me", web.Handle(controller.UpdateMyProfile))
+	router.HandleFunc("DELETE /users/me", web.Handle(controller.DeleteAccount))
+	router.HandleFunc("PUT /users/me/avatar", web.Handle(controller.UpdateAvatar))
+	router.HandleFunc("GET /users/me/export", web.Handle(controller.ExportAccount))
+	router.HandleFunc("GET /users/{handle}", web.Handle(controller.GetProfileByHandle))
+
+	return router
+}
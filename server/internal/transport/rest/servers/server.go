@@ -8,8 +8,15 @@ import (
 
 	"github.com/skrpld/NearBeee/internal/core/logger"
 	"github.com/skrpld/NearBeee/internal/core/repository"
+	"github.com/skrpld/NearBeee/internal/core/service"
+	"github.com/skrpld/NearBeee/internal/transport/rest/handlers"
 	"github.com/skrpld/NearBeee/internal/transport/rest/middlewares"
 	"github.com/skrpld/NearBeee/internal/transport/rest/routers"
+	"github.com/skrpld/NearBeee/internal/transport/rest/web"
+	"github.com/skrpld/NearBeee/pkg/utils/geocoder"
+	"github.com/skrpld/NearBeee/pkg/utils/mail"
+	"github.com/skrpld/NearBeee/pkg/utils/oidc"
+	"github.com/skrpld/NearBeee/pkg/utils/storage"
 )
 
 type HttpServerConfig struct {
@@ -18,25 +25,46 @@ type HttpServerConfig struct {
 	Secret string `env:"SECRET" env-default:"secret" mapstructure:"SECRET"`
 }
 
+type mediaStorage interface {
+	MountPath() string
+	Handler() http.Handler
+}
+
 type HttpServer struct {
 	cfg    HttpServerConfig
 	server *http.Server
 	logger logger.Logger
 }
 
-func NewHttpServer(cfg HttpServerConfig, postgresRepo *repository.PostgresRepository, mongodbRepo *repository.MongodbRepository, logger logger.Logger) (*HttpServer, error) {
+func NewHttpServer(cfg HttpServerConfig, authCfg service.AuthConfig, oidcCfg oidc.OIDCConfig, postsCfg service.PostsConfig, paginationCfg service.PaginationConfig, profileCfg service.ProfileConfig, accountCfg service.AccountConfig, mailer mail.Mailer, validator *mail.Validator, storage storage.Storage, geocoder *geocoder.Geocoder, clusters *service.ClusterCache, postgresRepo *repository.PostgresRepository, mongodbRepo *repository.MongodbRepository, logger logger.Logger) (*HttpServer, error) {
 	mainMux := http.NewServeMux()
 
-	authRouter, authSrv := routers.NewAuthRouter(postgresRepo, cfg.Secret)
-	postsRouter := routers.NewPostsRouter(postgresRepo)
-	messagesRouter := routers.NewMessagesRouter(mongodbRepo)
+	authRouter, authSrv, err := routers.NewAuthRouter(postgresRepo, mailer, validator, cfg.Secret, authCfg, oidcCfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	authors := service.NewAuthorResolver(postgresRepo, storage)
+	paginator := service.NewPaginator(cfg.Secret, paginationCfg)
+
+	postsRouter := routers.NewPostsRouter(postgresRepo, authors, paginator, geocoder, clusters, authCfg, postsCfg)
+	geoRouter := routers.NewGeoRouter(geocoder)
+	messagesRouter := routers.NewMessagesRouter(mongodbRepo, postgresRepo, authors, paginator, authCfg)
+	usersRouter := routers.NewUsersRouter(postgresRepo, mongodbRepo, storage, mailer, profileCfg, accountCfg)
+	areasSrv := service.NewAreasService(postgresRepo, authors, paginator)
+	areasRouter := routers.NewAreasRouter(areasSrv)
+	adminRouter := routers.NewAdminRouter(postgresRepo, mongodbRepo, areasSrv, clusters)
 
 	authMiddleware := middlewares.NewAuthMiddlewareHandler(authSrv).AuthMiddleware
 
 	apiMux := http.NewServeMux()
 	apiMux.Handle("/auth/", authRouter)
 	apiMux.Handle("/posts/", authMiddleware(postsRouter))
+	apiMux.Handle("/tiles/", authMiddleware(postsRouter))
+	apiMux.Handle("/areas/", authMiddleware(areasRouter))
+	apiMux.Handle("/geo/", authMiddleware(geoRouter))
 	apiMux.Handle("/messages/", authMiddleware(messagesRouter))
+	apiMux.Handle("/users/", authMiddleware(middlewares.RejectApiKeys(usersRouter)))
+	apiMux.Handle("/admin/", authMiddleware(middlewares.RejectApiKeys(adminRouter)))
 
 	handler := middlewares.LoggerMiddleware(logger)(
 		middlewares.GlobalMiddleware(
@@ -45,6 +73,15 @@ func NewHttpServer(cfg HttpServerConfig, postgresRepo *repository.PostgresReposi
 	)
 
 	mainMux.Handle("/api/", handler)
+	mainMux.Handle("GET /.well-known/jwks.json", middlewares.LoggerMiddleware(logger)(
+		middlewares.GlobalMiddleware(
+			web.Handle(handlers.JWKSHandler),
+		),
+	))
+
+	if media, ok := storage.(mediaStorage); ok {
+		mainMux.Handle("GET "+media.MountPath(), media.Handler())
+	}
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
@@ -5,14 +5,45 @@ type FormType string
 const (
 	UserForm     FormType = "user"
 	LocationForm FormType = "location"
+	BBoxForm     FormType = "bbox"
+	FeedForm     FormType = "feed"
 	PostForm     FormType = "post"
 	MessageForm  FormType = "message"
 	NullForm     FormType = ""
 )
 
 const (
-	FormValue = "type"
-	
-	PostPathValue = "post_id"
-	MsgPathValue  = "msg_id"
+	FormValue   = "type"
+	ExpandValue = "expand"
+	DebugValue  = "debug"
+	BBoxQuery   = "bbox"
+	ZoomQuery   = "zoom"
+	LatQuery    = "lat"
+	LonQuery    = "lon"
+
+	AuthorExpand = "author"
+	ScoreDebug   = "score"
+
+	PostPathValue     = "post_id"
+	MsgPathValue      = "msg_id"
+	SessionPathValue  = "session_id"
+	UserPathValue     = "user_id"
+	HandlePathValue   = "handle"
+	ApiKeyPathValue   = "key_id"
+	ProviderPathValue = "provider"
+	AreaPathValue     = "slug"
+	TileZPathValue    = "z"
+	TileXPathValue    = "x"
+	TileYPathValue    = "y"
+)
+
+const (
+	MVTExtension   = ".mvt"
+	MVTContentType = "application/vnd.mapbox-vector-tile"
+)
+
+const (
+	OIDCStateCookie     = "oidc_state"
+	OIDCAuthModeCookie  = "oidc_auth_mode"
+	OIDCStateCookiePath = "/api/auth/oidc/"
 )
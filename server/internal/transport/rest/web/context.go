@@ -13,6 +13,9 @@ type ctxKey int
 const (
 	CtxUserKey ctxKey = iota
 	CtxErrorKey
+	CtxSessionKey
+	CtxApiKeyKey
+	CtxResponseTypeKey
 )
 
 func GetHttpErrorFromCtx(ctx context.Context) *errors.HttpError {
@@ -29,3 +32,37 @@ func GetUserFromCtx(ctx context.Context) (*entities.User, error) {
 	}
 	return user, nil
 }
+
+func GetSessionFromCtx(ctx context.Context) (*entities.Session, error) {
+	ctxSession := ctx.Value(CtxSessionKey)
+	session, ok := ctxSession.(*entities.Session)
+	if !ok {
+		return nil, errors.ErrNoPermissions
+	}
+	return session, nil
+}
+
+func GetApiKeyFromCtx(ctx context.Context) (*entities.ApiKey, bool) {
+	apiKey, ok := ctx.Value(CtxApiKeyKey).(*entities.ApiKey)
+	return apiKey, ok
+}
+
+func CheckScope(ctx context.Context, scope entities.Scope) error {
+	apiKey, ok := GetApiKeyFromCtx(ctx)
+	if ok && !apiKey.HasScope(scope) {
+		return errors.ErrInsufficientScope
+	}
+	return nil
+}
+
+func CheckPermission(ctx context.Context, permission entities.Permission) (*entities.User, error) {
+	user, err := GetUserFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Role.Can(permission) {
+		return nil, errors.ErrNoPermissions
+	}
+	return user, nil
+}
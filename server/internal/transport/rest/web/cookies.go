@@ -0,0 +1,154 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/jwt"
+)
+
+type CookieConfig struct {
+	CookieDomain   string `env:"AUTH_COOKIE_DOMAIN" mapstructure:"AUTH_COOKIE_DOMAIN"`
+	CookieSecure   bool   `env:"AUTH_COOKIE_SECURE" env-default:"true" mapstructure:"AUTH_COOKIE_SECURE"`
+	CookieSameSite string `env:"AUTH_COOKIE_SAMESITE" env-default:"lax" mapstructure:"AUTH_COOKIE_SAMESITE"`
+}
+
+const (
+	AuthModeHeader = "X-Auth-Mode"
+	CSRFHeader     = "X-CSRF-Token"
+	CookieAuthMode = "cookie"
+
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFTokenCookie    = "csrf_token"
+
+	accessTokenCookiePath  = "/api/"
+	refreshTokenCookiePath = "/api/auth/"
+	csrfTokenCookiePath    = "/"
+)
+
+type cookieSettings struct {
+	domain   string
+	secure   bool
+	sameSite http.SameSite
+}
+
+var currentCookieSettings atomic.Pointer[cookieSettings]
+
+func UpdateCookieConfig(cfg *CookieConfig) error {
+	settings := &cookieSettings{domain: cfg.CookieDomain, secure: cfg.CookieSecure}
+
+	switch strings.ToLower(cfg.CookieSameSite) {
+	case "lax":
+		settings.sameSite = http.SameSiteLaxMode
+	case "strict":
+		settings.sameSite = http.SameSiteStrictMode
+	case "none":
+		if !cfg.CookieSecure {
+			return fmt.Errorf("web: AUTH_COOKIE_SAMESITE=none requires AUTH_COOKIE_SECURE=true")
+		}
+		settings.sameSite = http.SameSiteNoneMode
+	default:
+		return fmt.Errorf("web: unsupported AUTH_COOKIE_SAMESITE %q", cfg.CookieSameSite)
+	}
+
+	currentCookieSettings.Store(settings)
+
+	return nil
+}
+
+func IsCookieAuthMode(r *http.Request) bool {
+	if r.Header.Get(AuthModeHeader) == CookieAuthMode {
+		return true
+	}
+	if r.Header.Get("Authorization") != "" {
+		return false
+	}
+	return GetCookieValue(r, AccessTokenCookie) != "" || GetCookieValue(r, RefreshTokenCookie) != ""
+}
+
+func GetCookieValue(r *http.Request, name string) string {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+func CheckCSRF(r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	cookieToken := GetCookieValue(r, CSRFTokenCookie)
+	headerToken := r.Header.Get(CSRFHeader)
+
+	if cookieToken == "" || subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) != 1 {
+		return errors.ErrInvalidCSRFToken
+	}
+	return nil
+}
+
+func AuthCookies(accessToken, refreshToken string) ([]*http.Cookie, error) {
+	cookies := make([]*http.Cookie, 0, 3)
+
+	if accessToken != "" {
+		cookies = append(cookies, newCookie(AccessTokenCookie, accessToken, accessTokenCookiePath, int(jwt.AccessTokenTTL().Seconds()), true))
+	}
+
+	if refreshToken != "" {
+		csrfToken, err := newCSRFToken()
+		if err != nil {
+			return nil, err
+		}
+
+		maxAge := int(jwt.RefreshTokenTTL().Seconds())
+		cookies = append(cookies,
+			newCookie(RefreshTokenCookie, refreshToken, refreshTokenCookiePath, maxAge, true),
+			newCookie(CSRFTokenCookie, csrfToken, csrfTokenCookiePath, maxAge, false),
+		)
+	}
+
+	return cookies, nil
+}
+
+func ExpiredAuthCookies() []*http.Cookie {
+	return []*http.Cookie{
+		newCookie(AccessTokenCookie, "", accessTokenCookiePath, -1, true),
+		newCookie(RefreshTokenCookie, "", refreshTokenCookiePath, -1, true),
+		newCookie(CSRFTokenCookie, "", csrfTokenCookiePath, -1, false),
+	}
+}
+
+func newCookie(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	settings := currentCookieSettings.Load()
+	if settings == nil {
+		settings = &cookieSettings{secure: true, sameSite: http.SameSiteLaxMode}
+	}
+
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   settings.domain,
+		MaxAge:   maxAge,
+		Secure:   settings.secure,
+		HttpOnly: httpOnly,
+		SameSite: settings.sameSite,
+	}
+}
+
+func newCSRFToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,144 @@
+package web
+
+import (
+	stderr "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+func TestCheckCSRF(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		cookie  string
+		header  string
+		wantErr bool
+	}{
+		{name: "matching token", method: http.MethodPost, cookie: "token", header: "token"},
+		{name: "missing header", method: http.MethodPost, cookie: "token", wantErr: true},
+		{name: "mismatched header", method: http.MethodDelete, cookie: "token", header: "other", wantErr: true},
+		{name: "missing cookie", method: http.MethodPut, header: "token", wantErr: true},
+		{name: "both empty", method: http.MethodPatch, wantErr: true},
+		{name: "get skips the check", method: http.MethodGet},
+		{name: "head skips the check", method: http.MethodHead, cookie: "token", header: "other"},
+		{name: "options skips the check", method: http.MethodOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/api/posts", nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: CSRFTokenCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				r.Header.Set(CSRFHeader, tt.header)
+			}
+
+			err := CheckCSRF(r)
+			if tt.wantErr && !stderr.Is(err, errors.ErrInvalidCSRFToken) {
+				t.Errorf("CheckCSRF() error = %v, want %v", err, errors.ErrInvalidCSRFToken)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("CheckCSRF() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestIsCookieAuthMode(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		authorization string
+		cookie        string
+		want          bool
+	}{
+		{name: "plain request"},
+		{name: "mode header", mode: CookieAuthMode, want: true},
+		{name: "access cookie", cookie: AccessTokenCookie, want: true},
+		{name: "refresh cookie", cookie: RefreshTokenCookie, want: true},
+		{name: "bearer with stale cookies", authorization: "Bearer token", cookie: AccessTokenCookie},
+		{name: "bearer with mode header", authorization: "Bearer token", mode: CookieAuthMode, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+			if tt.mode != "" {
+				r.Header.Set(AuthModeHeader, tt.mode)
+			}
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: tt.cookie, Value: "token"})
+			}
+
+			if got := IsCookieAuthMode(r); got != tt.want {
+				t.Errorf("IsCookieAuthMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthCookies(t *testing.T) {
+	if err := UpdateCookieConfig(&CookieConfig{CookieDomain: "example.com", CookieSecure: true, CookieSameSite: "strict"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { currentCookieSettings.Store(nil) })
+
+	cookies, err := AuthCookies("access", "refresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]struct {
+		path     string
+		httpOnly bool
+	}{
+		AccessTokenCookie:  {accessTokenCookiePath, true},
+		RefreshTokenCookie: {refreshTokenCookiePath, true},
+		// Scripts read the CSRF token to echo it in the header.
+		CSRFTokenCookie: {csrfTokenCookiePath, false},
+	}
+	if len(cookies) != len(want) {
+		t.Fatalf("AuthCookies() = %d cookies, want %d", len(cookies), len(want))
+	}
+
+	for _, cookie := range cookies {
+		w, ok := want[cookie.Name]
+		if !ok {
+			t.Errorf("unexpected cookie %q", cookie.Name)
+			continue
+		}
+		if cookie.Path != w.path || cookie.HttpOnly != w.httpOnly || cookie.Value == "" {
+			t.Errorf("%s: path = %q, httpOnly = %v, value = %q", cookie.Name, cookie.Path, cookie.HttpOnly, cookie.Value)
+		}
+		if !cookie.Secure || cookie.SameSite != http.SameSiteStrictMode || cookie.Domain != "example.com" {
+			t.Errorf("%s: secure = %v, sameSite = %v, domain = %q", cookie.Name, cookie.Secure, cookie.SameSite, cookie.Domain)
+		}
+	}
+
+	// An access token alone refreshes nothing, so the CSRF token stays put.
+	cookies, err = AuthCookies("access", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 1 || cookies[0].Name != AccessTokenCookie {
+		t.Errorf("AuthCookies() without a refresh token = %v", cookies)
+	}
+}
+
+func TestUpdateCookieConfig(t *testing.T) {
+	t.Cleanup(func() { currentCookieSettings.Store(nil) })
+
+	if err := UpdateCookieConfig(&CookieConfig{CookieSameSite: "none"}); err == nil {
+		t.Error("SameSite=None without Secure was accepted")
+	}
+	if err := UpdateCookieConfig(&CookieConfig{CookieSameSite: "sometimes"}); err == nil {
+		t.Error("unknown SameSite mode was accepted")
+	}
+}
@@ -0,0 +1,182 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/geo"
+	"github.com/skrpld/NearBeee/pkg/geo/geojson"
+	"github.com/skrpld/NearBeee/pkg/geo/kml"
+)
+
+const (
+	JSONContentType    = "application/json"
+	GeoJSONContentType = geojson.ContentType
+	KMLContentType     = kml.ContentType
+
+	// featuresPerFlush bounds how many streamed features may sit in buffers
+	// before they are pushed to the client.
+	featuresPerFlush = 500
+)
+
+// Encoder writes a handler result in one media type. Handle picks the
+// encoder from the Accept header before the handler runs, so the handler can
+// shape its result for it via ResponseType.
+type Encoder interface {
+	ContentType() string
+	Encode(w http.ResponseWriter, data any) error
+}
+
+type JSONEncoder struct{}
+
+func (JSONEncoder) ContentType() string {
+	return JSONContentType
+}
+
+func (JSONEncoder) Encode(w http.ResponseWriter, data any) error {
+	w.Header().Set("Content-Type", JSONContentType)
+	return json.NewEncoder(w).Encode(data)
+}
+
+type GeoJSONEncoder struct{}
+
+func (GeoJSONEncoder) ContentType() string {
+	return GeoJSONContentType
+}
+
+func (GeoJSONEncoder) Encode(w http.ResponseWriter, data any) error {
+	source, ok := data.(geo.FeatureSource)
+	if !ok {
+		return errors.ErrNotAcceptable
+	}
+
+	w.Header().Set("Content-Type", GeoJSONContentType)
+	return streamFeatures(w, source, geojson.NewWriter(w))
+}
+
+type KMLEncoder struct {
+	DocumentName string
+}
+
+func (KMLEncoder) ContentType() string {
+	return KMLContentType
+}
+
+func (e KMLEncoder) Encode(w http.ResponseWriter, data any) error {
+	source, ok := data.(geo.FeatureSource)
+	if !ok {
+		return errors.ErrNotAcceptable
+	}
+
+	w.Header().Set("Content-Type", KMLContentType)
+	return streamFeatures(w, source, kml.NewWriter(w, e.DocumentName))
+}
+
+type featureWriter interface {
+	WriteFeature(feature geo.Feature) error
+	Flush() error
+	Close() error
+}
+
+func streamFeatures(w http.ResponseWriter, source geo.FeatureSource, writer featureWriter) error {
+	controller := http.NewResponseController(w)
+	count := 0
+
+	err := source.EachFeature(func(feature geo.Feature) error {
+		if err := writer.WriteFeature(feature); err != nil {
+			return err
+		}
+
+		if count++; count%featuresPerFlush == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			_ = controller.Flush()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// ResponseType returns the media type negotiated for the request by Handle.
+func ResponseType(r *http.Request) string {
+	if contentType, ok := r.Context().Value(CtxResponseTypeKey).(string); ok {
+		return contentType
+	}
+	return JSONContentType
+}
+
+func withResponseType(r *http.Request, contentType string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), CtxResponseTypeKey, contentType))
+}
+
+// negotiate returns the encoder with the highest quality in the Accept
+// header. Each encoder takes the quality of the most specific range matching
+// it; ties go to the earlier encoder.
+func negotiate(accept string, encoders []Encoder) (Encoder, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return encoders[0], true
+	}
+
+	var best Encoder
+	bestQuality := 0.0
+
+	for _, encoder := range encoders {
+		quality, specificity := 0.0, -1
+
+		for _, raw := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(raw))
+			if err != nil {
+				continue
+			}
+
+			rangeSpecificity := matchMediaRange(mediaType, encoder.ContentType())
+			if rangeSpecificity <= specificity {
+				continue
+			}
+
+			specificity = rangeSpecificity
+			quality = 1
+			if q, ok := params["q"]; ok {
+				if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = encoder, quality
+		}
+	}
+
+	return best, best != nil
+}
+
+// matchMediaRange returns -1 when the range does not match the media type,
+// and otherwise 0 for */*, 1 for type/* and 2 for an exact match.
+func matchMediaRange(mediaRange, mediaType string) int {
+	if mediaRange == "*/*" {
+		return 0
+	}
+	if mediaRange == mediaType {
+		return 2
+	}
+
+	rangeType, rangeSubtype, _ := strings.Cut(mediaRange, "/")
+	typ, _, _ := strings.Cut(mediaType, "/")
+	if rangeSubtype == "*" && rangeType == typ {
+		return 1
+	}
+
+	return -1
+}
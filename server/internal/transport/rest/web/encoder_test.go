@@ -0,0 +1,214 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	stderr "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/geo"
+)
+
+func TestNegotiate(t *testing.T) {
+	encoders := []Encoder{JSONEncoder{}, GeoJSONEncoder{}, KMLEncoder{}}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", JSONContentType},
+		{"*/*", JSONContentType},
+		{"application/*", JSONContentType},
+		{GeoJSONContentType, GeoJSONContentType},
+		{KMLContentType + ", " + JSONContentType, JSONContentType},
+		{KMLContentType + ", " + JSONContentType + ";q=0.9", KMLContentType},
+		{JSONContentType + ";q=0.5, " + GeoJSONContentType + ";q=0.8, */*;q=0.1", GeoJSONContentType},
+		// The exact range outranks the wildcard matching the same type.
+		{"application/*;q=0.9, " + JSONContentType + ";q=0.1", GeoJSONContentType},
+		{JSONContentType + ";q=0, */*", GeoJSONContentType},
+		{"text/html, */*;q=0.1", JSONContentType},
+		{"not a media type, " + KMLContentType, KMLContentType},
+		{JSONContentType + ";q=bogus", JSONContentType},
+		{"text/html", ""},
+		{"*/*;q=0", ""},
+		{JSONContentType + ";q=0, " + GeoJSONContentType + ";q=0, " + KMLContentType + ";q=0", ""},
+	}
+
+	for _, tt := range tests {
+		encoder, ok := negotiate(tt.accept, encoders)
+
+		got := ""
+		if ok {
+			got = encoder.ContentType()
+		}
+		if got != tt.want {
+			t.Errorf("negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestMatchMediaRange(t *testing.T) {
+	tests := []struct {
+		mediaRange, mediaType string
+		want                  int
+	}{
+		{"*/*", JSONContentType, 0},
+		{"application/*", JSONContentType, 1},
+		{JSONContentType, JSONContentType, 2},
+		{"text/*", JSONContentType, -1},
+		{GeoJSONContentType, JSONContentType, -1},
+		{"application", JSONContentType, -1},
+	}
+
+	for _, tt := range tests {
+		if got := matchMediaRange(tt.mediaRange, tt.mediaType); got != tt.want {
+			t.Errorf("matchMediaRange(%q, %q) = %d, want %d", tt.mediaRange, tt.mediaType, got, tt.want)
+		}
+	}
+}
+
+// pointSource yields count features spread along the equator.
+type pointSource int
+
+func (s pointSource) EachFeature(fn func(geo.Feature) error) error {
+	for i := range int(s) {
+		feature := geo.Feature{
+			Id:         fmt.Sprint(i),
+			Name:       fmt.Sprintf("post <%d>", i),
+			Coordinate: geo.Coordinate{Latitude: 0, Longitude: float64(i%360 - 180)},
+			Properties: []geo.Property{{Key: "index", Value: i}},
+		}
+		if err := fn(feature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestGeoJSONEncoderStreams(t *testing.T) {
+	for _, count := range []int{0, 1, featuresPerFlush + 1} {
+		w := httptest.NewRecorder()
+		if err := (GeoJSONEncoder{}).Encode(w, pointSource(count)); err != nil {
+			t.Fatalf("%d features: Encode() error = %v", count, err)
+		}
+
+		if got := w.Header().Get("Content-Type"); got != GeoJSONContentType {
+			t.Errorf("Content-Type = %q, want %q", got, GeoJSONContentType)
+		}
+
+		var collection struct {
+			Type     string `json:"type"`
+			Features []struct {
+				Id       string `json:"id"`
+				Geometry struct {
+					Type        string    `json:"type"`
+					Coordinates []float64 `json:"coordinates"`
+				} `json:"geometry"`
+			} `json:"features"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &collection); err != nil {
+			t.Fatalf("%d features: invalid GeoJSON: %v\n%s", count, err, w.Body.String())
+		}
+		if collection.Type != "FeatureCollection" || len(collection.Features) != count {
+			t.Errorf("%d features: got %s with %d features", count, collection.Type, len(collection.Features))
+		}
+		if count > 0 {
+			if geometry := collection.Features[count-1].Geometry; geometry.Type != "Point" || len(geometry.Coordinates) != 2 {
+				t.Errorf("%d features: last geometry = %+v", count, geometry)
+			}
+		}
+	}
+}
+
+func TestKMLEncoderStreams(t *testing.T) {
+	for _, count := range []int{0, 1, featuresPerFlush + 1} {
+		w := httptest.NewRecorder()
+		if err := (KMLEncoder{DocumentName: "posts & more"}).Encode(w, pointSource(count)); err != nil {
+			t.Fatalf("%d features: Encode() error = %v", count, err)
+		}
+
+		if got := w.Header().Get("Content-Type"); got != KMLContentType {
+			t.Errorf("Content-Type = %q, want %q", got, KMLContentType)
+		}
+
+		var document struct {
+			Document struct {
+				Name       string `xml:"name"`
+				Placemarks []struct {
+					Name        string `xml:"name"`
+					Coordinates string `xml:"Point>coordinates"`
+				} `xml:"Placemark"`
+			} `xml:"Document"`
+		}
+		if err := xml.Unmarshal(w.Body.Bytes(), &document); err != nil {
+			t.Fatalf("%d features: invalid KML: %v\n%s", count, err, w.Body.String())
+		}
+		if document.Document.Name != "posts & more" || len(document.Document.Placemarks) != count {
+			t.Errorf("%d features: got %q with %d placemarks", count, document.Document.Name, len(document.Document.Placemarks))
+		}
+		if count > 0 && document.Document.Placemarks[0].Name != "post <0>" {
+			t.Errorf("%d features: first placemark = %+v", count, document.Document.Placemarks[0])
+		}
+	}
+}
+
+func TestFeatureEncodersRefuseOtherResults(t *testing.T) {
+	for _, encoder := range []Encoder{GeoJSONEncoder{}, KMLEncoder{}} {
+		if err := encoder.Encode(httptest.NewRecorder(), map[string]string{}); !stderr.Is(err, errors.ErrNotAcceptable) {
+			t.Errorf("%s: Encode() error = %v, want %v", encoder.ContentType(), err, errors.ErrNotAcceptable)
+		}
+	}
+}
+
+func TestHandleNotAcceptable(t *testing.T) {
+	called := false
+	handler := Handle(func(r *http.Request) (any, error) {
+		called = true
+		return pointSource(1), nil
+	}, GeoJSONEncoder{})
+
+	r := httptest.NewRequest(http.MethodGet, "/posts/", nil)
+	r.Header.Set("Accept", "text/html")
+	httpError := &errors.HttpError{}
+	r = r.WithContext(context.WithValue(r.Context(), CtxErrorKey, httpError))
+
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	if httpError.Code != http.StatusNotAcceptable {
+		t.Errorf("code = %d, want %d", httpError.Code, http.StatusNotAcceptable)
+	}
+	if called {
+		t.Error("the handler ran for an unacceptable request")
+	}
+	if w.Header().Get("Vary") != "Accept" {
+		t.Errorf("Vary = %q, want Accept", w.Header().Get("Vary"))
+	}
+}
+
+func TestHandleResponseType(t *testing.T) {
+	var got string
+	handler := Handle(func(r *http.Request) (any, error) {
+		got = ResponseType(r)
+		return pointSource(1), nil
+	}, GeoJSONEncoder{}, KMLEncoder{})
+
+	r := httptest.NewRequest(http.MethodGet, "/posts/", nil)
+	r.Header.Set("Accept", KMLContentType)
+	r = r.WithContext(context.WithValue(r.Context(), CtxErrorKey, &errors.HttpError{}))
+
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	if got != KMLContentType {
+		t.Errorf("ResponseType() = %q, want %q", got, KMLContentType)
+	}
+	if w.Header().Get("Content-Type") != KMLContentType {
+		t.Errorf("Content-Type = %q, want %q", w.Header().Get("Content-Type"), KMLContentType)
+	}
+}
@@ -0,0 +1,107 @@
+package web
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+)
+
+// ProxyConfig lists the reverse proxies whose forwarding headers are trusted,
+// as comma-separated addresses or CIDR ranges. Requests from anywhere else are
+// attributed to their connection address.
+type ProxyConfig struct {
+	TrustedProxies string `env:"TRUSTED_PROXIES" mapstructure:"TRUSTED_PROXIES"`
+}
+
+var trustedProxies atomic.Pointer[[]netip.Prefix]
+
+func UpdateProxyConfig(cfg *ProxyConfig) error {
+	prefixes := make([]netip.Prefix, 0)
+
+	for _, raw := range strings.Split(cfg.TrustedProxies, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		if !strings.Contains(raw, "/") {
+			addr, err := netip.ParseAddr(raw)
+			if err != nil {
+				return fmt.Errorf("web: invalid TRUSTED_PROXIES entry %q", raw)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return fmt.Errorf("web: invalid TRUSTED_PROXIES entry %q", raw)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	trustedProxies.Store(&prefixes)
+
+	return nil
+}
+
+// GetClientIp returns the connection address unless it belongs to a trusted
+// proxy. Behind one, X-Forwarded-For is read right to left and the first hop
+// that is not itself a trusted proxy wins, since everything to its left was
+// supplied by the client; X-Real-IP is used when there is no such chain.
+func GetClientIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !isTrustedProxy(host) {
+		return host
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if _, err := netip.ParseAddr(hop); err != nil {
+				break
+			}
+			if !isTrustedProxy(hop) {
+				return hop
+			}
+			host = hop
+		}
+		return host
+	}
+
+	if realIp := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIp != "" {
+		if _, err := netip.ParseAddr(realIp); err == nil {
+			return realIp
+		}
+	}
+
+	return host
+}
+
+func isTrustedProxy(ip string) bool {
+	prefixes := trustedProxies.Load()
+	if prefixes == nil {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range *prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetClientIp(t *testing.T) {
+	if err := UpdateProxyConfig(&ProxyConfig{TrustedProxies: "10.0.0.0/8, 192.168.1.1"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = UpdateProxyConfig(&ProxyConfig{}) })
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		realIp     string
+		forwarded  string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "untrusted peer spoofing headers", remoteAddr: "203.0.113.7:5000", realIp: "1.2.3.4", forwarded: "1.2.3.4", want: "203.0.113.7"},
+		{name: "trusted proxy forwarded", remoteAddr: "10.1.2.3:5000", forwarded: "198.51.100.9", want: "198.51.100.9"},
+		{name: "client prefix ignored", remoteAddr: "10.1.2.3:5000", forwarded: "1.2.3.4, 198.51.100.9", want: "198.51.100.9"},
+		{name: "proxy chain", remoteAddr: "10.1.2.3:5000", forwarded: "198.51.100.9, 192.168.1.1", want: "198.51.100.9"},
+		{name: "garbage hop", remoteAddr: "10.1.2.3:5000", forwarded: "nonsense", want: "10.1.2.3"},
+		{name: "trusted proxy real ip", remoteAddr: "192.168.1.1:5000", realIp: "198.51.100.9", want: "198.51.100.9"},
+		{name: "trusted proxy without headers", remoteAddr: "10.1.2.3:5000", want: "10.1.2.3"},
+		{name: "ipv4 mapped peer", remoteAddr: "[::ffff:10.1.2.3]:5000", forwarded: "198.51.100.9", want: "198.51.100.9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.realIp != "" {
+				r.Header.Set("X-Real-IP", tt.realIp)
+			}
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			if got := GetClientIp(r); got != tt.want {
+				t.Errorf("GetClientIp() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateProxyConfigRejectsInvalidEntries(t *testing.T) {
+	if err := UpdateProxyConfig(&ProxyConfig{TrustedProxies: "10.0.0.0/33"}); err == nil {
+		t.Error("expected an error for an invalid prefix")
+	}
+	if err := UpdateProxyConfig(&ProxyConfig{TrustedProxies: "proxy.local"}); err == nil {
+		t.Error("expected an error for a hostname")
+	}
+}
@@ -0,0 +1,58 @@
+package web
+
+import (
+	"encoding/json"
+	stderr "errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+func HasExpand(r *http.Request, field string) bool {
+	for _, value := range strings.Split(r.FormValue(ExpandValue), ",") {
+		if strings.TrimSpace(value) == field {
+			return true
+		}
+	}
+	return false
+}
+
+func DecodeJSON(r *http.Request, v any) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil || stderr.Is(err, io.EOF) {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if stderr.As(err, &typeErr) && typeErr.Field != "" {
+		return errors.FieldErrors{jsonFieldName(typeErr.Field): "must be " + jsonTypeName(typeErr.Type)}.AsError()
+	}
+
+	return errors.ErrInvalidRequestBody
+}
+
+func jsonFieldName(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+func jsonTypeName(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a number"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,73 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type Redirect struct {
+	Url     string
+	Cookies []*http.Cookie
+}
+
+func (r *Redirect) WriteResponse(w http.ResponseWriter) error {
+	for _, cookie := range r.Cookies {
+		http.SetCookie(w, cookie)
+	}
+
+	w.Header().Set("Location", r.Url)
+	w.WriteHeader(http.StatusFound)
+
+	return nil
+}
+
+type WithCookies struct {
+	Data    any
+	Cookies []*http.Cookie
+}
+
+// Blob writes a raw body with validator headers, answering 304 Not Modified
+// when the request already holds the current ETag.
+type Blob struct {
+	ContentType  string
+	Body         []byte
+	ETag         string
+	CacheControl string
+	NotModified  bool
+}
+
+func (b *Blob) WriteResponse(w http.ResponseWriter) error {
+	header := w.Header()
+	if b.ETag != "" {
+		header.Set("ETag", b.ETag)
+	}
+	if b.CacheControl != "" {
+		header.Set("Cache-Control", b.CacheControl)
+	}
+
+	if b.NotModified {
+		header.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	header.Set("Content-Type", b.ContentType)
+	header.Set("Content-Length", strconv.Itoa(len(b.Body)))
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(b.Body)
+	return err
+}
+
+// ETagMatches reports whether If-None-Match lists etag, comparing weakly as
+// RFC 9110 requires for GET.
+func ETagMatches(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
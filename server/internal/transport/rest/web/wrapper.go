@@ -1,7 +1,6 @@
 package web
 
 import (
-	"encoding/json"
 	"net/http"
 	"reflect"
 
@@ -10,10 +9,33 @@ import (
 
 type Handler func(r *http.Request) (any, error)
 
-func Handle(handler Handler) http.HandlerFunc {
+type Response interface {
+	WriteResponse(w http.ResponseWriter) error
+}
+
+// Handle runs the handler and writes its result as JSON, or with one of the
+// extra encoders when the Accept header prefers it.
+func Handle(handler Handler, encoders ...Encoder) http.HandlerFunc {
+	encoders = append([]Encoder{JSONEncoder{}}, encoders...)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		httpError := GetHttpErrorFromCtx(r.Context())
 
+		encoder := encoders[0]
+		if len(encoders) > 1 {
+			w.Header().Add("Vary", "Accept")
+
+			var ok bool
+			if encoder, ok = negotiate(r.Header.Get("Accept"), encoders); !ok {
+				parsedErr := errors.ParseHttpError(errors.ErrNotAcceptable)
+				httpError.Err = parsedErr.Err
+				httpError.Code = parsedErr.Code
+
+				return
+			}
+			r = withResponseType(r, encoder.ContentType())
+		}
+
 		data, err := handler(r)
 
 		if err != nil {
@@ -24,34 +46,108 @@ func Handle(handler Handler) http.HandlerFunc {
 			return
 		}
 
-		accessToken, ok := hasAccessToken(data)
-		if ok {
+		if withCookies, ok := data.(*WithCookies); ok {
+			for _, cookie := range withCookies.Cookies {
+				http.SetCookie(w, cookie)
+			}
+			data = withCookies.Data
+		}
+
+		if response, ok := data.(Response); ok {
+			if err = response.WriteResponse(w); err != nil {
+				parsedErr := errors.ParseHttpError(err)
+				httpError.Err = parsedErr.Err
+				httpError.Code = parsedErr.Code
+			}
+
+			return
+		}
+
+		if IsCookieAuthMode(r) {
+			accessToken, _ := takeStringField(data, "AccessToken")
+			refreshToken, _ := takeStringField(data, "RefreshToken")
+
+			cookies, err := AuthCookies(accessToken, refreshToken)
+			if err != nil {
+				parsedErr := errors.ParseHttpError(err)
+				httpError.Err = parsedErr.Err
+				httpError.Code = parsedErr.Code
+
+				return
+			}
+
+			for _, cookie := range cookies {
+				http.SetCookie(w, cookie)
+			}
+		} else if accessToken, ok := hasAccessToken(data); ok && accessToken != "" {
 			w.Header().Set("Authorization", "Bearer "+accessToken)
 		}
 
 		if data != nil {
-			json.NewEncoder(w).Encode(data)
+			tracked := &trackingWriter{ResponseWriter: w}
+			if err = encoder.Encode(tracked, data); err != nil {
+				// A streamed body cannot be replaced by an error once it has
+				// started, so the connection is dropped instead.
+				if tracked.written {
+					panic(http.ErrAbortHandler)
+				}
+
+				parsedErr := errors.ParseHttpError(err)
+				httpError.Err = parsedErr.Err
+				httpError.Code = parsedErr.Code
+			}
 		}
 	}
 }
 
+type trackingWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *trackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func hasAccessToken(v any) (string, bool) {
+	field, ok := stringField(v, "AccessToken")
+	if !ok {
+		return "", false
+	}
+	return field.String(), true
+}
+
+func takeStringField(v any, name string) (string, bool) {
+	field, ok := stringField(v, name)
+	if !ok {
+		return "", false
+	}
+
+	value := field.String()
+	if field.CanSet() {
+		field.SetString("")
+	}
+	return value, true
+}
+
+func stringField(v any, name string) (reflect.Value, bool) {
 	val := reflect.ValueOf(v)
 
 	if val.Kind() == reflect.Ptr {
 		val = val.Elem()
 	}
 	if val.Kind() != reflect.Struct {
-		return "", false
+		return reflect.Value{}, false
 	}
 
-	field := val.FieldByName("AccessToken")
-	if !field.IsValid() {
-		return "", false
+	field := val.FieldByName(name)
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return reflect.Value{}, false
 	}
-
-	if field.Kind() != reflect.String {
-		return "", false
-	}
-	return field.String(), true
+	return field, true
 }
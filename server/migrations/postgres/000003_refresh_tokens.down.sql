@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS refresh_tokens;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS refresh_token TEXT,
+    ADD COLUMN IF NOT EXISTS refresh_token_expiry_time TIMESTAMP WITH TIME ZONE;
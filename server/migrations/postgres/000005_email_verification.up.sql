@@ -0,0 +1,20 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE users SET verified = TRUE, verified_at = NOW();
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+    token_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    purpose TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT fk_user_tokens_user
+                                 FOREIGN KEY (user_id)
+                                 REFERENCES users(user_id)
+                                 ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_purpose ON user_tokens (user_id, purpose, created_at DESC);
@@ -0,0 +1,7 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user',
+    ADD COLUMN IF NOT EXISTS banned_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS ban_reason TEXT,
+    ADD CONSTRAINT chk_users_role CHECK (role IN ('user', 'moderator', 'admin'));
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users (role);
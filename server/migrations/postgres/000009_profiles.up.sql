@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS profiles (
+    user_id UUID PRIMARY KEY,
+    handle VARCHAR(32) UNIQUE NOT NULL,
+    display_name VARCHAR(64) NOT NULL DEFAULT '',
+    bio TEXT NOT NULL DEFAULT '',
+    avatar_key TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT fk_profiles_user
+                                 FOREIGN KEY (user_id)
+                                 REFERENCES users(user_id)
+                                 ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_profiles_modtime
+    BEFORE UPDATE ON profiles
+    FOR EACH ROW
+EXECUTE FUNCTION update_modified_column();
+
+INSERT INTO profiles (user_id, handle)
+SELECT user_id, 'user_' || SUBSTRING(REPLACE(user_id::TEXT, '-', '') FOR 12)
+FROM users
+ON CONFLICT (user_id) DO NOTHING;
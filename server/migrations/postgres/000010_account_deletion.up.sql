@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS account_deletions (
+    user_id UUID PRIMARY KEY,
+    step TEXT NOT NULL,
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    CONSTRAINT fk_account_deletions_user
+                                 FOREIGN KEY (user_id)
+                                 REFERENCES users(user_id)
+                                 ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_deletions_scheduled ON account_deletions (scheduled_for);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS update_posts_location ON posts;
+DROP FUNCTION IF EXISTS update_post_location();
+DROP INDEX IF EXISTS idx_posts_location;
+DROP INDEX IF EXISTS idx_posts_geohash;
+ALTER TABLE posts DROP COLUMN IF EXISTS location;
+ALTER TABLE posts DROP COLUMN IF EXISTS geohash;
+DROP FUNCTION IF EXISTS geohash_encode(DOUBLE PRECISION, DOUBLE PRECISION, INT);
@@ -0,0 +1,97 @@
+CREATE OR REPLACE FUNCTION geohash_encode(
+    lat DOUBLE PRECISION,
+    lon DOUBLE PRECISION,
+    hash_precision INT
+)
+    RETURNS TEXT AS $$
+DECLARE
+    alphabet CONSTANT TEXT := '0123456789bcdefghjkmnpqrstuvwxyz';
+    lat_min DOUBLE PRECISION := -90;
+    lat_max DOUBLE PRECISION := 90;
+    lon_min DOUBLE PRECISION := -180;
+    lon_max DOUBLE PRECISION := 180;
+    mid DOUBLE PRECISION;
+    hash TEXT := '';
+    bits INT := 0;
+    bit INT := 0;
+    even BOOLEAN := TRUE;
+BEGIN
+    IF lat IS NULL OR lon IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    WHILE length(hash) < hash_precision LOOP
+        IF even THEN
+            mid := (lon_min + lon_max) / 2;
+            IF lon >= mid THEN
+                bits := bits * 2 + 1;
+                lon_min := mid;
+            ELSE
+                bits := bits * 2;
+                lon_max := mid;
+            END IF;
+        ELSE
+            mid := (lat_min + lat_max) / 2;
+            IF lat >= mid THEN
+                bits := bits * 2 + 1;
+                lat_min := mid;
+            ELSE
+                bits := bits * 2;
+                lat_max := mid;
+            END IF;
+        END IF;
+
+        even := NOT even;
+        bit := bit + 1;
+
+        IF bit = 5 THEN
+            hash := hash || substr(alphabet, bits + 1, 1);
+            bits := 0;
+            bit := 0;
+        END IF;
+    END LOOP;
+
+    RETURN hash;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE PARALLEL SAFE;
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS geohash TEXT COLLATE "C"
+    GENERATED ALWAYS AS (geohash_encode(latitude, longitude, 12)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_posts_geohash ON posts (geohash);
+
+DO $$
+BEGIN
+    BEGIN
+        CREATE EXTENSION IF NOT EXISTS postgis;
+    EXCEPTION WHEN OTHERS THEN
+        RAISE NOTICE 'postgis is unavailable (%), proximity queries will use the geohash index', SQLERRM;
+        RETURN;
+    END;
+
+    ALTER TABLE posts ADD COLUMN IF NOT EXISTS location geography(Point, 4326);
+
+    UPDATE posts SET location = ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography
+    WHERE latitude IS NOT NULL AND longitude IS NOT NULL AND location IS NULL;
+
+    CREATE OR REPLACE FUNCTION update_post_location()
+        RETURNS TRIGGER AS $fn$
+    BEGIN
+        IF NEW.latitude IS NULL OR NEW.longitude IS NULL THEN
+            NEW.location = NULL;
+        ELSE
+            NEW.location = ST_SetSRID(ST_MakePoint(NEW.longitude, NEW.latitude), 4326)::geography;
+        END IF;
+        RETURN NEW;
+    END;
+    $fn$ LANGUAGE plpgsql;
+
+    DROP TRIGGER IF EXISTS update_posts_location ON posts;
+    CREATE TRIGGER update_posts_location
+        BEFORE INSERT OR UPDATE OF latitude, longitude ON posts
+        FOR EACH ROW
+    EXECUTE FUNCTION update_post_location();
+
+    CREATE INDEX IF NOT EXISTS idx_posts_location ON posts USING GIST (location);
+END
+$$;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_posts_user_page;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_posts_user_page ON posts (user_id, created_at DESC, post_id DESC);
@@ -0,0 +1 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS message_count INTEGER NOT NULL DEFAULT 0;
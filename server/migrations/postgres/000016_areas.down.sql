@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS post_areas;
+DROP TABLE IF EXISTS areas;
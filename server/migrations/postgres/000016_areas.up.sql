@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS areas (
+    area_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    slug VARCHAR(64) UNIQUE NOT NULL,
+    name TEXT NOT NULL,
+    geometry JSONB NOT NULL,
+    min_latitude DOUBLE PRECISION NOT NULL,
+    min_longitude DOUBLE PRECISION NOT NULL,
+    max_latitude DOUBLE PRECISION NOT NULL,
+    max_longitude DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_areas_bounds ON areas (min_latitude, max_latitude);
+
+CREATE TABLE IF NOT EXISTS post_areas (
+    post_id UUID NOT NULL,
+    area_id UUID NOT NULL,
+    PRIMARY KEY (post_id, area_id),
+    CONSTRAINT fk_post_areas_post
+                                 FOREIGN KEY (post_id)
+                                 REFERENCES posts(post_id)
+                                 ON DELETE CASCADE,
+    CONSTRAINT fk_post_areas_area
+                                 FOREIGN KEY (area_id)
+                                 REFERENCES areas(area_id)
+                                 ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_areas_area ON post_areas (area_id);
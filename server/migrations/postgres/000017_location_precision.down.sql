@@ -0,0 +1,5 @@
+UPDATE posts SET latitude = exact_latitude, longitude = exact_longitude WHERE exact_latitude IS NOT NULL;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS exact_longitude;
+ALTER TABLE posts DROP COLUMN IF EXISTS exact_latitude;
+ALTER TABLE posts DROP COLUMN IF EXISTS location_precision;
@@ -0,0 +1,7 @@
+-- latitude and longitude hold the public location every spatial index and
+-- query works on; the author's exact point is kept alongside it.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS location_precision VARCHAR(16) NOT NULL DEFAULT 'exact';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS exact_latitude DOUBLE PRECISION;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS exact_longitude DOUBLE PRECISION;
+
+UPDATE posts SET exact_latitude = latitude, exact_longitude = longitude WHERE exact_latitude IS NULL;
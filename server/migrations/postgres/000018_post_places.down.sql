@@ -0,0 +1,3 @@
+ALTER TABLE posts DROP COLUMN IF EXISTS locality;
+ALTER TABLE posts DROP COLUMN IF EXISTS region;
+ALTER TABLE posts DROP COLUMN IF EXISTS country;
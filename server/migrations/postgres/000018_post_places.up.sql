@@ -0,0 +1,3 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS country TEXT;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS region TEXT;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS locality TEXT;
@@ -0,0 +1,2 @@
+-- The addresses as originally typed are not kept, so there is nothing to restore.
+SELECT 1;
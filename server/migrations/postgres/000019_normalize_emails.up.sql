@@ -0,0 +1,24 @@
+-- Sign-in and password reset look addresses up in the form mail.Normalize
+-- produces: the local part as typed, the domain lowercased and without a
+-- trailing dot. Rewrite older ASCII addresses into that form. Addresses with
+-- non-ASCII domains need punycode and are rewritten by the server at startup.
+--
+-- When several accounts normalize to the same address, an account already in
+-- that form keeps it, then a verified one, then the lowest id. The others keep
+-- their stored address and can no longer sign in with it.
+WITH candidates AS (
+    SELECT user_id, email, verified,
+           substring(email FROM '^(.*)@[^@]*$') || '@' || lower(rtrim(substring(email FROM '@([^@]*)$'), '.')) AS normalized
+    FROM users
+    WHERE octet_length(email) = char_length(email) AND position('@' IN email) > 0
+), ranked AS (
+    SELECT user_id, email, normalized,
+           row_number() OVER (PARTITION BY normalized ORDER BY email = normalized DESC, verified DESC, user_id) AS rank
+    FROM candidates
+)
+UPDATE users u SET email = r.normalized
+FROM ranked r
+WHERE u.user_id = r.user_id
+    AND r.rank = 1
+    AND r.email <> r.normalized
+    AND NOT EXISTS (SELECT 1 FROM users o WHERE o.email = r.normalized AND o.user_id <> r.user_id);
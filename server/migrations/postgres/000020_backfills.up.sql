@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS backfills
+(
+    name         TEXT PRIMARY KEY,
+    completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
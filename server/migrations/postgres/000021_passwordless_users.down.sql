@@ -0,0 +1,4 @@
+-- An empty hash never matches a password, so these accounts stay passwordless.
+UPDATE users SET password_hash = '' WHERE password_hash IS NULL;
+
+ALTER TABLE users ALTER COLUMN password_hash SET NOT NULL;
@@ -0,0 +1,3 @@
+-- Accounts created through an identity provider have no password until the
+-- user sets one with a password reset.
+ALTER TABLE users ALTER COLUMN password_hash DROP NOT NULL;
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"sort"
+	"strings"
 )
 
 type HttpError struct {
@@ -35,18 +37,46 @@ func ParseHttpError(err error) *HttpError {
 }
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error  string            `json:"error"`
+	Fields map[string]string `json:"fields,omitempty"`
 }
 
 func (e *HttpError) MarshalJSON() ([]byte, error) {
-	resp := ErrorResponse{ErrUnknownError.Error()}
+	resp := ErrorResponse{Error: ErrUnknownError.Error()}
 
 	if e.HasError() {
 		resp.Error = e.Error()
+
+		var fields FieldErrors
+		if errors.As(e.Err, &fields) {
+			resp.Error = errInvalidFields.Error()
+			resp.Fields = fields
+		}
 	}
 	return json.Marshal(resp)
 }
 
+type FieldErrors map[string]string
+
+func (f FieldErrors) Error() string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return errInvalidFields.Error() + ": " + strings.Join(names, ", ")
+}
+
+func (f FieldErrors) AsError() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return NewHttpError(f, http.StatusBadRequest)
+}
+
+var errInvalidFields = errors.New("invalid request fields")
+
 func MarshalError(err error) []byte {
 	httpError := ParseHttpError(err)
 	data, _ := json.Marshal(httpError)
@@ -68,4 +98,50 @@ var (
 	ErrUnknownError                = NewHttpError(errors.New("unknown error"), http.StatusInternalServerError)
 	ErrMsgNotFound                 = NewHttpError(errors.New("message not found"), http.StatusNotFound)
 	ErrInvalidMsgId                = NewHttpError(errors.New("invalid message id"), http.StatusBadRequest)
+	ErrRefreshTokenReused          = NewHttpError(errors.New("refresh token reuse detected"), http.StatusUnauthorized)
+	ErrSessionNotFound             = NewHttpError(errors.New("session not found"), http.StatusNotFound)
+	ErrSessionRevoked              = NewHttpError(errors.New("session revoked"), http.StatusUnauthorized)
+	ErrEmailNotVerified            = NewHttpError(errors.New("email not verified"), http.StatusForbidden)
+	ErrEmailAlreadyVerified        = NewHttpError(errors.New("email already verified"), http.StatusBadRequest)
+	ErrTooManyRequests             = NewHttpError(errors.New("too many requests"), http.StatusTooManyRequests)
+	ErrDisposableEmail             = NewHttpError(errors.New("disposable email addresses are not allowed"), http.StatusBadRequest)
+	ErrTwoFactorAlreadyEnabled     = NewHttpError(errors.New("two-factor authentication already enabled"), http.StatusBadRequest)
+	ErrTwoFactorNotEnabled         = NewHttpError(errors.New("two-factor authentication not enabled"), http.StatusBadRequest)
+	ErrTwoFactorUnavailable        = NewHttpError(errors.New("two-factor authentication is not configured"), http.StatusServiceUnavailable)
+	ErrInvalidTwoFactorCode        = NewHttpError(errors.New("invalid two-factor code"), http.StatusUnauthorized)
+	ErrUserNotFound                = NewHttpError(errors.New("user not found"), http.StatusNotFound)
+	ErrUserBanned                  = NewHttpError(errors.New("user is banned"), http.StatusForbidden)
+	ErrInvalidRole                 = NewHttpError(errors.New("invalid role"), http.StatusBadRequest)
+	ErrInvalidUserId               = NewHttpError(errors.New("invalid user id"), http.StatusBadRequest)
+	ErrInvalidQuery                = NewHttpError(errors.New("invalid query parameters"), http.StatusBadRequest)
+	ErrInvalidCredentials          = NewHttpError(errors.New("invalid email or password"), http.StatusUnauthorized)
+	ErrTooManyAttempts             = NewHttpError(errors.New("too many failed login attempts, try again later"), http.StatusTooManyRequests)
+	ErrProfileNotFound             = NewHttpError(errors.New("profile not found"), http.StatusNotFound)
+	ErrHandleTaken                 = NewHttpError(errors.New("handle already taken"), http.StatusConflict)
+	ErrInvalidHandle               = NewHttpError(errors.New("handle must be 3-32 characters of a-z, 0-9 or _"), http.StatusBadRequest)
+	ErrInvalidProfile              = NewHttpError(errors.New("invalid profile fields"), http.StatusBadRequest)
+	ErrInvalidAvatar               = NewHttpError(errors.New("avatar must be a png, jpeg, gif or webp image"), http.StatusBadRequest)
+	ErrAvatarTooLarge              = NewHttpError(errors.New("avatar is too large"), http.StatusRequestEntityTooLarge)
+	ErrAccountDeletionInProgress   = NewHttpError(errors.New("account is being deleted"), http.StatusGone)
+	ErrApiKeyNotFound              = NewHttpError(errors.New("api key not found"), http.StatusNotFound)
+	ErrInvalidScope                = NewHttpError(errors.New("invalid api key scope"), http.StatusBadRequest)
+	ErrInsufficientScope           = NewHttpError(errors.New("api key lacks the required scope"), http.StatusForbidden)
+	ErrInvalidApiKeyName           = NewHttpError(errors.New("api key name must be 1-64 characters"), http.StatusBadRequest)
+	ErrWeakPassword                = NewHttpError(errors.New("password is too weak"), http.StatusBadRequest)
+	ErrPasswordNotSet              = NewHttpError(errors.New("account has no password, set one with a password reset"), http.StatusConflict)
+	ErrUnknownOIDCProvider         = NewHttpError(errors.New("unknown identity provider"), http.StatusNotFound)
+	ErrInvalidOIDCState            = NewHttpError(errors.New("invalid or expired sign-in state"), http.StatusBadRequest)
+	ErrOIDCProviderFailed          = NewHttpError(errors.New("identity provider sign-in failed"), http.StatusBadGateway)
+	ErrOIDCEmailNotVerified        = NewHttpError(errors.New("identity provider did not return a verified email"), http.StatusForbidden)
+	ErrOIDCAccountNotVerified      = NewHttpError(errors.New("an unverified account already uses this email, verify it before linking"), http.StatusConflict)
+	ErrIdentityAlreadyLinked       = NewHttpError(errors.New("identity already linked to another account"), http.StatusConflict)
+	ErrInvalidRequestBody          = NewHttpError(errors.New("invalid request body"), http.StatusBadRequest)
+	ErrAreaNotFound                = NewHttpError(errors.New("area not found"), http.StatusNotFound)
+	ErrAreaSlugTaken               = NewHttpError(errors.New("area slug is already taken"), http.StatusConflict)
+	ErrPlaceNotFound               = NewHttpError(errors.New("no known place near this location"), http.StatusNotFound)
+	ErrGeocoderUnavailable         = NewHttpError(errors.New("reverse geocoding is not configured"), http.StatusServiceUnavailable)
+	ErrNotAcceptable               = NewHttpError(errors.New("requested media type is not available"), http.StatusNotAcceptable)
+	ErrInvalidTile                 = NewHttpError(errors.New("invalid tile coordinates"), http.StatusBadRequest)
+	ErrInvalidCursor               = NewHttpError(errors.New("invalid or expired cursor"), http.StatusBadRequest)
+	ErrInvalidCSRFToken            = NewHttpError(errors.New("missing or invalid csrf token"), http.StatusForbidden)
 )
@@ -0,0 +1,141 @@
+package geo
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+const EarthRadius = 6371.0
+
+type BBox struct {
+	SouthWest Coordinate `json:"south_west"`
+	NorthEast Coordinate `json:"north_east"`
+}
+
+// Normalize validates the box and wraps both corners into [-180, 180]. A box
+// whose west edge ends up east of its east edge crosses the antimeridian.
+func (b BBox) Normalize() (BBox, error) {
+	fields := b.Validate()
+	if len(fields) > 0 {
+		return BBox{}, fields.AsError()
+	}
+
+	west, east := b.SouthWest.Longitude, b.NorthEast.Longitude
+	if east-west >= 2*MaxLongitude {
+		west, east = -MaxLongitude, MaxLongitude
+	} else {
+		west, east = WrapLongitude(west), WrapLongitude(east)
+		if east == -MaxLongitude && b.NorthEast.Longitude > -MaxLongitude {
+			east = MaxLongitude
+		}
+	}
+
+	return BBox{
+		SouthWest: Coordinate{Latitude: b.SouthWest.Latitude, Longitude: west},
+		NorthEast: Coordinate{Latitude: b.NorthEast.Latitude, Longitude: east},
+	}, nil
+}
+
+func (b BBox) Validate() errors.FieldErrors {
+	fields := errors.FieldErrors{}
+
+	for field, message := range b.SouthWest.Validate() {
+		fields["south_west."+field] = message
+	}
+	for field, message := range b.NorthEast.Validate() {
+		fields["north_east."+field] = message
+	}
+
+	if len(fields) == 0 && b.SouthWest.Latitude > b.NorthEast.Latitude {
+		fields["north_east.latitude"] = "must not be south of south_west.latitude"
+	}
+
+	return fields
+}
+
+func (b BBox) CrossesAntimeridian() bool {
+	return b.SouthWest.Longitude > b.NorthEast.Longitude
+}
+
+// Split returns the box as parts that do not cross the antimeridian.
+func (b BBox) Split() []BBox {
+	if !b.CrossesAntimeridian() {
+		return []BBox{b}
+	}
+
+	return []BBox{
+		{SouthWest: b.SouthWest, NorthEast: Coordinate{Latitude: b.NorthEast.Latitude, Longitude: MaxLongitude}},
+		{SouthWest: Coordinate{Latitude: b.SouthWest.Latitude, Longitude: -MaxLongitude}, NorthEast: b.NorthEast},
+	}
+}
+
+func (b BBox) Contains(c Coordinate) bool {
+	if c.Latitude < b.SouthWest.Latitude || c.Latitude > b.NorthEast.Latitude {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return c.Longitude >= b.SouthWest.Longitude || c.Longitude <= b.NorthEast.Longitude
+	}
+	return c.Longitude >= b.SouthWest.Longitude && c.Longitude <= b.NorthEast.Longitude
+}
+
+// BoundingCircle returns a circle in km that contains a box not crossing the
+// antimeridian. While the box spans at most 180 degrees of longitude its
+// corners are the points farthest from its centre; wider boxes get a circle
+// covering the whole sphere.
+func (b BBox) BoundingCircle() (Coordinate, float64) {
+	center := Coordinate{
+		Latitude:  (b.SouthWest.Latitude + b.NorthEast.Latitude) / 2,
+		Longitude: (b.SouthWest.Longitude + b.NorthEast.Longitude) / 2,
+	}
+
+	if b.NorthEast.Longitude-b.SouthWest.Longitude > MaxLongitude {
+		return center, math.Pi * EarthRadius
+	}
+
+	radius := 0.0
+	for _, corner := range []Coordinate{
+		b.SouthWest,
+		b.NorthEast,
+		{Latitude: b.SouthWest.Latitude, Longitude: b.NorthEast.Longitude},
+		{Latitude: b.NorthEast.Latitude, Longitude: b.SouthWest.Longitude},
+	} {
+		radius = max(radius, Distance(center, corner))
+	}
+
+	return center, radius
+}
+
+// Distance is the Haversine distance in km, matching calculate_distance.
+func Distance(a, b Coordinate) float64 {
+	p := math.Pi / 180
+	h := 0.5 - math.Cos((b.Latitude-a.Latitude)*p)/2 +
+		math.Cos(a.Latitude*p)*math.Cos(b.Latitude*p)*(1-math.Cos((b.Longitude-a.Longitude)*p))/2
+
+	return 2 * EarthRadius * math.Asin(math.Sqrt(min(max(h, 0), 1)))
+}
+
+// ParseBBox parses the "west,south,east,north" form used by map clients.
+func ParseBBox(raw string) (BBox, bool) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return BBox{}, false
+	}
+
+	values := make([]float64, 4)
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BBox{}, false
+		}
+		values[i] = value
+	}
+
+	return BBox{
+		SouthWest: Coordinate{Latitude: values[1], Longitude: values[0]},
+		NorthEast: Coordinate{Latitude: values[3], Longitude: values[2]},
+	}, true
+}
@@ -0,0 +1,114 @@
+package geo
+
+import (
+	"slices"
+	"testing"
+)
+
+func box(south, west, north, east float64) BBox {
+	return BBox{
+		SouthWest: Coordinate{Latitude: south, Longitude: west},
+		NorthEast: Coordinate{Latitude: north, Longitude: east},
+	}
+}
+
+func TestBBoxNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   BBox
+		want BBox
+	}{
+		{"plain", box(10, 10, 20, 20), box(10, 10, 20, 20)},
+		{"wrapped east of the antimeridian", box(-10, 170, 10, 190), box(-10, 170, 10, -170)},
+		{"wrapped west of the antimeridian", box(-10, -190, 10, -170), box(-10, 170, 10, -170)},
+		{"both corners wrapped", box(0, 190, 10, 200), box(0, -170, 10, -160)},
+		{"east edge on the antimeridian", box(0, 170, 10, 180), box(0, 170, 10, 180)},
+		{"whole world", box(-90, -180, 90, 180), box(-90, -180, 90, 180)},
+		{"wider than the world", box(-10, -200, 10, 200), box(-10, -180, 10, 180)},
+		{"whole world shifted", box(-10, 0, 10, 360), box(-10, -180, 10, 180)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.in.Normalize()
+			if err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBBoxNormalizeRejectsInvalidBoxes(t *testing.T) {
+	tests := map[string]BBox{
+		"south above north":   box(20, 0, 10, 10),
+		"latitude off range":  box(-91, 0, 10, 10),
+		"longitude off range": box(0, 0, 10, 361),
+	}
+
+	for name, in := range tests {
+		if _, err := in.Normalize(); err == nil {
+			t.Errorf("%s: Normalize() error = nil", name)
+		}
+	}
+}
+
+func TestBBoxSplit(t *testing.T) {
+	plain := box(0, 10, 10, 20)
+	if got := plain.Split(); !slices.Equal(got, []BBox{plain}) {
+		t.Errorf("Split() of a plain box = %+v", got)
+	}
+
+	crossing := box(-10, 170, 10, -170)
+	want := []BBox{box(-10, 170, 10, 180), box(-10, -180, 10, -170)}
+	if got := crossing.Split(); !slices.Equal(got, want) {
+		t.Errorf("Split() = %+v, want %+v", got, want)
+	}
+
+	for _, part := range crossing.Split() {
+		if part.CrossesAntimeridian() {
+			t.Errorf("part %+v still crosses the antimeridian", part)
+		}
+	}
+}
+
+func TestBBoxContains(t *testing.T) {
+	crossing := box(-10, 170, 10, -170)
+
+	tests := []struct {
+		c    Coordinate
+		want bool
+	}{
+		{Coordinate{Latitude: 0, Longitude: 175}, true},
+		{Coordinate{Latitude: 0, Longitude: -175}, true},
+		{Coordinate{Latitude: 0, Longitude: 180}, true},
+		{Coordinate{Latitude: 0, Longitude: -180}, true},
+		{Coordinate{Latitude: 0, Longitude: 0}, false},
+		{Coordinate{Latitude: 0, Longitude: 160}, false},
+		{Coordinate{Latitude: 11, Longitude: 175}, false},
+	}
+
+	for _, tt := range tests {
+		if got := crossing.Contains(tt.c); got != tt.want {
+			t.Errorf("Contains(%+v) = %v, want %v", tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestParseBBox(t *testing.T) {
+	got, ok := ParseBBox("-10.5, 20,30,40.25")
+	if !ok {
+		t.Fatal("ParseBBox() ok = false")
+	}
+	if want := box(20, -10.5, 40.25, 30); got != want {
+		t.Errorf("ParseBBox() = %+v, want %+v", got, want)
+	}
+
+	for _, raw := range []string{"", "1,2,3", "1,2,3,4,5", "a,2,3,4"} {
+		if _, ok := ParseBBox(raw); ok {
+			t.Errorf("ParseBBox(%q) ok = true", raw)
+		}
+	}
+}
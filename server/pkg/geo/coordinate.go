@@ -0,0 +1,63 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+const (
+	MaxLatitude     = 90.0
+	MaxLongitude    = 180.0
+	maxRawLongitude = 360.0
+)
+
+type Coordinate struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+func NewCoordinate(latitude, longitude float64) (Coordinate, error) {
+	return Coordinate{Latitude: latitude, Longitude: longitude}.Normalize()
+}
+
+// Normalize validates the coordinate and wraps longitudes such as 190 or -181
+// into [-180, 180).
+func (c Coordinate) Normalize() (Coordinate, error) {
+	fields := c.Validate()
+	if len(fields) > 0 {
+		return Coordinate{}, fields.AsError()
+	}
+
+	return Coordinate{Latitude: c.Latitude, Longitude: WrapLongitude(c.Longitude)}, nil
+}
+
+func (c Coordinate) Validate() errors.FieldErrors {
+	fields := errors.FieldErrors{}
+
+	if !IsFinite(c.Latitude) || math.Abs(c.Latitude) > MaxLatitude {
+		fields["latitude"] = fmt.Sprintf("must be a number between %g and %g", -MaxLatitude, MaxLatitude)
+	}
+	if !IsFinite(c.Longitude) || math.Abs(c.Longitude) > maxRawLongitude {
+		fields["longitude"] = fmt.Sprintf("must be a number between %g and %g", -maxRawLongitude, maxRawLongitude)
+	}
+
+	return fields
+}
+
+func WrapLongitude(longitude float64) float64 {
+	if longitude >= -MaxLongitude && longitude < MaxLongitude {
+		return longitude
+	}
+
+	wrapped := math.Mod(longitude+MaxLongitude, 2*MaxLongitude)
+	if wrapped < 0 {
+		wrapped += 2 * MaxLongitude
+	}
+	return wrapped - MaxLongitude
+}
+
+func IsFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,19 @@
+package geo
+
+type Property struct {
+	Key   string
+	Value any
+}
+
+type Feature struct {
+	Id         string
+	Name       string
+	Coordinate Coordinate
+	Properties []Property
+}
+
+// FeatureSource yields point features one at a time, so writers can stream
+// them without holding the whole collection.
+type FeatureSource interface {
+	EachFeature(fn func(Feature) error) error
+}
@@ -0,0 +1,102 @@
+package geojson
+
+import (
+	"encoding/json"
+	stderr "errors"
+
+	"github.com/skrpld/NearBeee/pkg/geo"
+)
+
+var (
+	ErrUnsupportedGeometry = stderr.New("geometry must be a Polygon or MultiPolygon")
+	ErrInvalidPosition     = stderr.New("position must have longitude and latitude")
+)
+
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geometry       `json:"geometry"`
+}
+
+// DecodePolygons reads a Polygon or MultiPolygon geometry, or a Feature
+// wrapping one, into a MultiPolygon.
+func DecodePolygons(data []byte) (geo.MultiPolygon, error) {
+	var g geometry
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, err
+	}
+
+	if g.Type == "Feature" {
+		if g.Geometry == nil {
+			return nil, ErrUnsupportedGeometry
+		}
+		g = *g.Geometry
+	}
+
+	switch g.Type {
+	case "Polygon":
+		var positions [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &positions); err != nil {
+			return nil, err
+		}
+
+		polygon, err := decodePolygon(positions)
+		if err != nil {
+			return nil, err
+		}
+		return geo.MultiPolygon{polygon}, nil
+	case "MultiPolygon":
+		var positions [][][][]float64
+		if err := json.Unmarshal(g.Coordinates, &positions); err != nil {
+			return nil, err
+		}
+
+		polygons := make(geo.MultiPolygon, 0, len(positions))
+		for _, p := range positions {
+			polygon, err := decodePolygon(p)
+			if err != nil {
+				return nil, err
+			}
+			polygons = append(polygons, polygon)
+		}
+		return polygons, nil
+	default:
+		return nil, ErrUnsupportedGeometry
+	}
+}
+
+// EncodePolygons writes the polygons as a MultiPolygon geometry.
+func EncodePolygons(polygons geo.MultiPolygon) ([]byte, error) {
+	positions := make([][][][2]float64, 0, len(polygons))
+	for _, polygon := range polygons {
+		rings := make([][][2]float64, 0, len(polygon))
+		for _, ring := range polygon {
+			points := make([][2]float64, 0, len(ring))
+			for _, c := range ring {
+				points = append(points, [2]float64{c.Longitude, c.Latitude})
+			}
+			rings = append(rings, points)
+		}
+		positions = append(positions, rings)
+	}
+
+	return json.Marshal(struct {
+		Type        string           `json:"type"`
+		Coordinates [][][][2]float64 `json:"coordinates"`
+	}{Type: "MultiPolygon", Coordinates: positions})
+}
+
+func decodePolygon(positions [][][]float64) (geo.Polygon, error) {
+	polygon := make(geo.Polygon, 0, len(positions))
+	for _, ring := range positions {
+		coordinates := make(geo.Ring, 0, len(ring))
+		for _, position := range ring {
+			if len(position) < 2 {
+				return nil, ErrInvalidPosition
+			}
+			coordinates = append(coordinates, geo.Coordinate{Latitude: position[1], Longitude: position[0]})
+		}
+		polygon = append(polygon, coordinates)
+	}
+	return polygon, nil
+}
@@ -0,0 +1,108 @@
+// Package geojson streams point features as an RFC 7946 FeatureCollection.
+package geojson
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/skrpld/NearBeee/pkg/geo"
+)
+
+const ContentType = "application/geo+json"
+
+type Writer struct {
+	w       *bufio.Writer
+	started bool
+	count   int
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (w *Writer) WriteFeature(feature geo.Feature) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	if w.count > 0 {
+		if err := w.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	w.count++
+
+	properties := make([]byte, 0, 64)
+	properties = append(properties, '{')
+	for i, property := range feature.Properties {
+		key, err := json.Marshal(property.Key)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(property.Value)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			properties = append(properties, ',')
+		}
+		properties = append(properties, key...)
+		properties = append(properties, ':')
+		properties = append(properties, value...)
+	}
+	properties = append(properties, '}')
+
+	encoded, err := json.Marshal(struct {
+		Type       string          `json:"type"`
+		Id         string          `json:"id,omitempty"`
+		Geometry   point           `json:"geometry"`
+		Properties json.RawMessage `json:"properties"`
+	}{
+		Type:       "Feature",
+		Id:         feature.Id,
+		Geometry:   point{Type: "Point", Coordinates: [2]float64{feature.Coordinate.Longitude, feature.Coordinate.Latitude}},
+		Properties: properties,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.w.Write(encoded)
+	return err
+}
+
+// Flush pushes buffered features to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Close terminates the collection. It must be called even when no feature
+// was written.
+func (w *Writer) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	if _, err := w.w.WriteString("]}\n"); err != nil {
+		return err
+	}
+
+	return w.w.Flush()
+}
+
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	_, err := w.w.WriteString(`{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+type point struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
@@ -0,0 +1,107 @@
+// Package kml streams point features as a KML 2.2 document.
+package kml
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/skrpld/NearBeee/pkg/geo"
+)
+
+const ContentType = "application/vnd.google-earth.kml+xml"
+
+type Writer struct {
+	w       *bufio.Writer
+	name    string
+	started bool
+}
+
+func NewWriter(w io.Writer, name string) *Writer {
+	return &Writer{w: bufio.NewWriter(w), name: name}
+}
+
+func (w *Writer) WriteFeature(feature geo.Feature) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	w.w.WriteString("<Placemark")
+	if feature.Id != "" {
+		w.w.WriteString(` id="`)
+		w.escape(feature.Id)
+		w.w.WriteString(`"`)
+	}
+	w.w.WriteString("><name>")
+	w.escape(feature.Name)
+	w.w.WriteString("</name>")
+
+	if len(feature.Properties) > 0 {
+		w.w.WriteString("<ExtendedData>")
+		for _, property := range feature.Properties {
+			w.w.WriteString(`<Data name="`)
+			w.escape(property.Key)
+			w.w.WriteString(`"><value>`)
+			w.escape(formatValue(property.Value))
+			w.w.WriteString("</value></Data>")
+		}
+		w.w.WriteString("</ExtendedData>")
+	}
+
+	_, err := fmt.Fprintf(w.w, "<Point><coordinates>%s,%s</coordinates></Point></Placemark>\n",
+		strconv.FormatFloat(feature.Coordinate.Longitude, 'f', -1, 64),
+		strconv.FormatFloat(feature.Coordinate.Latitude, 'f', -1, 64))
+	return err
+}
+
+// Flush pushes buffered placemarks to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Close terminates the document. It must be called even when no feature was
+// written.
+func (w *Writer) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	if _, err := w.w.WriteString("</Document>\n</kml>\n"); err != nil {
+		return err
+	}
+
+	return w.w.Flush()
+}
+
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	w.w.WriteString(xml.Header)
+	w.w.WriteString(`<kml xmlns="http://www.opengis.net/kml/2.2">` + "\n<Document><name>")
+	w.escape(w.name)
+	_, err := w.w.WriteString("</name>\n")
+	return err
+}
+
+func (w *Writer) escape(s string) {
+	_ = xml.EscapeText(w.w, []byte(s))
+}
+
+func formatValue(v any) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case time.Time:
+		return value.UTC().Format(time.RFC3339)
+	case fmt.Stringer:
+		return value.String()
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,116 @@
+package geo
+
+import (
+	"fmt"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+const minRingSize = 4
+
+// Ring is a closed linear ring whose first and last coordinates are equal.
+type Ring []Coordinate
+
+// Polygon holds an exterior ring followed by any number of holes.
+type Polygon []Ring
+
+type MultiPolygon []Polygon
+
+// Contains uses the even-odd rule per polygon, so a point inside a hole is
+// outside the polygon. Points exactly on an edge may fall either way.
+func (m MultiPolygon) Contains(c Coordinate) bool {
+	for _, polygon := range m {
+		inside := false
+		for _, ring := range polygon {
+			if ring.crossings(c)%2 == 1 {
+				inside = !inside
+			}
+		}
+		if inside {
+			return true
+		}
+	}
+	return false
+}
+
+func (m MultiPolygon) Bounds() BBox {
+	box := BBox{
+		SouthWest: Coordinate{Latitude: MaxLatitude, Longitude: MaxLongitude},
+		NorthEast: Coordinate{Latitude: -MaxLatitude, Longitude: -MaxLongitude},
+	}
+
+	for _, polygon := range m {
+		if len(polygon) == 0 {
+			continue
+		}
+		for _, c := range polygon[0] {
+			box.SouthWest.Latitude = min(box.SouthWest.Latitude, c.Latitude)
+			box.SouthWest.Longitude = min(box.SouthWest.Longitude, c.Longitude)
+			box.NorthEast.Latitude = max(box.NorthEast.Latitude, c.Latitude)
+			box.NorthEast.Longitude = max(box.NorthEast.Longitude, c.Longitude)
+		}
+	}
+
+	return box
+}
+
+// Validate checks ring structure and coordinate ranges. Longitudes must stay
+// within [-180, 180]; shapes crossing the antimeridian have to be split into
+// several polygons as RFC 7946 recommends.
+func (m MultiPolygon) Validate() errors.FieldErrors {
+	fields := errors.FieldErrors{}
+
+	if len(m) == 0 {
+		fields["geometry"] = "must contain at least one polygon"
+		return fields
+	}
+
+	for i, polygon := range m {
+		if len(polygon) == 0 {
+			fields[fmt.Sprintf("geometry.%d", i)] = "must have an exterior ring"
+			continue
+		}
+
+		for j, ring := range polygon {
+			key := fmt.Sprintf("geometry.%d.%d", i, j)
+
+			if len(ring) < minRingSize {
+				fields[key] = fmt.Sprintf("must have at least %d positions", minRingSize)
+				continue
+			}
+			if ring[0] != ring[len(ring)-1] {
+				fields[key] = "must end where it starts"
+				continue
+			}
+
+			for _, c := range ring {
+				if len(c.Validate()) > 0 || c.Longitude < -MaxLongitude || c.Longitude > MaxLongitude {
+					fields[key] = fmt.Sprintf("positions must be within latitude ±%g and longitude ±%g", MaxLatitude, MaxLongitude)
+					break
+				}
+			}
+		}
+	}
+
+	return fields
+}
+
+// crossings counts the ring edges crossed by a ray cast from c towards
+// increasing longitude.
+func (r Ring) crossings(c Coordinate) int {
+	count := 0
+
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if (a.Latitude > c.Latitude) == (b.Latitude > c.Latitude) {
+			continue
+		}
+
+		longitude := a.Longitude + (c.Latitude-a.Latitude)/(b.Latitude-a.Latitude)*(b.Longitude-a.Longitude)
+		if c.Longitude < longitude {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,108 @@
+package geo
+
+import "testing"
+
+func square(south, west, north, east float64) Ring {
+	return Ring{
+		{Latitude: south, Longitude: west},
+		{Latitude: south, Longitude: east},
+		{Latitude: north, Longitude: east},
+		{Latitude: north, Longitude: west},
+		{Latitude: south, Longitude: west},
+	}
+}
+
+func TestMultiPolygonContains(t *testing.T) {
+	// A square with a square hole, next to a triangle.
+	shape := MultiPolygon{
+		{square(0, 0, 10, 10), square(4, 4, 6, 6)},
+		{{
+			{Latitude: 0, Longitude: 20},
+			{Latitude: 0, Longitude: 30},
+			{Latitude: 10, Longitude: 20},
+			{Latitude: 0, Longitude: 20},
+		}},
+	}
+
+	tests := []struct {
+		name string
+		c    Coordinate
+		want bool
+	}{
+		{"inside the square", Coordinate{Latitude: 2, Longitude: 2}, true},
+		{"inside the hole", Coordinate{Latitude: 5, Longitude: 5}, false},
+		{"between the hole and the edge", Coordinate{Latitude: 5, Longitude: 8}, true},
+		{"inside the triangle", Coordinate{Latitude: 2, Longitude: 22}, true},
+		{"beside the triangle's slope", Coordinate{Latitude: 8, Longitude: 28}, false},
+		{"between the polygons", Coordinate{Latitude: 5, Longitude: 15}, false},
+		{"west of everything", Coordinate{Latitude: 5, Longitude: -5}, false},
+		{"north of everything", Coordinate{Latitude: 15, Longitude: 5}, false},
+	}
+
+	for _, tt := range tests {
+		if got := shape.Contains(tt.c); got != tt.want {
+			t.Errorf("%s: Contains(%+v) = %v, want %v", tt.name, tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestMultiPolygonContainsConcaveRing(t *testing.T) {
+	// A "U" open to the north: the notch between the arms is outside.
+	shape := MultiPolygon{{{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 0, Longitude: 3},
+		{Latitude: 3, Longitude: 3},
+		{Latitude: 3, Longitude: 2},
+		{Latitude: 1, Longitude: 2},
+		{Latitude: 1, Longitude: 1},
+		{Latitude: 3, Longitude: 1},
+		{Latitude: 3, Longitude: 0},
+		{Latitude: 0, Longitude: 0},
+	}}}
+
+	if !shape.Contains(Coordinate{Latitude: 2, Longitude: 0.5}) {
+		t.Error("west arm is not contained")
+	}
+	if !shape.Contains(Coordinate{Latitude: 2, Longitude: 2.5}) {
+		t.Error("east arm is not contained")
+	}
+	if shape.Contains(Coordinate{Latitude: 2, Longitude: 1.5}) {
+		t.Error("notch is contained")
+	}
+}
+
+func TestMultiPolygonBounds(t *testing.T) {
+	shape := MultiPolygon{
+		{square(0, 0, 10, 10), square(4, 4, 6, 6)},
+		{square(-5, 20, 2, 30)},
+	}
+
+	if got, want := shape.Bounds(), box(-5, 0, 10, 30); got != want {
+		t.Errorf("Bounds() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMultiPolygonValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		shape MultiPolygon
+		field string
+	}{
+		{"empty", MultiPolygon{}, "geometry"},
+		{"no exterior ring", MultiPolygon{{}}, "geometry.0"},
+		{"short ring", MultiPolygon{{square(0, 0, 1, 1)[:3]}}, "geometry.0.0"},
+		{"open ring", MultiPolygon{{square(0, 0, 1, 1)[:4]}}, "geometry.0.0"},
+		{"unwrapped longitude", MultiPolygon{{square(0, 170, 1, 190)}}, "geometry.0.0"},
+	}
+
+	for _, tt := range tests {
+		fields := tt.shape.Validate()
+		if _, ok := fields[tt.field]; !ok || len(fields) != 1 {
+			t.Errorf("%s: Validate() = %v, want an error on %s", tt.name, fields, tt.field)
+		}
+	}
+
+	if fields := (MultiPolygon{{square(0, 0, 1, 1)}}).Validate(); len(fields) > 0 {
+		t.Errorf("Validate() of a valid square = %v", fields)
+	}
+}
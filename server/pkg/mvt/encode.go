@@ -0,0 +1,230 @@
+// Package mvt encodes point layers as Mapbox Vector Tiles (specification 2.1).
+package mvt
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	specVersion = 2
+
+	pointGeometry = 1
+	moveToCommand = 1
+)
+
+// Field numbers from vector_tile.proto.
+const (
+	tileLayers = 3
+
+	layerName     = 1
+	layerFeatures = 2
+	layerKeys     = 3
+	layerValues   = 4
+	layerExtent   = 5
+	layerVersion  = 15
+
+	featureId       = 1
+	featureTags     = 2
+	featureType     = 3
+	featureGeometry = 4
+
+	valueString = 1
+	valueDouble = 3
+	valueInt    = 4
+	valueUint   = 5
+	valueBool   = 7
+)
+
+type Property struct {
+	Key   string
+	Value any
+}
+
+type Feature struct {
+	Id         uint64
+	X, Y       int64
+	Properties []Property
+}
+
+type Layer struct {
+	Name     string
+	Extent   uint32
+	Features []Feature
+}
+
+func NewLayer(name string, extent uint32) *Layer {
+	return &Layer{Name: name, Extent: extent}
+}
+
+func (l *Layer) AddPoint(id uint64, x, y int64, properties ...Property) {
+	l.Features = append(l.Features, Feature{Id: id, X: x, Y: y, Properties: properties})
+}
+
+// Encode serializes the layers into a tile. Property values may be strings,
+// booleans, signed or unsigned integers and floats; anything else is an error.
+func Encode(layers ...*Layer) ([]byte, error) {
+	var tile []byte
+
+	for _, layer := range layers {
+		encoded, err := layer.encode()
+		if err != nil {
+			return nil, err
+		}
+
+		tile = protowire.AppendTag(tile, tileLayers, protowire.BytesType)
+		tile = protowire.AppendBytes(tile, encoded)
+	}
+
+	return tile, nil
+}
+
+func (l *Layer) encode() ([]byte, error) {
+	var keys []string
+	keyIndex := make(map[string]uint64)
+	var values [][]byte
+	valueIndex := make(map[string]uint64)
+
+	var buf []byte
+	buf = protowire.AppendTag(buf, layerVersion, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, specVersion)
+	buf = protowire.AppendTag(buf, layerName, protowire.BytesType)
+	buf = protowire.AppendString(buf, l.Name)
+
+	for _, feature := range l.Features {
+		tags := make([]uint64, 0, 2*len(feature.Properties))
+
+		for _, property := range feature.Properties {
+			key, ok := keyIndex[property.Key]
+			if !ok {
+				key = uint64(len(keys))
+				keyIndex[property.Key] = key
+				keys = append(keys, property.Key)
+			}
+
+			encoded, err := encodeValue(property.Value)
+			if err != nil {
+				return nil, fmt.Errorf("mvt: property %q: %w", property.Key, err)
+			}
+
+			value, ok := valueIndex[string(encoded)]
+			if !ok {
+				value = uint64(len(values))
+				valueIndex[string(encoded)] = value
+				values = append(values, encoded)
+			}
+
+			tags = append(tags, key, value)
+		}
+
+		buf = protowire.AppendTag(buf, layerFeatures, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, encodeFeature(feature, tags))
+	}
+
+	for _, key := range keys {
+		buf = protowire.AppendTag(buf, layerKeys, protowire.BytesType)
+		buf = protowire.AppendString(buf, key)
+	}
+
+	for _, value := range values {
+		buf = protowire.AppendTag(buf, layerValues, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, value)
+	}
+
+	extent := l.Extent
+	if extent == 0 {
+		extent = DefaultExtent
+	}
+	buf = protowire.AppendTag(buf, layerExtent, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, uint64(extent))
+
+	return buf, nil
+}
+
+func encodeFeature(feature Feature, tags []uint64) []byte {
+	var buf []byte
+
+	if feature.Id != 0 {
+		buf = protowire.AppendTag(buf, featureId, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, feature.Id)
+	}
+
+	if len(tags) > 0 {
+		buf = protowire.AppendTag(buf, featureTags, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, packVarints(tags...))
+	}
+
+	buf = protowire.AppendTag(buf, featureType, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, pointGeometry)
+
+	geometry := packVarints(
+		moveToCommand|1<<3,
+		protowire.EncodeZigZag(feature.X),
+		protowire.EncodeZigZag(feature.Y),
+	)
+	buf = protowire.AppendTag(buf, featureGeometry, protowire.BytesType)
+	buf = protowire.AppendBytes(buf, geometry)
+
+	return buf
+}
+
+func encodeValue(v any) ([]byte, error) {
+	var buf []byte
+
+	switch value := v.(type) {
+	case string:
+		buf = protowire.AppendTag(buf, valueString, protowire.BytesType)
+		buf = protowire.AppendString(buf, value)
+	case bool:
+		buf = protowire.AppendTag(buf, valueBool, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, protowire.EncodeBool(value))
+	case int:
+		buf = appendInt(buf, int64(value))
+	case int32:
+		buf = appendInt(buf, int64(value))
+	case int64:
+		buf = appendInt(buf, value)
+	case uint:
+		buf = appendUint(buf, uint64(value))
+	case uint32:
+		buf = appendUint(buf, uint64(value))
+	case uint64:
+		buf = appendUint(buf, value)
+	case float32:
+		buf = appendDouble(buf, float64(value))
+	case float64:
+		buf = appendDouble(buf, value)
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+
+	return buf, nil
+}
+
+func appendInt(buf []byte, v int64) []byte {
+	if v >= 0 {
+		return appendUint(buf, uint64(v))
+	}
+	buf = protowire.AppendTag(buf, valueInt, protowire.VarintType)
+	return protowire.AppendVarint(buf, uint64(v))
+}
+
+func appendUint(buf []byte, v uint64) []byte {
+	buf = protowire.AppendTag(buf, valueUint, protowire.VarintType)
+	return protowire.AppendVarint(buf, v)
+}
+
+func appendDouble(buf []byte, v float64) []byte {
+	buf = protowire.AppendTag(buf, valueDouble, protowire.Fixed64Type)
+	return protowire.AppendFixed64(buf, math.Float64bits(v))
+}
+
+func packVarints(values ...uint64) []byte {
+	var buf []byte
+	for _, v := range values {
+		buf = protowire.AppendVarint(buf, v)
+	}
+	return buf
+}
@@ -0,0 +1,325 @@
+package mvt
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestEncodeGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		layers []*Layer
+	}{
+		{
+			name: "property_types",
+			layers: []*Layer{{
+				Name: "posts",
+				Features: []Feature{{
+					Id: 1, X: 2048, Y: 1024,
+					Properties: []Property{
+						{Key: "title", Value: "hello"},
+						{Key: "pinned", Value: true},
+						{Key: "delta", Value: -42},
+						{Key: "count", Value: int64(7)},
+						{Key: "big", Value: uint64(math.MaxUint64)},
+						{Key: "ratio32", Value: float32(0.5)},
+						{Key: "ratio", Value: 3.25},
+					},
+				}},
+			}},
+		},
+		{
+			name: "dedup",
+			layers: []*Layer{{
+				Name: "posts",
+				Features: []Feature{
+					{Id: 1, X: 10, Y: 20, Properties: []Property{{Key: "kind", Value: "cafe"}, {Key: "count", Value: 1}}},
+					{Id: 2, X: 30, Y: 40, Properties: []Property{{Key: "kind", Value: "cafe"}, {Key: "count", Value: uint32(1)}}},
+					{Id: 3, X: 50, Y: 60, Properties: []Property{{Key: "kind", Value: "1"}, {Key: "count", Value: 2}}},
+				},
+			}},
+		},
+		{
+			name: "layers",
+			layers: []*Layer{
+				{Name: "posts", Extent: 512, Features: []Feature{{Id: 9, X: -64, Y: 576}}},
+				{Name: "clusters", Features: []Feature{{X: 0, Y: 0, Properties: []Property{{Key: "count", Value: 12}}}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Encode(tt.layers...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", tt.name+".mvt")
+			if *update {
+				if err = os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("encoding differs from %s (run with -update after checking the change)", path)
+			}
+		})
+	}
+}
+
+func TestEncodePropertyTypes(t *testing.T) {
+	layer := NewLayer("posts", 0)
+	layer.AddPoint(1, 0, 0,
+		Property{Key: "s", Value: "x"},
+		Property{Key: "b", Value: false},
+		Property{Key: "neg", Value: int32(-1)},
+		Property{Key: "pos", Value: 5},
+		Property{Key: "u", Value: uint(6)},
+		Property{Key: "f", Value: 1.5},
+	)
+
+	tile, err := Encode(layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := decodeTile(t, tile)[0]
+	if decoded.extent != DefaultExtent {
+		t.Errorf("extent = %d, want %d", decoded.extent, DefaultExtent)
+	}
+
+	want := map[string]decodedValue{
+		"s":   {field: valueString, raw: "x"},
+		"b":   {field: valueBool, raw: uint64(0)},
+		"neg": {field: valueInt, raw: uint64(math.MaxUint64)},
+		"pos": {field: valueUint, raw: uint64(5)},
+		"u":   {field: valueUint, raw: uint64(6)},
+		"f":   {field: valueDouble, raw: math.Float64bits(1.5)},
+	}
+
+	properties := decoded.properties(t, 0)
+	for key, value := range want {
+		if properties[key] != value {
+			t.Errorf("%s = %+v, want %+v", key, properties[key], value)
+		}
+	}
+}
+
+func TestEncodeDeduplicatesKeysAndValues(t *testing.T) {
+	layer := NewLayer("posts", 0)
+	layer.AddPoint(1, 0, 0, Property{Key: "kind", Value: "cafe"}, Property{Key: "count", Value: 1})
+	layer.AddPoint(2, 0, 0, Property{Key: "kind", Value: "cafe"}, Property{Key: "count", Value: uint64(1)})
+	layer.AddPoint(3, 0, 0, Property{Key: "count", Value: "1"}, Property{Key: "kind", Value: 1.0})
+
+	tile, err := Encode(layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := decodeTile(t, tile)[0]
+	if len(decoded.keys) != 2 {
+		t.Errorf("keys = %v, want kind and count once each", decoded.keys)
+	}
+	// "cafe", the integer 1 shared by int and uint64, the string "1" and the
+	// double 1.0.
+	if len(decoded.values) != 4 {
+		t.Errorf("values = %d, want 4", len(decoded.values))
+	}
+	if decoded.features[0].tags[3] != decoded.features[1].tags[3] {
+		t.Errorf("int 1 and uint64 1 encoded as different values")
+	}
+}
+
+func TestEncodeRejectsUnsupportedValues(t *testing.T) {
+	layer := NewLayer("posts", 0)
+	layer.AddPoint(1, 0, 0, Property{Key: "bad", Value: []string{"x"}})
+
+	if _, err := Encode(layer); err == nil {
+		t.Error("expected an error for a slice value")
+	}
+}
+
+func TestEncodeGeometry(t *testing.T) {
+	layer := NewLayer("posts", 0)
+	layer.AddPoint(0, -3, 4097)
+
+	decoded := decodeTile(t, mustEncode(t, layer))[0]
+	feature := decoded.features[0]
+
+	if feature.hasId {
+		t.Error("zero id should be omitted")
+	}
+
+	want := []uint64{moveToCommand | 1<<3, protowire.EncodeZigZag(-3), protowire.EncodeZigZag(4097)}
+	if len(feature.geometry) != len(want) {
+		t.Fatalf("geometry = %v, want %v", feature.geometry, want)
+	}
+	for i := range want {
+		if feature.geometry[i] != want[i] {
+			t.Errorf("geometry = %v, want %v", feature.geometry, want)
+		}
+	}
+}
+
+func mustEncode(t *testing.T, layers ...*Layer) []byte {
+	t.Helper()
+
+	tile, err := Encode(layers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tile
+}
+
+type decodedValue struct {
+	field protowire.Number
+	raw   any
+}
+
+type decodedFeature struct {
+	id       uint64
+	hasId    bool
+	tags     []uint64
+	geometry []uint64
+}
+
+type decodedLayer struct {
+	name     string
+	extent   uint64
+	keys     []string
+	values   []decodedValue
+	features []decodedFeature
+}
+
+func (l decodedLayer) properties(t *testing.T, feature int) map[string]decodedValue {
+	t.Helper()
+
+	tags := l.features[feature].tags
+	properties := make(map[string]decodedValue, len(tags)/2)
+	for i := 0; i+1 < len(tags); i += 2 {
+		properties[l.keys[tags[i]]] = l.values[tags[i+1]]
+	}
+	return properties
+}
+
+func decodeTile(t *testing.T, tile []byte) []decodedLayer {
+	t.Helper()
+
+	var layers []decodedLayer
+	eachField(t, tile, func(num protowire.Number, typ protowire.Type, data []byte, _ uint64) {
+		if num != tileLayers {
+			t.Fatalf("unexpected tile field %d", num)
+		}
+		layers = append(layers, decodeLayer(t, data))
+	})
+	return layers
+}
+
+func decodeLayer(t *testing.T, data []byte) decodedLayer {
+	var layer decodedLayer
+	eachField(t, data, func(num protowire.Number, typ protowire.Type, data []byte, varint uint64) {
+		switch num {
+		case layerName:
+			layer.name = string(data)
+		case layerExtent:
+			layer.extent = varint
+		case layerKeys:
+			layer.keys = append(layer.keys, string(data))
+		case layerValues:
+			eachField(t, data, func(num protowire.Number, typ protowire.Type, data []byte, varint uint64) {
+				if num == valueString {
+					layer.values = append(layer.values, decodedValue{field: num, raw: string(data)})
+				} else {
+					layer.values = append(layer.values, decodedValue{field: num, raw: varint})
+				}
+			})
+		case layerFeatures:
+			layer.features = append(layer.features, decodeFeature(t, data))
+		}
+	})
+	return layer
+}
+
+func decodeFeature(t *testing.T, data []byte) decodedFeature {
+	var feature decodedFeature
+	eachField(t, data, func(num protowire.Number, typ protowire.Type, data []byte, varint uint64) {
+		switch num {
+		case featureId:
+			feature.id, feature.hasId = varint, true
+		case featureTags:
+			feature.tags = unpackVarints(t, data)
+		case featureGeometry:
+			feature.geometry = unpackVarints(t, data)
+		}
+	})
+	return feature
+}
+
+// eachField walks a message, passing bytes fields as data and varint and
+// fixed64 fields as varint.
+func eachField(t *testing.T, b []byte, fn func(num protowire.Number, typ protowire.Type, data []byte, varint uint64)) {
+	t.Helper()
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		switch typ {
+		case protowire.BytesType:
+			data, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				t.Fatal(protowire.ParseError(n))
+			}
+			fn(num, typ, data, 0)
+			b = b[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				t.Fatal(protowire.ParseError(n))
+			}
+			fn(num, typ, nil, v)
+			b = b[n:]
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				t.Fatal(protowire.ParseError(n))
+			}
+			fn(num, typ, nil, v)
+			b = b[n:]
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+	}
+}
+
+func unpackVarints(t *testing.T, b []byte) []uint64 {
+	t.Helper()
+
+	var values []uint64
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		values = append(values, v)
+		b = b[n:]
+	}
+	return values
+}
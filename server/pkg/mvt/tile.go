@@ -0,0 +1,52 @@
+package mvt
+
+import "math"
+
+const (
+	DefaultExtent = 4096
+	MaxZoom       = 24
+
+	maxMercatorLatitude = 85.05112878
+)
+
+type Tile struct {
+	Z, X, Y int
+}
+
+func (t Tile) IsValid() bool {
+	if t.Z < 0 || t.Z > MaxZoom {
+		return false
+	}
+	n := 1 << t.Z
+	return t.X >= 0 && t.X < n && t.Y >= 0 && t.Y < n
+}
+
+// Bounds returns the tile's south, west, north and east edges in degrees.
+func (t Tile) Bounds() (south, west, north, east float64) {
+	n := math.Exp2(float64(t.Z))
+	west = float64(t.X)/n*360 - 180
+	east = float64(t.X+1)/n*360 - 180
+	north = tileLatitude(float64(t.Y), n)
+	south = tileLatitude(float64(t.Y+1), n)
+	return south, west, north, east
+}
+
+// Project converts a coordinate into tile-local pixel coordinates with the
+// origin in the top-left corner. Points outside the tile fall outside
+// [0, extent).
+func (t Tile) Project(latitude, longitude float64, extent uint32) (x, y int64) {
+	n := math.Exp2(float64(t.Z))
+	latitude = max(min(latitude, maxMercatorLatitude), -maxMercatorLatitude)
+
+	worldX := (longitude + 180) / 360 * n
+	sin := math.Sin(latitude * math.Pi / 180)
+	worldY := (0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)) * n
+
+	x = int64(math.Floor((worldX - float64(t.X)) * float64(extent)))
+	y = int64(math.Floor((worldY - float64(t.Y)) * float64(extent)))
+	return x, y
+}
+
+func tileLatitude(y, n float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
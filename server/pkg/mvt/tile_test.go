@@ -0,0 +1,101 @@
+package mvt
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTileIsValid(t *testing.T) {
+	tests := []struct {
+		tile Tile
+		want bool
+	}{
+		{Tile{0, 0, 0}, true},
+		{Tile{1, 1, 1}, true},
+		{Tile{1, 2, 0}, false},
+		{Tile{1, 0, -1}, false},
+		{Tile{MaxZoom, 1<<MaxZoom - 1, 1<<MaxZoom - 1}, true},
+		{Tile{MaxZoom + 1, 0, 0}, false},
+		{Tile{-1, 0, 0}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.tile.IsValid(); got != tt.want {
+			t.Errorf("%+v.IsValid() = %v, want %v", tt.tile, got, tt.want)
+		}
+	}
+}
+
+func TestTileBoundsWorld(t *testing.T) {
+	south, west, north, east := Tile{}.Bounds()
+
+	if west != -180 || east != 180 {
+		t.Errorf("west, east = %v, %v, want -180, 180", west, east)
+	}
+	if math.Abs(north-maxMercatorLatitude) > 1e-6 || math.Abs(south+maxMercatorLatitude) > 1e-6 {
+		t.Errorf("south, north = %v, %v, want ±%v", south, north, maxMercatorLatitude)
+	}
+}
+
+func TestTileBoundsEdges(t *testing.T) {
+	south, west, north, east := Tile{Z: 1, X: 1, Y: 0}.Bounds()
+	if south != 0 || west != 0 || east != 180 || math.Abs(north-maxMercatorLatitude) > 1e-6 {
+		t.Errorf("bounds = %v, %v, %v, %v", south, west, north, east)
+	}
+
+	south, west, _, east = Tile{Z: 3, X: 7, Y: 7}.Bounds()
+	if east != 180 || west != 135 || math.Abs(south+maxMercatorLatitude) > 1e-6 {
+		t.Errorf("south-east corner tile bounds = %v, %v, %v", south, west, east)
+	}
+}
+
+func TestTileProject(t *testing.T) {
+	const extent = DefaultExtent
+
+	tests := []struct {
+		name                string
+		tile                Tile
+		latitude, longitude float64
+		x, y                int64
+	}{
+		{"world north-west corner", Tile{}, maxMercatorLatitude, -180, 0, 0},
+		{"world centre", Tile{}, 0, 0, extent / 2, extent / 2},
+		{"latitude clamped at the pole", Tile{}, 90, -180, 0, 0},
+		{"latitude clamped at the south pole", Tile{}, -90, 180, extent, extent},
+		{"meridian on the left edge", Tile{Z: 1, X: 1, Y: 0}, 0, 0, 0, extent},
+		{"meridian on the right edge", Tile{Z: 1, X: 0, Y: 0}, 0, 0, extent, extent},
+		{"outside to the west", Tile{Z: 1, X: 1, Y: 0}, 0, -90, -extent / 2, extent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := tt.tile.Project(tt.latitude, tt.longitude, extent)
+			if abs(x-tt.x) > 1 || abs(y-tt.y) > 1 {
+				t.Errorf("Project = (%d, %d), want (%d, %d)", x, y, tt.x, tt.y)
+			}
+		})
+	}
+}
+
+func TestTileProjectMatchesBounds(t *testing.T) {
+	for _, tile := range []Tile{{Z: 2, X: 0, Y: 0}, {Z: 5, X: 17, Y: 11}, {Z: 12, X: 2345, Y: 1234}, {Z: MaxZoom, X: 1 << 23, Y: 1 << 23}} {
+		south, west, north, east := tile.Bounds()
+
+		x, y := tile.Project(north, west, DefaultExtent)
+		if abs(x) > 1 || abs(y) > 1 {
+			t.Errorf("%+v: north-west corner projects to (%d, %d)", tile, x, y)
+		}
+
+		x, y = tile.Project(south, east, DefaultExtent)
+		if abs(x-DefaultExtent) > 1 || abs(y-DefaultExtent) > 1 {
+			t.Errorf("%+v: south-east corner projects to (%d, %d)", tile, x, y)
+		}
+	}
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
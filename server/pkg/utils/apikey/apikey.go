@@ -0,0 +1,50 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	Prefix = "nbk_"
+
+	idBytes     = 6
+	secretBytes = 24
+)
+
+func Generate() (key, id string, err error) {
+	rawId := make([]byte, idBytes)
+	if _, err = rand.Read(rawId); err != nil {
+		return "", "", err
+	}
+
+	rawSecret := make([]byte, secretBytes)
+	if _, err = rand.Read(rawSecret); err != nil {
+		return "", "", err
+	}
+
+	id = hex.EncodeToString(rawId)
+	key = Prefix + id + "_" + base64.RawURLEncoding.EncodeToString(rawSecret)
+
+	return key, id, nil
+}
+
+func IsApiKey(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+func Parse(key string) (id string, ok bool) {
+	rest, found := strings.CutPrefix(key, Prefix)
+	if !found {
+		return "", false
+	}
+
+	id, secret, found := strings.Cut(rest, "_")
+	if !found || len(id) != hex.EncodedLen(idBytes) || secret == "" {
+		return "", false
+	}
+
+	return id, true
+}
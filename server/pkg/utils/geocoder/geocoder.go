@@ -0,0 +1,76 @@
+package geocoder
+
+import (
+	"math"
+
+	"github.com/skrpld/NearBeee/pkg/geo"
+)
+
+// GeocoderConfig points at GeoNames-style dumps: a cities file such as
+// cities1000.txt, and optionally admin1CodesASCII.txt and countryInfo.txt to
+// resolve region and country codes into names. Without a places file every
+// lookup misses.
+type GeocoderConfig struct {
+	PlacesPath    string  `env:"GEOCODER_PLACES_PATH" mapstructure:"GEOCODER_PLACES_PATH"`
+	RegionsPath   string  `env:"GEOCODER_REGIONS_PATH" mapstructure:"GEOCODER_REGIONS_PATH"`
+	CountriesPath string  `env:"GEOCODER_COUNTRIES_PATH" mapstructure:"GEOCODER_COUNTRIES_PATH"`
+	MaxDistance   float64 `env:"GEOCODER_MAX_DISTANCE_KM" env-default:"50" mapstructure:"GEOCODER_MAX_DISTANCE_KM"`
+}
+
+type Place struct {
+	Locality    string  `json:"locality"`
+	Region      string  `json:"region"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+}
+
+type Geocoder struct {
+	places      []Place
+	tree        *kdTree
+	maxDistance float64
+}
+
+func NewGeocoder(cfg GeocoderConfig) (*Geocoder, error) {
+	if cfg.PlacesPath == "" {
+		return &Geocoder{tree: newKDTree(nil)}, nil
+	}
+
+	regions, err := loadNames(cfg.RegionsPath, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	countries, err := loadNames(cfg.CountriesPath, 0, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	places, err := loadPlaces(cfg.PlacesPath, regions, countries)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Geocoder{places: places, tree: newKDTree(places), maxDistance: cfg.MaxDistance}, nil
+}
+
+func (g *Geocoder) Enabled() bool {
+	return len(g.places) > 0
+}
+
+// Reverse returns the place nearest to the point and its distance in km. Points
+// farther than the configured distance from any place have no match.
+func (g *Geocoder) Reverse(latitude, longitude float64) (Place, float64, bool) {
+	i, chord := g.tree.nearest(latitude, longitude)
+	if i < 0 {
+		return Place{}, 0, false
+	}
+
+	distance := 2 * geo.EarthRadius * math.Asin(math.Min(chord/2, 1))
+	if g.maxDistance > 0 && distance > g.maxDistance {
+		return Place{}, 0, false
+	}
+
+	return g.places[i], distance, true
+}
@@ -0,0 +1,109 @@
+package geocoder
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPlaces = `# geonameid	name	asciiname	alternatenames	latitude	longitude	class	code	country	cc2	admin1
+524901	Moscow	Moscow		55.75222	37.61556	P	PPLC	RU		48
+5128581	New York City	New York City		40.71427	-74.00597	P	PPL	US		NY
+2147714	Sydney	Sydney		-33.86785	151.20732	P	PPLA	AU		02
+2643743	Mount Nowhere	Mount Nowhere		55.8	37.7	T	MT	RU		48
+`
+
+const testRegions = "RU.48\tMoscow\tMoscow\t524894\nUS.NY\tNew York\tNew York\t5128638\n"
+
+const testCountries = "RU\tRUS\t643\tRS\tRussia\nUS\tUSA\t840\tUS\tUnited States\n"
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func newTestGeocoder(t *testing.T, maxDistance float64) *Geocoder {
+	t.Helper()
+
+	g, err := NewGeocoder(GeocoderConfig{
+		PlacesPath:    writeFile(t, "cities.txt", testPlaces),
+		RegionsPath:   writeFile(t, "admin1.txt", testRegions),
+		CountriesPath: writeFile(t, "countries.txt", testCountries),
+		MaxDistance:   maxDistance,
+	})
+	if err != nil {
+		t.Fatalf("NewGeocoder() error = %v", err)
+	}
+	return g
+}
+
+func TestGeocoderReverse(t *testing.T) {
+	g := newTestGeocoder(t, 50)
+
+	if !g.Enabled() {
+		t.Fatal("Enabled() = false")
+	}
+
+	place, distance, ok := g.Reverse(55.76, 37.62)
+	if !ok {
+		t.Fatal("Reverse() near Moscow found nothing")
+	}
+	want := Place{Locality: "Moscow", Region: "Moscow", Country: "Russia", CountryCode: "RU", Latitude: 55.75222, Longitude: 37.61556}
+	if place != want {
+		t.Errorf("Reverse() = %+v, want %+v", place, want)
+	}
+	if math.Abs(distance-0.9) > 0.1 {
+		t.Errorf("Reverse() distance = %v km, want about 0.9", distance)
+	}
+
+	// Region and country codes without a name fall back to empty and the code.
+	if place, _, ok = g.Reverse(-33.87, 151.2); !ok || place.Region != "" || place.Country != "AU" {
+		t.Errorf("Reverse() near Sydney = %+v, %v", place, ok)
+	}
+}
+
+func TestGeocoderReverseMaxDistance(t *testing.T) {
+	if _, _, ok := newTestGeocoder(t, 50).Reverse(0, 0); ok {
+		t.Error("Reverse() far from every place matched")
+	}
+
+	place, distance, ok := newTestGeocoder(t, 0).Reverse(0, 0)
+	if !ok || distance < 1000 {
+		t.Errorf("Reverse() without a limit = %+v, %v, %v", place, distance, ok)
+	}
+}
+
+func TestGeocoderDisabled(t *testing.T) {
+	g, err := NewGeocoder(GeocoderConfig{})
+	if err != nil {
+		t.Fatalf("NewGeocoder() error = %v", err)
+	}
+
+	if g.Enabled() {
+		t.Error("Enabled() = true without a places file")
+	}
+	if _, _, ok := g.Reverse(55.75, 37.62); ok {
+		t.Error("Reverse() matched without a places file")
+	}
+}
+
+func TestNewGeocoderReportsBadRows(t *testing.T) {
+	tests := map[string]string{
+		"short row":    "524901\tMoscow\n",
+		"bad latitude": "524901\tMoscow\tMoscow\t\tnorth\t37.61556\tP\tPPLC\tRU\t\t48\n",
+	}
+
+	for name, content := range tests {
+		_, err := NewGeocoder(GeocoderConfig{PlacesPath: writeFile(t, "cities.txt", content)})
+		if err == nil || !strings.Contains(err.Error(), "cities.txt:1") {
+			t.Errorf("%s: NewGeocoder() error = %v, want one naming the line", name, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package geocoder
+
+import (
+	"math"
+	"slices"
+)
+
+// kdTree indexes places by their position on the unit sphere. Working in 3D
+// keeps nearest-neighbour search correct across the antimeridian and near the
+// poles, and the chord length between two points grows with their great-circle
+// distance, so the closest chord is also the closest place.
+type kdTree struct {
+	nodes []kdNode
+}
+
+type kdNode struct {
+	point [3]float64
+	place int
+}
+
+func newKDTree(places []Place) *kdTree {
+	nodes := make([]kdNode, len(places))
+	for i, place := range places {
+		nodes[i] = kdNode{point: unitVector(place.Latitude, place.Longitude), place: i}
+	}
+
+	build(nodes, 0)
+
+	return &kdTree{nodes: nodes}
+}
+
+// build lays the tree out in place: the median of each range is its root and
+// the halves on either side are its subtrees.
+func build(nodes []kdNode, depth int) {
+	if len(nodes) < 2 {
+		return
+	}
+
+	axis := depth % 3
+	slices.SortFunc(nodes, func(a, b kdNode) int {
+		switch {
+		case a.point[axis] < b.point[axis]:
+			return -1
+		case a.point[axis] > b.point[axis]:
+			return 1
+		}
+		return 0
+	})
+
+	mid := len(nodes) / 2
+	build(nodes[:mid], depth+1)
+	build(nodes[mid+1:], depth+1)
+}
+
+// nearest returns the index of the closest place and its chord distance on the
+// unit sphere, or -1 for an empty tree.
+func (t *kdTree) nearest(latitude, longitude float64) (int, float64) {
+	best, bestDistance := -1, math.Inf(1)
+	search(t.nodes, unitVector(latitude, longitude), 0, &best, &bestDistance)
+
+	return best, math.Sqrt(bestDistance)
+}
+
+func search(nodes []kdNode, target [3]float64, depth int, best *int, bestDistance *float64) {
+	if len(nodes) == 0 {
+		return
+	}
+
+	mid := len(nodes) / 2
+	node := nodes[mid]
+
+	if distance := squaredDistance(node.point, target); distance < *bestDistance {
+		*best, *bestDistance = node.place, distance
+	}
+
+	axis := depth % 3
+	diff := target[axis] - node.point[axis]
+
+	near, far := nodes[:mid], nodes[mid+1:]
+	if diff > 0 {
+		near, far = far, near
+	}
+
+	search(near, target, depth+1, best, bestDistance)
+	if diff*diff < *bestDistance {
+		search(far, target, depth+1, best, bestDistance)
+	}
+}
+
+func unitVector(latitude, longitude float64) [3]float64 {
+	lat := latitude * math.Pi / 180
+	lon := longitude * math.Pi / 180
+	return [3]float64{math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)}
+}
+
+func squaredDistance(a, b [3]float64) float64 {
+	dx, dy, dz := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dx*dx + dy*dy + dz*dz
+}
@@ -0,0 +1,93 @@
+package geocoder
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomPlaces(rnd *rand.Rand, n int) []Place {
+	places := make([]Place, n)
+	for i := range places {
+		// Uniform on the sphere, so the poles are not oversampled.
+		places[i] = Place{
+			Latitude:  math.Asin(rnd.Float64()*2-1) * 180 / math.Pi,
+			Longitude: rnd.Float64()*360 - 180,
+		}
+	}
+	return places
+}
+
+func bruteForceNearest(places []Place, latitude, longitude float64) (int, float64) {
+	target := unitVector(latitude, longitude)
+
+	best, bestDistance := -1, math.Inf(1)
+	for i, place := range places {
+		if distance := squaredDistance(unitVector(place.Latitude, place.Longitude), target); distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+
+	return best, math.Sqrt(bestDistance)
+}
+
+func TestKDTreeMatchesBruteForce(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{1, 2, 3, 10, 1000} {
+		places := randomPlaces(rnd, n)
+		tree := newKDTree(places)
+
+		for i := 0; i < 2000; i++ {
+			latitude, longitude := rnd.Float64()*180-90, rnd.Float64()*360-180
+
+			got, gotDistance := tree.nearest(latitude, longitude)
+			want, wantDistance := bruteForceNearest(places, latitude, longitude)
+			if got != want && gotDistance != wantDistance {
+				t.Fatalf("n=%d: nearest(%v, %v) = %d at %v, want %d at %v", n, latitude, longitude, got, gotDistance, want, wantDistance)
+			}
+		}
+	}
+}
+
+func TestKDTreeAcrossTheAntimeridian(t *testing.T) {
+	places := []Place{
+		{Locality: "west", Latitude: 0, Longitude: -179.9},
+		{Locality: "far east", Latitude: 0, Longitude: 179},
+		{Locality: "north pole", Latitude: 89.9, Longitude: 0},
+	}
+	tree := newKDTree(places)
+
+	tests := []struct {
+		latitude, longitude float64
+		want                string
+	}{
+		{0, 179.95, "west"},
+		{0, -179.5, "west"},
+		{0, 178.5, "far east"},
+		{89.95, 180, "north pole"},
+	}
+
+	for _, tt := range tests {
+		i, _ := tree.nearest(tt.latitude, tt.longitude)
+		if i < 0 || places[i].Locality != tt.want {
+			t.Errorf("nearest(%v, %v) = %d, want %s", tt.latitude, tt.longitude, i, tt.want)
+		}
+	}
+}
+
+func TestKDTreeEmpty(t *testing.T) {
+	if i, _ := newKDTree(nil).nearest(0, 0); i != -1 {
+		t.Errorf("nearest() on an empty tree = %d, want -1", i)
+	}
+}
+
+func BenchmarkKDTreeNearest(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := newKDTree(randomPlaces(rnd, 100000))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.nearest(rnd.Float64()*180-90, rnd.Float64()*360-180)
+	}
+}
@@ -0,0 +1,111 @@
+package geocoder
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GeoNames column positions in the cities dumps.
+const (
+	placeNameColumn     = 1
+	placeLatColumn      = 4
+	placeLonColumn      = 5
+	placeClassColumn    = 6
+	placeCountryColumn  = 8
+	placeAdmin1Column   = 10
+	placeColumnsMinimum = 11
+
+	populatedPlaceClass = "P"
+	maxLineLength       = 1 << 20
+)
+
+func loadPlaces(path string, regions, countries map[string]string) ([]Place, error) {
+	places := make([]Place, 0)
+
+	err := eachRow(path, func(line int, columns []string) error {
+		if len(columns) < placeColumnsMinimum {
+			return fmt.Errorf("%s:%d: expected at least %d columns, got %d", path, line, placeColumnsMinimum, len(columns))
+		}
+		if class := columns[placeClassColumn]; class != "" && class != populatedPlaceClass {
+			return nil
+		}
+
+		latitude, err := strconv.ParseFloat(columns[placeLatColumn], 64)
+		if err != nil {
+			return fmt.Errorf("%s:%d: latitude: %w", path, line, err)
+		}
+		longitude, err := strconv.ParseFloat(columns[placeLonColumn], 64)
+		if err != nil {
+			return fmt.Errorf("%s:%d: longitude: %w", path, line, err)
+		}
+
+		countryCode := columns[placeCountryColumn]
+		country, ok := countries[countryCode]
+		if !ok {
+			country = countryCode
+		}
+
+		places = append(places, Place{
+			Locality:    columns[placeNameColumn],
+			Region:      regions[countryCode+"."+columns[placeAdmin1Column]],
+			Country:     country,
+			CountryCode: countryCode,
+			Latitude:    latitude,
+			Longitude:   longitude,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return places, nil
+}
+
+// loadNames maps one column of a lookup file to another, e.g. "US.CA" to
+// "California". An empty path yields an empty map.
+func loadNames(path string, keyColumn, nameColumn int) (map[string]string, error) {
+	names := make(map[string]string)
+	if path == "" {
+		return names, nil
+	}
+
+	err := eachRow(path, func(line int, columns []string) error {
+		if len(columns) <= max(keyColumn, nameColumn) {
+			return fmt.Errorf("%s:%d: expected at least %d columns, got %d", path, line, max(keyColumn, nameColumn)+1, len(columns))
+		}
+		names[columns[keyColumn]] = columns[nameColumn]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+func eachRow(path string, fn func(line int, columns []string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if err = fn(line, strings.Split(text, "\t")); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,157 @@
+package geohash
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+)
+
+const (
+	alphabet     = "0123456789bcdefghjkmnpqrstuvwxyz"
+	MaxPrecision = 12
+	earthRadius  = 6371.0
+)
+
+func Encode(latitude, longitude float64, precision int) string {
+	latMin, latMax := -90.0, 90.0
+	lonMin, lonMax := -180.0, 180.0
+
+	hash := make([]byte, 0, precision)
+	bits, bit, even := 0, 0, true
+
+	for len(hash) < precision {
+		if even {
+			mid := (lonMin + lonMax) / 2
+			if longitude >= mid {
+				bits = bits*2 + 1
+				lonMin = mid
+			} else {
+				bits = bits * 2
+				lonMax = mid
+			}
+		} else {
+			mid := (latMin + latMax) / 2
+			if latitude >= mid {
+				bits = bits*2 + 1
+				latMin = mid
+			} else {
+				bits = bits * 2
+				latMax = mid
+			}
+		}
+
+		even = !even
+		if bit++; bit == 5 {
+			hash = append(hash, alphabet[bits])
+			bits, bit = 0, 0
+		}
+	}
+
+	return string(hash)
+}
+
+func CellSize(precision int) (latDegrees, lonDegrees float64) {
+	lonBits := (5*precision + 1) / 2
+	latBits := 5 * precision / 2
+	return 180 / math.Exp2(float64(latBits)), 360 / math.Exp2(float64(lonBits))
+}
+
+// Jitter moves a point to a pseudo-random spot inside its cell at the given
+// precision. The spot depends only on the cell and the seed, so the result is
+// stable across calls and reveals nothing finer than the cell itself.
+func Jitter(latitude, longitude float64, precision int, seed []byte) (float64, float64) {
+	latSize, lonSize := CellSize(precision)
+
+	south := math.Min(math.Floor((latitude+90)/latSize)*latSize-90, 90-latSize)
+	west := math.Min(math.Floor((longitude+180)/lonSize)*lonSize-180, 180-lonSize)
+
+	sum := sha256.Sum256(seed)
+	latOffset := float64(binary.BigEndian.Uint32(sum[0:4])) / (1 << 32)
+	lonOffset := float64(binary.BigEndian.Uint32(sum[4:8])) / (1 << 32)
+
+	return south + latOffset*latSize, west + lonOffset*lonSize
+}
+
+// Cover returns the cell containing the point and its neighbours at the finest
+// precision whose cells are at least radius km wide, so together they contain
+// the whole circle. It returns nil when no precision can cover the circle.
+func Cover(latitude, longitude, radius float64) []string {
+	radiusDegrees := radius / earthRadius * 180 / math.Pi
+	if latitude+radiusDegrees >= 90 || latitude-radiusDegrees <= -90 {
+		return nil
+	}
+
+	cosLat := math.Cos((math.Abs(latitude) + radiusDegrees) * math.Pi / 180)
+
+	for precision := MaxPrecision; precision > 0; precision-- {
+		latSize, lonSize := CellSize(precision)
+		if latSize < radiusDegrees || lonSize*cosLat < radiusDegrees {
+			continue
+		}
+
+		return neighbourhood(latitude, longitude, precision, latSize, lonSize)
+	}
+
+	return nil
+}
+
+func neighbourhood(latitude, longitude float64, precision int, latSize, lonSize float64) []string {
+	seen := make(map[string]struct{}, 9)
+	cells := make([]string, 0, 9)
+
+	for _, dLat := range []float64{-latSize, 0, latSize} {
+		lat := latitude + dLat
+		if lat < -90 || lat > 90 {
+			continue
+		}
+
+		for _, dLon := range []float64{-lonSize, 0, lonSize} {
+			lon := longitude + dLon
+			if lon > 180 {
+				lon -= 360
+			} else if lon < -180 {
+				lon += 360
+			}
+
+			cell := Encode(lat, lon, precision)
+			if _, ok := seen[cell]; ok {
+				continue
+			}
+
+			seen[cell] = struct{}{}
+			cells = append(cells, cell)
+		}
+	}
+
+	return cells
+}
+
+// CoverBox returns the cells of the given precision that intersect a box not
+// crossing the antimeridian, or nil when there would be more than limit.
+// Precision 0 is a single empty cell matching every hash.
+func CoverBox(south, west, north, east float64, precision, limit int) []string {
+	if precision == 0 {
+		return []string{""}
+	}
+
+	latSize, lonSize := CellSize(precision)
+	latFrom, latTo := cellIndex(south+90, latSize, 180), cellIndex(north+90, latSize, 180)
+	lonFrom, lonTo := cellIndex(west+180, lonSize, 360), cellIndex(east+180, lonSize, 360)
+
+	if (latTo-latFrom+1)*(lonTo-lonFrom+1) > limit {
+		return nil
+	}
+
+	cells := make([]string, 0, (latTo-latFrom+1)*(lonTo-lonFrom+1))
+	for i := latFrom; i <= latTo; i++ {
+		for j := lonFrom; j <= lonTo; j++ {
+			cells = append(cells, Encode(-90+(float64(i)+0.5)*latSize, -180+(float64(j)+0.5)*lonSize, precision))
+		}
+	}
+
+	return cells
+}
+
+func cellIndex(offset, size, span float64) int {
+	return int(math.Floor(min(offset, span-size/2) / size))
+}
@@ -0,0 +1,174 @@
+package geohash
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/skrpld/NearBeee/pkg/geo"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		latitude, longitude float64
+		precision           int
+		want                string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{42.6, -5.6, 5, "ezs42"},
+		{-25.382708, -49.265506, 8, "6gkzwgjz"},
+		{0, 0, 3, "s00"},
+		{-90, -180, 4, "0000"},
+		{90, 180, 4, "zzzz"},
+	}
+
+	for _, tt := range tests {
+		if got := Encode(tt.latitude, tt.longitude, tt.precision); got != tt.want {
+			t.Errorf("Encode(%v, %v, %d) = %q, want %q", tt.latitude, tt.longitude, tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestEncodePrefixes(t *testing.T) {
+	full := Encode(55.7558, 37.6173, MaxPrecision)
+	for precision := 1; precision < MaxPrecision; precision++ {
+		if got := Encode(55.7558, 37.6173, precision); !strings.HasPrefix(full, got) {
+			t.Errorf("precision %d hash %q is not a prefix of %q", precision, got, full)
+		}
+	}
+}
+
+func TestCellSize(t *testing.T) {
+	tests := []struct {
+		precision        int
+		latSize, lonSize float64
+	}{
+		{1, 45, 45},
+		{2, 180.0 / 32, 360.0 / 32},
+		{5, 180.0 / 4096, 360.0 / 8192},
+	}
+
+	for _, tt := range tests {
+		latSize, lonSize := CellSize(tt.precision)
+		if latSize != tt.latSize || lonSize != tt.lonSize {
+			t.Errorf("CellSize(%d) = %v, %v, want %v, %v", tt.precision, latSize, lonSize, tt.latSize, tt.lonSize)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		latitude, longitude := rnd.Float64()*180-90, rnd.Float64()*360-180
+		precision := 1 + rnd.Intn(8)
+		seed := []byte{byte(i), byte(i >> 8)}
+
+		lat, lon := Jitter(latitude, longitude, precision, seed)
+		if got, want := Encode(lat, lon, precision), Encode(latitude, longitude, precision); got != want {
+			t.Fatalf("Jitter(%v, %v, %d) = %v, %v in cell %q, want cell %q", latitude, longitude, precision, lat, lon, got, want)
+		}
+
+		if lat2, lon2 := Jitter(latitude, longitude, precision, seed); lat2 != lat || lon2 != lon {
+			t.Fatalf("Jitter(%v, %v, %d) is not deterministic", latitude, longitude, precision)
+		}
+	}
+}
+
+func TestJitterDependsOnSeed(t *testing.T) {
+	lat1, lon1 := Jitter(55.7558, 37.6173, 6, []byte("a"))
+	lat2, lon2 := Jitter(55.7558, 37.6173, 6, []byte("b"))
+
+	if lat1 == lat2 && lon1 == lon2 {
+		t.Error("different seeds gave the same point")
+	}
+}
+
+func TestJitterEdgeCells(t *testing.T) {
+	for _, point := range [][2]float64{{90, 180}, {-90, -180}, {90, -180}, {-90, 180}} {
+		lat, lon := Jitter(point[0], point[1], 5, []byte("seed"))
+		if math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+			t.Errorf("Jitter(%v, %v) = %v, %v is out of range", point[0], point[1], lat, lon)
+		}
+	}
+}
+
+// TestCoverContainsCircle samples points inside each circle and checks that
+// their cells are part of the cover.
+func TestCoverContainsCircle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	centers := [][2]float64{{55.7558, 37.6173}, {0, 0}, {-33.87, 151.21}, {10, 179.99}, {-10, -179.99}, {80, 20}}
+	for _, center := range centers {
+		for _, radius := range []float64{0.1, 1, 5, 25, 100} {
+			cells := Cover(center[0], center[1], radius)
+			if cells == nil {
+				t.Fatalf("Cover(%v, %v, %v) = nil", center[0], center[1], radius)
+			}
+
+			origin := geo.Coordinate{Latitude: center[0], Longitude: center[1]}
+			radiusDegrees := radius / earthRadius * 180 / math.Pi
+			lonDegrees := radiusDegrees / math.Cos(center[0]*math.Pi/180)
+
+			for i := 0; i < 500; i++ {
+				point := geo.Coordinate{
+					Latitude:  center[0] + (rnd.Float64()*2-1)*radiusDegrees,
+					Longitude: geo.WrapLongitude(center[1] + (rnd.Float64()*2-1)*lonDegrees),
+				}
+				if geo.Distance(origin, point) > radius {
+					continue
+				}
+
+				cell := Encode(point.Latitude, point.Longitude, len(cells[0]))
+				if !slices.Contains(cells, cell) {
+					t.Fatalf("Cover(%v, %v, %v) = %v misses %+v in cell %q", center[0], center[1], radius, cells, point, cell)
+				}
+			}
+		}
+	}
+}
+
+func TestCoverNearPoles(t *testing.T) {
+	if cells := Cover(89.99, 0, 5); cells != nil {
+		t.Errorf("Cover() near the north pole = %v, want nil", cells)
+	}
+	if cells := Cover(-89.99, 0, 5); cells != nil {
+		t.Errorf("Cover() near the south pole = %v, want nil", cells)
+	}
+}
+
+func TestCoverBox(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	boxes := [][4]float64{{55, 37, 56, 38}, {-10, -10, 10, 10}, {-90, -180, 90, 180}, {0, 170, 5, 180}}
+	for _, box := range boxes {
+		south, west, north, east := box[0], box[1], box[2], box[3]
+
+		for precision := 1; precision <= 3; precision++ {
+			cells := CoverBox(south, west, north, east, precision, math.MaxInt)
+
+			for i := 0; i < 500; i++ {
+				latitude := south + rnd.Float64()*(north-south)
+				longitude := west + rnd.Float64()*(east-west)
+
+				if cell := Encode(latitude, longitude, precision); !slices.Contains(cells, cell) {
+					t.Fatalf("CoverBox(%v, %d) misses %v, %v in cell %q", box, precision, latitude, longitude, cell)
+				}
+			}
+		}
+	}
+}
+
+func TestCoverBoxLimit(t *testing.T) {
+	if cells := CoverBox(-90, -180, 90, 180, 1, 32); len(cells) != 32 {
+		t.Errorf("CoverBox() of the world at precision 1 = %d cells, want 32", len(cells))
+	}
+	if cells := CoverBox(-90, -180, 90, 180, 1, 31); cells != nil {
+		t.Errorf("CoverBox() over the limit = %v, want nil", cells)
+	}
+	if cells := CoverBox(-90, -180, 90, 180, 0, 1); !slices.Equal(cells, []string{""}) {
+		t.Errorf("CoverBox() at precision 0 = %v, want one empty cell", cells)
+	}
+}
@@ -1,6 +1,11 @@
 package hash
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 func HashString(str string) (string, error) {
 	hashed, err := bcrypt.GenerateFromPassword([]byte(str), bcrypt.DefaultCost)
@@ -13,3 +18,8 @@ func HashString(str string) (string, error) {
 func CompareHashAndPassword(hashed, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password))
 }
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
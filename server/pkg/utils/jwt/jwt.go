@@ -13,58 +13,102 @@ type JWTConfig struct {
 	RefreshTokenExpiryTime time.Duration `env:"REFRESH_TOKEN_EXPIRY_TIME" mapstructure:"REFRESH_TOKEN_EXPIRY_TIME" env-default:"168h"`
 	AccessTokenExpiryTime  time.Duration `env:"ACCESS_TOKEN_EXPIRY_TIME" mapstructure:"ACCESS_TOKEN_EXPIRY_TIME" env-default:"2h"`
 	IssuedAt               string        `env:"ISSUED_AT" mapstructure:"ISSUED_AT" env-default:"nearbeee"`
+	Algorithm              string        `env:"JWT_ALGORITHM" mapstructure:"JWT_ALGORITHM" env-default:"HS512"`
+	SigningKeyPath         string        `env:"JWT_SIGNING_KEY_PATH" mapstructure:"JWT_SIGNING_KEY_PATH"`
+	SigningKeyId           string        `env:"JWT_SIGNING_KEY_ID" mapstructure:"JWT_SIGNING_KEY_ID"`
+	VerificationKeys       string        `env:"JWT_VERIFICATION_KEYS" mapstructure:"JWT_VERIFICATION_KEYS"`
+	AcceptHMAC             bool          `env:"JWT_ACCEPT_HMAC" mapstructure:"JWT_ACCEPT_HMAC" env-default:"false"`
 }
 
-var currentConfig atomic.Pointer[JWTConfig]
+const (
+	AccessTokenType             = "access"
+	RefreshTokenType            = "refresh"
+	EmailVerificationTokenType  = "email_verification"
+	PasswordResetTokenType      = "password_reset"
+	TwoFactorChallengeTokenType = "2fa_challenge"
+	AccountDeletionTokenType    = "account_deletion"
+)
+
+var (
+	currentConfig atomic.Pointer[JWTConfig]
+	currentKeys   atomic.Pointer[keySet]
+)
+
+func UpdateJWTConfig(newCfg *JWTConfig, secret string) error {
+	keys, err := loadKeySet(newCfg, secret)
+	if err != nil {
+		return err
+	}
 
-func UpdateJWTConfig(newCfg *JWTConfig) {
+	currentKeys.Store(keys)
 	currentConfig.Store(newCfg)
+
+	return nil
 }
 
-func NewAccessToken(id, secret string) (string, error) {
-	cfg := currentConfig.Load()
-	if cfg == nil {
-		return "", errors.ErrInternalServer
+func GetJWKS() JWKSet {
+	keys := currentKeys.Load()
+	if keys == nil {
+		return JWKSet{Keys: []JWK{}}
 	}
+	return keys.jwks()
+}
 
-	token := jwt.New(jwt.SigningMethodHS512)
-	token.Claims = jwt.MapClaims{
-		"iss": cfg.IssuedAt,
-		"sub": id,
-		"exp": time.Now().Add(cfg.AccessTokenExpiryTime).Unix(),
-		"iat": time.Now().Unix(),
+func AccessTokenTTL() time.Duration {
+	if cfg := currentConfig.Load(); cfg != nil {
+		return cfg.AccessTokenExpiryTime
 	}
-	return token.SignedString([]byte(secret))
+	return 0
+}
+
+func RefreshTokenTTL() time.Duration {
+	if cfg := currentConfig.Load(); cfg != nil {
+		return cfg.RefreshTokenExpiryTime
+	}
+	return 0
+}
+
+func NewAccessToken(id, sessionId string) (string, error) {
+	return signToken(jwt.MapClaims{
+		"sub": id,
+		"sid": sessionId,
+		"typ": AccessTokenType,
+	}, func(cfg *JWTConfig) time.Duration { return cfg.AccessTokenExpiryTime })
 }
 
-func NewRefreshToken(email, secret string) (string, time.Duration, error) {
+func NewRefreshToken(id, tokenId, familyId string) (string, time.Duration, error) {
 	cfg := currentConfig.Load()
 	if cfg == nil {
 		return "", 0, errors.ErrInternalServer
 	}
 
-	token := jwt.New(jwt.SigningMethodHS512)
-	token.Claims = jwt.MapClaims{
-		"iss": cfg.IssuedAt,
-		"sub": email,
-		"exp": time.Now().Add(cfg.RefreshTokenExpiryTime).Unix(),
-		"iat": time.Now().Unix(),
-	}
-
-	tokenString, err := token.SignedString([]byte(secret))
+	tokenString, err := signToken(jwt.MapClaims{
+		"sub": id,
+		"jti": tokenId,
+		"fam": familyId,
+		"typ": RefreshTokenType,
+	}, func(cfg *JWTConfig) time.Duration { return cfg.RefreshTokenExpiryTime })
 	if err != nil {
 		return "", 0, err
 	}
 	return tokenString, cfg.RefreshTokenExpiryTime, nil
 }
 
-func ValidateToken(tokenString string, secret string) (*jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.ErrInvalidToken
-		}
-		return []byte(secret), nil
-	})
+func NewOneTimeToken(id, tokenId, tokenType string, ttl time.Duration) (string, error) {
+	return signToken(jwt.MapClaims{
+		"sub": id,
+		"jti": tokenId,
+		"typ": tokenType,
+	}, func(*JWTConfig) time.Duration { return ttl })
+}
+
+func ValidateToken(tokenString, tokenType string) (*jwt.MapClaims, error) {
+	keys := currentKeys.Load()
+	if keys == nil {
+		return nil, errors.ErrInternalServer
+	}
+
+	token, err := jwt.Parse(tokenString, keys.keyFunc)
 	if err != nil {
 		return nil, errors.ErrInvalidToken
 	}
@@ -90,5 +134,38 @@ func ValidateToken(tokenString string, secret string) (*jwt.MapClaims, error) {
 		return nil, errors.ErrExpiredToken
 	}
 
+	typ, err := GetStringClaim(&claims, "typ")
+	if err != nil || typ != tokenType {
+		return nil, errors.ErrInvalidToken
+	}
+
 	return &claims, nil
 }
+
+func GetStringClaim(claims *jwt.MapClaims, key string) (string, error) {
+	value, ok := (*claims)[key].(string)
+	if !ok || value == "" {
+		return "", errors.ErrInvalidToken
+	}
+	return value, nil
+}
+
+func signToken(claims jwt.MapClaims, expiry func(cfg *JWTConfig) time.Duration) (string, error) {
+	cfg := currentConfig.Load()
+	keys := currentKeys.Load()
+	if cfg == nil || keys == nil {
+		return "", errors.ErrInternalServer
+	}
+
+	now := time.Now()
+	claims["iss"] = cfg.IssuedAt
+	claims["exp"] = now.Add(expiry(cfg)).Unix()
+	claims["iat"] = now.Unix()
+
+	token := jwt.NewWithClaims(keys.signingMethod, claims)
+	if keys.signingKid != "" {
+		token.Header["kid"] = keys.signingKid
+	}
+
+	return token.SignedString(keys.signingKey)
+}
@@ -0,0 +1,156 @@
+package jwt
+
+import (
+	stderr "errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+const testSecret = "test-secret"
+
+func updateConfig(t *testing.T, cfg JWTConfig) {
+	t.Helper()
+
+	cfg.AccessTokenExpiryTime = time.Hour
+	cfg.RefreshTokenExpiryTime = time.Hour
+	cfg.IssuedAt = "nearbeee-test"
+
+	if err := UpdateJWTConfig(&cfg, testSecret); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// forgeToken signs access claims with an arbitrary method, key and kid.
+func forgeToken(t *testing.T, method jwt.SigningMethod, key any, kid string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"sub": "user",
+		"sid": "session",
+		"typ": AccessTokenType,
+		"iss": "nearbeee-test",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenString
+}
+
+func TestSignAndValidate(t *testing.T) {
+	keys := writeTestKeys(t)
+
+	tests := []struct {
+		name    string
+		cfg     JWTConfig
+		wantKid bool
+	}{
+		{"HS512", JWTConfig{Algorithm: HS512Algorithm}, false},
+		{"RS256", JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.rsaPrivate}, true},
+		{"EdDSA", JWTConfig{Algorithm: EdDSAAlgorithm, SigningKeyPath: keys.edPrivate}, true},
+	}
+
+	for _, tt := range tests {
+		updateConfig(t, tt.cfg)
+
+		token, err := NewAccessToken("user", "session")
+		if err != nil {
+			t.Fatalf("%s: NewAccessToken() error = %v", tt.name, err)
+		}
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Method.Alg() != tt.name {
+			t.Errorf("%s: alg = %s", tt.name, parsed.Method.Alg())
+		}
+		if _, ok := parsed.Header["kid"]; ok != tt.wantKid {
+			t.Errorf("%s: kid header present = %v, want %v", tt.name, ok, tt.wantKid)
+		}
+
+		claims, err := ValidateToken(token, AccessTokenType)
+		if err != nil {
+			t.Fatalf("%s: ValidateToken() error = %v", tt.name, err)
+		}
+		if sub, _ := claims.GetSubject(); sub != "user" {
+			t.Errorf("%s: sub = %q, want %q", tt.name, sub, "user")
+		}
+
+		if _, err = ValidateToken(token, RefreshTokenType); !stderr.Is(err, errors.ErrInvalidToken) {
+			t.Errorf("%s: wrong type: err = %v, want %v", tt.name, err, errors.ErrInvalidToken)
+		}
+
+		header, payload, _ := strings.Cut(token, ".")
+		payload, signature, _ := strings.Cut(payload, ".")
+		tampered := header + "." + payload + "." + strings.Repeat("A", len(signature))
+		if _, err = ValidateToken(tampered, AccessTokenType); !stderr.Is(err, errors.ErrInvalidToken) {
+			t.Errorf("%s: tampered: err = %v, want %v", tt.name, err, errors.ErrInvalidToken)
+		}
+	}
+}
+
+func TestValidateTokenKeyRotation(t *testing.T) {
+	keys := writeTestKeys(t)
+
+	updateConfig(t, JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.rsaPrivate, SigningKeyId: "old"})
+	token, err := NewAccessToken("user", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A token from the retired key verifies while its public key is listed.
+	updateConfig(t, JWTConfig{Algorithm: EdDSAAlgorithm, SigningKeyPath: keys.edPrivate, VerificationKeys: "old:" + keys.rsaPublic})
+	if _, err = ValidateToken(token, AccessTokenType); err != nil {
+		t.Errorf("listed kid: err = %v", err)
+	}
+
+	updateConfig(t, JWTConfig{Algorithm: EdDSAAlgorithm, SigningKeyPath: keys.edPrivate})
+	if _, err = ValidateToken(token, AccessTokenType); !stderr.Is(err, errors.ErrInvalidToken) {
+		t.Errorf("unknown kid: err = %v, want %v", err, errors.ErrInvalidToken)
+	}
+}
+
+func TestValidateTokenRejectsAlgorithmConfusion(t *testing.T) {
+	keys := writeTestKeys(t)
+
+	updateConfig(t, JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.rsaPrivate, SigningKeyId: "rsa", AcceptHMAC: true})
+
+	publicPEM, err := os.ReadFile(keys.rsaPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := map[string]string{
+		"hmac with the rsa kid":        forgeToken(t, jwt.SigningMethodHS512, []byte(testSecret), "rsa"),
+		"hmac keyed by the public key": forgeToken(t, jwt.SigningMethodHS256, publicPEM, "rsa"),
+		"hmac with an unknown kid":     forgeToken(t, jwt.SigningMethodHS512, []byte(testSecret), "other"),
+		"unsigned":                     forgeToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, ""),
+	}
+	for name, token := range tokens {
+		if _, err = ValidateToken(token, AccessTokenType); !stderr.Is(err, errors.ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want %v", name, err, errors.ErrInvalidToken)
+		}
+	}
+
+	// Legacy HMAC tokens carry no kid and only verify while HMAC is accepted.
+	legacy := forgeToken(t, jwt.SigningMethodHS512, []byte(testSecret), "")
+	if _, err = ValidateToken(legacy, AccessTokenType); err != nil {
+		t.Errorf("legacy hmac: err = %v", err)
+	}
+
+	updateConfig(t, JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.rsaPrivate, SigningKeyId: "rsa"})
+	if _, err = ValidateToken(legacy, AccessTokenType); !stderr.Is(err, errors.ErrInvalidToken) {
+		t.Errorf("legacy hmac without AcceptHMAC: err = %v, want %v", err, errors.ErrInvalidToken)
+	}
+}
@@ -0,0 +1,232 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	HS512Algorithm = "HS512"
+	RS256Algorithm = "RS256"
+	EdDSAAlgorithm = "EdDSA"
+)
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+type keySet struct {
+	signingMethod jwt.SigningMethod
+	signingKid    string
+	signingKey    any
+	hmacSecret    []byte
+	verifyKeys    map[string]verificationKey
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func loadKeySet(cfg *JWTConfig, secret string) (*keySet, error) {
+	keys := &keySet{verifyKeys: make(map[string]verificationKey)}
+
+	if cfg.Algorithm == HS512Algorithm || cfg.AcceptHMAC {
+		keys.hmacSecret = []byte(secret)
+	}
+
+	switch cfg.Algorithm {
+	case HS512Algorithm:
+		keys.signingMethod = jwt.SigningMethodHS512
+		keys.signingKey = keys.hmacSecret
+	case RS256Algorithm, EdDSAAlgorithm:
+		privateKey, err := readPrivateKey(cfg.SigningKeyPath)
+		if err != nil {
+			return nil, err
+		}
+
+		method, publicKey, err := methodForKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		if method.Alg() != cfg.Algorithm {
+			return nil, fmt.Errorf("jwt: signing key %s does not match algorithm %s", cfg.SigningKeyPath, cfg.Algorithm)
+		}
+
+		kid := cfg.SigningKeyId
+		if kid == "" {
+			if kid, err = thumbprint(publicKey); err != nil {
+				return nil, err
+			}
+		}
+
+		keys.signingMethod = method
+		keys.signingKid = kid
+		keys.signingKey = privateKey
+		keys.verifyKeys[kid] = verificationKey{method: method, key: publicKey}
+	default:
+		return nil, fmt.Errorf("jwt: unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	for _, item := range strings.Split(cfg.VerificationKeys, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		kid, path, ok := strings.Cut(item, ":")
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("jwt: verification key %q must look like kid:path", item)
+		}
+
+		publicKey, err := readPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+
+		method, publicKey, err := methodForKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		keys.verifyKeys[kid] = verificationKey{method: method, key: publicKey}
+	}
+
+	return keys, nil
+}
+
+func (k *keySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || len(k.hmacSecret) == 0 {
+			return nil, fmt.Errorf("jwt: unexpected signing method %s", token.Method.Alg())
+		}
+		return k.hmacSecret, nil
+	}
+
+	key, ok := k.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("jwt: unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("jwt: unexpected signing method %s", token.Method.Alg())
+	}
+	return key.key, nil
+}
+
+func (k *keySet) jwks() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(k.verifyKeys))}
+
+	for kid, key := range k.verifyKeys {
+		jwk := JWK{Use: "sig", Alg: key.method.Alg(), Kid: kid}
+
+		switch publicKey := key.key.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+func methodForKey(key any) (jwt.SigningMethod, crypto.PublicKey, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, &k.PublicKey, nil
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, k, nil
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, k.Public(), nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, k, nil
+	default:
+		return nil, nil, fmt.Errorf("jwt: unsupported key type %T", key)
+	}
+}
+
+func readPrivateKey(path string) (any, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("jwt: unexpected PEM block %q in %s", block.Type, path)
+	}
+}
+
+func readPublicKey(path string) (any, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("jwt: unexpected PEM block %q in %s", block.Type, path)
+	}
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwt: no PEM data in %s", path)
+	}
+	return block, nil
+}
+
+func thumbprint(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
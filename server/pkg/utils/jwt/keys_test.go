@@ -0,0 +1,180 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testKeys holds PEM files for one RSA and one Ed25519 key pair.
+type testKeys struct {
+	rsaPrivate, rsaPKCS8, rsaPublic string
+	edPrivate, edPublic             string
+}
+
+func writeTestKeys(t *testing.T) testKeys {
+	t.Helper()
+
+	dir := t.TempDir()
+	write := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	must := func(der []byte, err error) []byte {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testKeys{
+		rsaPrivate: write("rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+		rsaPKCS8:   write("rsa8.pem", "PRIVATE KEY", must(x509.MarshalPKCS8PrivateKey(rsaKey))),
+		rsaPublic:  write("rsa.pub", "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)),
+		edPrivate:  write("ed.pem", "PRIVATE KEY", must(x509.MarshalPKCS8PrivateKey(edPrivate))),
+		edPublic:   write("ed.pub", "PUBLIC KEY", must(x509.MarshalPKIXPublicKey(edPublic))),
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	keys := writeTestKeys(t)
+
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     JWTConfig
+		wantErr bool
+	}{
+		{"hmac", JWTConfig{Algorithm: HS512Algorithm}, false},
+		{"rsa pkcs1", JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.rsaPrivate}, false},
+		{"rsa pkcs8", JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.rsaPKCS8}, false},
+		{"ed25519", JWTConfig{Algorithm: EdDSAAlgorithm, SigningKeyPath: keys.edPrivate}, false},
+		{"verification keys", JWTConfig{Algorithm: EdDSAAlgorithm, SigningKeyPath: keys.edPrivate,
+			VerificationKeys: "old-rsa:" + keys.rsaPublic + ", old-ed:" + keys.edPublic}, false},
+		{"unsupported algorithm", JWTConfig{Algorithm: "ES256"}, true},
+		{"algorithm mismatch", JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.edPrivate}, true},
+		{"missing key", JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: filepath.Join(t.TempDir(), "missing.pem")}, true},
+		{"no pem data", JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: garbage}, true},
+		{"public key as signing key", JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.rsaPublic}, true},
+		{"private key as verification key", JWTConfig{Algorithm: HS512Algorithm, VerificationKeys: "old:" + keys.rsaPrivate}, true},
+		{"verification key without kid", JWTConfig{Algorithm: HS512Algorithm, VerificationKeys: keys.rsaPublic}, true},
+	}
+
+	for _, tt := range tests {
+		_, err := loadKeySet(&tt.cfg, testSecret)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: loadKeySet() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadKeySetKid(t *testing.T) {
+	keys := writeTestKeys(t)
+
+	// Both encodings of the same key get the same derived kid.
+	pkcs1, err := loadKeySet(&JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.rsaPrivate}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := loadKeySet(&JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.rsaPKCS8}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkcs1.signingKid == "" || pkcs1.signingKid != pkcs8.signingKid {
+		t.Errorf("derived kids = %q, %q, want the same non-empty kid", pkcs1.signingKid, pkcs8.signingKid)
+	}
+
+	named, err := loadKeySet(&JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.rsaPrivate, SigningKeyId: "2024-01"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if named.signingKid != "2024-01" {
+		t.Errorf("configured kid = %q, want %q", named.signingKid, "2024-01")
+	}
+}
+
+func TestGetJWKS(t *testing.T) {
+	keys := writeTestKeys(t)
+
+	updateConfig(t, JWTConfig{Algorithm: RS256Algorithm, SigningKeyPath: keys.rsaPrivate, SigningKeyId: "current",
+		VerificationKeys: "old:" + keys.edPublic, AcceptHMAC: true})
+
+	data, err := json.Marshal(GetJWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	if err = json.Unmarshal(data, &set); err != nil {
+		t.Fatal(err)
+	}
+
+	// The HMAC secret is never published.
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS = %s, want two keys", data)
+	}
+
+	byKid := make(map[string]map[string]string)
+	for _, key := range set.Keys {
+		byKid[key["kid"]] = key
+	}
+
+	want := map[string][]string{
+		"current": {"kty", "use", "alg", "kid", "n", "e"},
+		"old":     {"kty", "use", "alg", "kid", "crv", "x"},
+	}
+	for kid, fields := range want {
+		key := byKid[kid]
+		if len(key) != len(fields) {
+			t.Errorf("%s: fields = %v, want %v", kid, key, fields)
+		}
+		for _, field := range fields {
+			if key[field] == "" {
+				t.Errorf("%s: missing %q in %v", kid, field, key)
+			}
+		}
+	}
+
+	if key := byKid["current"]; key["kty"] != "RSA" || key["alg"] != RS256Algorithm || key["use"] != "sig" || key["e"] != "AQAB" {
+		t.Errorf("RSA key = %v", key)
+	}
+	if key := byKid["old"]; key["kty"] != "OKP" || key["alg"] != EdDSAAlgorithm || key["crv"] != "Ed25519" || len(key["x"]) != 43 {
+		t.Errorf("Ed25519 key = %v", key)
+	}
+}
+
+func TestGetJWKSWithoutKeys(t *testing.T) {
+	updateConfig(t, JWTConfig{Algorithm: HS512Algorithm})
+
+	data, err := json.Marshal(GetJWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"keys":[]}` {
+		t.Errorf("JWKS = %s, want an empty key list", data)
+	}
+}
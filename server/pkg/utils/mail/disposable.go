@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+)
+
+type DisposableStage struct {
+	domains map[string]struct{}
+}
+
+func NewDisposableStage(domains []string) *DisposableStage {
+	stage := &DisposableStage{domains: make(map[string]struct{}, len(domains))}
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain != "" {
+			stage.domains[domain] = struct{}{}
+		}
+	}
+	return stage
+}
+
+func LoadDisposableStage(path string) (*DisposableStage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var domains []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewDisposableStage(domains), nil
+}
+
+func (s *DisposableStage) Check(_ context.Context, addr *Address) error {
+	domain := addr.Domain
+	for {
+		if _, ok := s.domains[domain]; ok {
+			return errors.ErrDisposableEmail
+		}
+
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			return nil
+		}
+		domain = domain[dot+1:]
+	}
+}
@@ -1,43 +1,137 @@
 package mail
 
 import (
-	"net"
+	"context"
+	stdmail "net/mail"
 	"strings"
+	"time"
 	"unicode/utf8"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+
+	"golang.org/x/net/idna"
 )
 
-func IsEmailValid(email string) bool {
+type ValidationConfig struct {
+	DisposableDomainsPath string        `env:"EMAIL_DISPOSABLE_DOMAINS_PATH" mapstructure:"EMAIL_DISPOSABLE_DOMAINS_PATH"`
+	MXCheck               bool          `env:"EMAIL_MX_CHECK" env-default:"false" mapstructure:"EMAIL_MX_CHECK"`
+	MXCacheTTL            time.Duration `env:"EMAIL_MX_CACHE_TTL" env-default:"1h" mapstructure:"EMAIL_MX_CACHE_TTL"`
+	MXTimeout             time.Duration `env:"EMAIL_MX_TIMEOUT" env-default:"2s" mapstructure:"EMAIL_MX_TIMEOUT"`
+}
+
+type Address struct {
+	Local  string
+	Domain string
+}
+
+func (a *Address) String() string {
+	return a.Local + "@" + a.Domain
+}
+
+type Stage interface {
+	Check(ctx context.Context, addr *Address) error
+}
+
+type StageFunc func(ctx context.Context, addr *Address) error
+
+func (f StageFunc) Check(ctx context.Context, addr *Address) error {
+	return f(ctx, addr)
+}
+
+type Validator struct {
+	stages []Stage
+}
+
+func NewValidator(stages ...Stage) *Validator {
+	return &Validator{stages: stages}
+}
+
+func NewValidatorFromConfig(cfg ValidationConfig) (*Validator, error) {
+	stages := []Stage{StageFunc(IDNStage)}
+
+	if cfg.DisposableDomainsPath != "" {
+		disposable, err := LoadDisposableStage(cfg.DisposableDomainsPath)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, disposable)
+	}
+
+	if cfg.MXCheck {
+		stages = append(stages, NewMXStage(cfg.MXTimeout, cfg.MXCacheTTL))
+	}
+
+	return NewValidator(stages...), nil
+}
+
+func (v *Validator) Validate(ctx context.Context, email string) (string, error) {
+	addr, err := ParseAddress(email)
+	if err != nil {
+		return "", err
+	}
+
+	for _, stage := range v.stages {
+		if err = stage.Check(ctx, addr); err != nil {
+			return "", err
+		}
+	}
+
+	return addr.String(), nil
+}
+
+func Normalize(email string) (string, error) {
+	addr, err := ParseAddress(email)
+	if err != nil {
+		return "", err
+	}
+
+	if err = IDNStage(context.Background(), addr); err != nil {
+		return "", err
+	}
+
+	return addr.String(), nil
+}
+
+func ParseAddress(email string) (*Address, error) {
 	totalLength := utf8.RuneCountInString(email)
 	if totalLength == 0 || totalLength > 254 {
-		return false
+		return nil, errors.ErrInvalidEmail
 	}
 
-	parts := strings.Split(email, "@")
-	if len(parts) != 2 {
-		return false
+	parsed, err := stdmail.ParseAddress(email)
+	if err != nil || parsed.Name != "" || parsed.Address != email {
+		return nil, errors.ErrInvalidEmail
 	}
-	localPart := parts[0]
-	domainPart := parts[1]
 
-	localLength := utf8.RuneCountInString(localPart)
+	at := strings.LastIndex(parsed.Address, "@")
+	if at < 0 {
+		return nil, errors.ErrInvalidEmail
+	}
 
-	if localLength == 0 || localLength > 64 {
-		return false
+	addr := &Address{
+		Local:  parsed.Address[:at],
+		Domain: parsed.Address[at+1:],
 	}
 
-	domainLength := utf8.RuneCountInString(domainPart)
+	localLength := utf8.RuneCountInString(addr.Local)
+	if localLength == 0 || localLength > 64 {
+		return nil, errors.ErrInvalidEmail
+	}
 
-	if domainLength == 0 || domainLength > 253 {
-		return false
+	domainLength := utf8.RuneCountInString(addr.Domain)
+	if domainLength == 0 || domainLength > 253 || strings.HasPrefix(addr.Domain, "[") {
+		return nil, errors.ErrInvalidEmail
 	}
 
-	return hasMXRecord(domainPart)
+	return addr, nil
 }
 
-func hasMXRecord(domain string) bool {
-	records, err := net.LookupMX(domain)
-	if err != nil || len(records) == 0 {
-		return false
+func IDNStage(_ context.Context, addr *Address) error {
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(addr.Domain, "."))
+	if err != nil || !strings.Contains(domain, ".") {
+		return errors.ErrInvalidEmail
 	}
-	return true
+
+	addr.Domain = strings.ToLower(domain)
+	return nil
 }
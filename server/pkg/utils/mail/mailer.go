@@ -0,0 +1,61 @@
+package mail
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type MailConfig struct {
+	Driver       string `env:"MAIL_DRIVER" env-default:"file" mapstructure:"MAIL_DRIVER"`
+	From         string `env:"MAIL_FROM" env-default:"no-reply@nearbeee.local" mapstructure:"MAIL_FROM"`
+	FileDir      string `env:"MAIL_FILE_DIR" env-default:"./mail" mapstructure:"MAIL_FILE_DIR"`
+	SMTPHost     string `env:"SMTP_HOST" env-default:"localhost" mapstructure:"SMTP_HOST"`
+	SMTPPort     int    `env:"SMTP_PORT" env-default:"587" mapstructure:"SMTP_PORT"`
+	SMTPUsername string `env:"SMTP_USERNAME" mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD" mapstructure:"SMTP_PASSWORD"`
+}
+
+const (
+	SMTPDriver   = "smtp"
+	FileDriver   = "file"
+	MemoryDriver = "memory"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+func NewMailer(cfg MailConfig) (Mailer, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case SMTPDriver:
+		return NewSMTPMailer(cfg), nil
+	case FileDriver:
+		return NewFileMailer(cfg.FileDir, cfg.From)
+	case MemoryDriver:
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+func (m *Message) Bytes(from string) []byte {
+	var b strings.Builder
+
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + m.To + "\r\n")
+	b.WriteString("Subject: " + m.Subject + "\r\n")
+	b.WriteString("Date: " + time.Now().UTC().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(m.Body)
+
+	return []byte(b.String())
+}
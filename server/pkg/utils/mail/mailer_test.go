@@ -0,0 +1,99 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewMailerDrivers(t *testing.T) {
+	mailer, err := NewMailer(MailConfig{Driver: "Memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mailer.(*MemoryMailer); !ok {
+		t.Errorf("memory driver returned %T", mailer)
+	}
+
+	mailer, err = NewMailer(MailConfig{Driver: FileDriver, FileDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mailer.(*FileMailer); !ok {
+		t.Errorf("file driver returned %T", mailer)
+	}
+
+	if _, err = NewMailer(MailConfig{Driver: "carrier-pigeon"}); err == nil {
+		t.Error("expected an error for an unknown driver")
+	}
+}
+
+func TestMemoryMailerKeepsMessages(t *testing.T) {
+	mailer := NewMemoryMailer()
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		if err := mailer.Send(&Message{To: to, Subject: "hi", Body: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 2 || messages[0].To != "a@example.com" || messages[1].To != "b@example.com" {
+		t.Fatalf("messages = %+v", messages)
+	}
+
+	messages[0].To = "changed@example.com"
+	if mailer.Messages()[0].To != "a@example.com" {
+		t.Error("Messages returned the mailer's own slice")
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	msg := &Message{To: "user@example.com", Subject: "Welcome", Body: "line one\nline two"}
+
+	raw := string(msg.Bytes("no-reply@example.com"))
+	headers, body, ok := strings.Cut(raw, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("no header separator in %q", raw)
+	}
+
+	for _, header := range []string{
+		"From: no-reply@example.com",
+		"To: user@example.com",
+		"Subject: Welcome",
+		"Content-Type: text/plain; charset=UTF-8",
+	} {
+		if !strings.Contains(headers+"\r\n", header+"\r\n") {
+			t.Errorf("missing header %q in %q", header, headers)
+		}
+	}
+	if body != msg.Body {
+		t.Errorf("body = %q, want %q", body, msg.Body)
+	}
+}
+
+func TestFileMailerWritesMessages(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	mailer, err := NewFileMailer(dir, "no-reply@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mailer.Send(&Message{To: "user@example.com", Subject: "Welcome", Body: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("files = %v, err = %v", files, err)
+	}
+
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "To: user@example.com\r\n") || !strings.HasSuffix(string(raw), "hello") {
+		t.Errorf("unexpected message file %q", raw)
+	}
+}
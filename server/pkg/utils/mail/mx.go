@@ -0,0 +1,62 @@
+package mail
+
+import (
+	"context"
+	stderr "errors"
+	"net"
+	"time"
+
+	"github.com/skrpld/NearBeee/pkg/errors"
+	"github.com/skrpld/NearBeee/pkg/utils/cache"
+)
+
+const mxCacheSize = 10000
+
+type MXStage struct {
+	resolver *net.Resolver
+	timeout  time.Duration
+	cache    *cache.Cache[string, bool]
+}
+
+func NewMXStage(timeout, cacheTTL time.Duration) *MXStage {
+	return &MXStage{
+		resolver: net.DefaultResolver,
+		timeout:  timeout,
+		cache:    cache.New[string, bool](cacheTTL, mxCacheSize),
+	}
+}
+
+func (s *MXStage) Check(ctx context.Context, addr *Address) error {
+	hasMX, ok := s.cache.Get(addr.Domain)
+	if !ok {
+		var err error
+
+		hasMX, err = s.lookup(ctx, addr.Domain)
+		if err != nil {
+			// DNS trouble is not the user's fault, so the address is let through uncached.
+			return nil
+		}
+		s.cache.Set(addr.Domain, hasMX)
+	}
+
+	if !hasMX {
+		return errors.ErrInvalidEmail
+	}
+	return nil
+}
+
+func (s *MXStage) lookup(ctx context.Context, domain string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	records, err := s.resolver.LookupMX(ctx, domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if stderr.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return len(records) > 0, nil
+}
@@ -0,0 +1,229 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/skrpld/NearBeee/pkg/utils/oidc"
+	"github.com/skrpld/NearBeee/pkg/utils/oidc/oidctest"
+)
+
+const (
+	clientId    = "nearbeee"
+	redirectUrl = "https://nearbeee.test/api/auth/oidc/test/callback"
+)
+
+func newIssuer(t *testing.T) (*oidctest.Issuer, *oidc.Client) {
+	t.Helper()
+
+	issuer, err := oidctest.NewIssuer(oidctest.User{
+		Subject:       "subject-1",
+		Email:         "user@example.com",
+		EmailVerified: true,
+		Name:          "Test User",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+
+	client := oidc.NewClient(oidc.ProviderConfig{
+		Name:        "test",
+		Issuer:      issuer.URL(),
+		ClientId:    clientId,
+		RedirectUrl: redirectUrl,
+		Scopes:      []string{"openid", "email"},
+	}, issuer.Server.Client())
+
+	return issuer, client
+}
+
+// authorize follows the authorization URL and returns the code the issuer
+// redirected back with.
+func authorize(t *testing.T, issuer *oidctest.Issuer, authUrl string) (code, state string) {
+	t.Helper()
+
+	httpClient := issuer.Server.Client()
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	resp, err := httpClient.Get(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestDiscover(t *testing.T) {
+	issuer, client := newIssuer(t)
+
+	discovery, err := client.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if discovery.Issuer != issuer.URL() {
+		t.Errorf("issuer = %q, want %q", discovery.Issuer, issuer.URL())
+	}
+	if discovery.TokenEndpoint != issuer.URL()+"/token" || discovery.JWKSUri != issuer.URL()+"/jwks" {
+		t.Errorf("unexpected endpoints: %+v", discovery)
+	}
+}
+
+func TestDiscoverFailsForUnknownIssuer(t *testing.T) {
+	client := oidc.NewClient(oidc.ProviderConfig{Issuer: "http://127.0.0.1:1"}, nil)
+
+	if _, err := client.Discover(context.Background()); !errors.Is(err, oidc.ErrDiscoveryFailed) {
+		t.Errorf("err = %v, want %v", err, oidc.ErrDiscoveryFailed)
+	}
+}
+
+func TestPKCEExchange(t *testing.T) {
+	issuer, client := newIssuer(t)
+	ctx := context.Background()
+
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authUrl, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, state := authorize(t, issuer, authUrl)
+	if state != "state-1" {
+		t.Errorf("state = %q, want %q", state, "state-1")
+	}
+
+	token, err := client.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := client.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := oidc.IDTokenClaims{Subject: "subject-1", Email: "user@example.com", EmailVerified: true, Name: "Test User"}
+	if *claims != want {
+		t.Errorf("claims = %+v, want %+v", *claims, want)
+	}
+
+	if _, err = client.Exchange(ctx, code, verifier); !errors.Is(err, oidc.ErrExchangeFailed) {
+		t.Errorf("reused code: err = %v, want %v", err, oidc.ErrExchangeFailed)
+	}
+}
+
+func TestPKCEExchangeRejectsWrongVerifier(t *testing.T) {
+	issuer, client := newIssuer(t)
+	ctx := context.Background()
+
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authUrl, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _ := authorize(t, issuer, authUrl)
+
+	other, err := oidc.NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Exchange(ctx, code, other); !errors.Is(err, oidc.ErrExchangeFailed) {
+		t.Errorf("err = %v, want %v", err, oidc.ErrExchangeFailed)
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	issuer, client := newIssuer(t)
+	ctx := context.Background()
+
+	foreignKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		key       *rsa.PrivateKey
+		nonce     string
+		overrides jwt.MapClaims
+	}{
+		{name: "nonce", nonce: "other-nonce"},
+		{name: "audience", overrides: jwt.MapClaims{"aud": "someone-else"}},
+		{name: "issuer", overrides: jwt.MapClaims{"iss": "https://evil.example"}},
+		{name: "expired", overrides: jwt.MapClaims{"iat": expired.Add(-time.Minute).Unix(), "exp": expired.Unix()}},
+		{name: "missing expiry", overrides: jwt.MapClaims{"exp": nil}},
+		{name: "missing subject", overrides: jwt.MapClaims{"sub": nil}},
+		{name: "signature", key: foreignKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nonce := tt.nonce
+			if nonce == "" {
+				nonce = "nonce-1"
+			}
+
+			key := tt.key
+			var idToken string
+			if key != nil {
+				idToken, err = issuer.SignIDToken(key, clientId, nonce, tt.overrides)
+			} else {
+				idToken, err = issuer.IDToken(clientId, nonce, tt.overrides)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err = client.VerifyIDToken(ctx, idToken, "nonce-1"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Errorf("err = %v, want %v", err, oidc.ErrInvalidIDToken)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenAcceptsStringEmailVerified(t *testing.T) {
+	issuer, client := newIssuer(t)
+
+	idToken, err := issuer.IDToken(clientId, "nonce-1", jwt.MapClaims{"email_verified": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := client.VerifyIDToken(context.Background(), idToken, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.EmailVerified {
+		t.Error("email_verified \"true\" not accepted")
+	}
+}
@@ -0,0 +1,56 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type OIDCConfig struct {
+	Providers       string                    `env:"OIDC_PROVIDERS" mapstructure:"OIDC_PROVIDERS"`
+	StateTTL        time.Duration             `env:"OIDC_STATE_TTL" env-default:"10m" mapstructure:"OIDC_STATE_TTL"`
+	ProviderConfigs map[string]ProviderConfig `mapstructure:"-"`
+}
+
+type ProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+}
+
+func LoadProviderConfigs(providers string, get func(key string) string) (map[string]ProviderConfig, error) {
+	configs := make(map[string]ProviderConfig)
+
+	for _, name := range strings.Split(providers, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		cfg := ProviderConfig{
+			Name:         name,
+			Issuer:       strings.TrimSuffix(get(prefix+"ISSUER"), "/"),
+			ClientId:     get(prefix + "CLIENT_ID"),
+			ClientSecret: get(prefix + "CLIENT_SECRET"),
+			RedirectUrl:  get(prefix + "REDIRECT_URL"),
+			Scopes:       []string{"openid", "email", "profile"},
+		}
+
+		if scopes := get(prefix + "SCOPES"); scopes != "" {
+			cfg.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+
+		if cfg.Issuer == "" || cfg.ClientId == "" || cfg.RedirectUrl == "" {
+			return nil, fmt.Errorf("oidc: provider %q requires %sISSUER, %sCLIENT_ID and %sREDIRECT_URL", name, prefix, prefix, prefix)
+		}
+
+		configs[name] = cfg
+	}
+
+	return configs, nil
+}
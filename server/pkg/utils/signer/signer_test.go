@@ -0,0 +1,75 @@
+package signer
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type payload struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	s := New("secret", "purpose")
+
+	token, err := s.Encode(payload{Name: "cursor", Count: 3})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	var got payload
+	if err = s.Decode(token, &got); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got != (payload{Name: "cursor", Count: 3}) {
+		t.Errorf("Decode() = %+v", got)
+	}
+}
+
+func TestSignerRejectsOtherKeys(t *testing.T) {
+	token, err := New("secret", "purpose").Encode(payload{Name: "cursor"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	others := map[string]*Signer{
+		"other secret":  New("other", "purpose"),
+		"other purpose": New("secret", "other"),
+	}
+	for name, other := range others {
+		var got payload
+		if err = other.Decode(token, &got); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: Decode() error = %v, want %v", name, err, ErrInvalidSignature)
+		}
+	}
+}
+
+func TestSignerRejectsTampering(t *testing.T) {
+	s := New("secret", "purpose")
+
+	token, err := s.Encode(payload{Name: "cursor", Count: 3})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	encoded, signature, _ := strings.Cut(token, ".")
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"name":"cursor","count":4}`))
+
+	tokens := map[string]string{
+		"empty":           "",
+		"no signature":    encoded,
+		"empty signature": encoded + ".",
+		"forged payload":  forged + "." + signature,
+		"other signature": encoded + "." + s.sign(forged),
+		"bad encoding":    "!!!." + s.sign("!!!"),
+	}
+	for name, token := range tokens {
+		var got payload
+		if err = s.Decode(token, &got); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: Decode() error = %v, want %v", name, err, ErrInvalidSignature)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type StorageConfig struct {
+	Driver    string `env:"STORAGE_DRIVER" env-default:"local" mapstructure:"STORAGE_DRIVER"`
+	LocalDir  string `env:"STORAGE_LOCAL_DIR" env-default:"./media" mapstructure:"STORAGE_LOCAL_DIR"`
+	PublicUrl string `env:"STORAGE_PUBLIC_URL" env-default:"/media" mapstructure:"STORAGE_PUBLIC_URL"`
+}
+
+const LocalDriver = "local"
+
+type Storage interface {
+	Save(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	URL(key string) string
+}
+
+func NewStorage(cfg StorageConfig) (Storage, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case LocalDriver:
+		return NewLocalStorage(cfg.LocalDir, cfg.PublicUrl)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}